@@ -0,0 +1,239 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/blades"
+
+	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/internal/prompts"
+	"github.com/oneblade/service"
+)
+
+// DefaultCollectTimeout 单个 service 采集的默认超时
+const DefaultCollectTimeout = 60 * time.Second
+
+// MetadataEvidenceBundle 采集证据在消息 Metadata 中的 key
+const MetadataEvidenceBundle = "evidence_bundle"
+
+// EvidenceStatus 单个 service 的采集状态
+type EvidenceStatus string
+
+const (
+	EvidenceOK      EvidenceStatus = "ok"
+	EvidenceError   EvidenceStatus = "error"
+	EvidenceTimeout EvidenceStatus = "timeout"
+)
+
+// Evidence 单个 service 的采集结果
+type Evidence struct {
+	Service    string         `json:"service"`
+	Type       string         `json:"type"`
+	Status     EvidenceStatus `json:"status"`
+	Summary    string         `json:"summary,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+}
+
+// EvidenceBundle 采集阶段汇总的证据，按 service 的顺序排列
+type EvidenceBundle struct {
+	CollectedAt time.Time  `json:"collected_at"`
+	Evidence    []Evidence `json:"evidence"`
+}
+
+// EvidenceFromMessage 返回 collection_agent 消息中的采集证据
+func EvidenceFromMessage(msg *blades.Message) (*EvidenceBundle, bool) {
+	bundle, ok := msg.Metadata[MetadataEvidenceBundle].(*EvidenceBundle)
+	return bundle, ok
+}
+
+// Render 将采集证据渲染为 Markdown，作为预测与报告阶段的输入
+func (b *EvidenceBundle) Render() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## 采集证据\n\n**采集时间**：%s\n", b.CollectedAt.Format(time.RFC3339))
+	for _, e := range b.Evidence {
+		fmt.Fprintf(&sb, "\n### %s (%s)\n\n**状态**：%s　**耗时**：%dms\n", e.Service, e.Type, evidenceStatusLabel(e.Status), e.DurationMs)
+		if e.Error != "" {
+			fmt.Fprintf(&sb, "**错误**：%s\n", e.Error)
+		}
+		if e.Summary != "" {
+			fmt.Fprintf(&sb, "\n%s\n", e.Summary)
+		}
+	}
+	return sb.String()
+}
+
+func evidenceStatusLabel(status EvidenceStatus) string {
+	switch status {
+	case EvidenceOK:
+		return "成功"
+	case EvidenceTimeout:
+		return "超时"
+	default:
+		return "失败"
+	}
+}
+
+type CollectionConfig struct {
+	Model    blades.ModelProvider
+	Services []service.Service
+	// Timeouts 按 service 名称配置的采集超时，未配置时使用 DefaultCollectTimeout
+	Timeouts map[string]time.Duration
+	// Prompts 提示词模板，为空时使用内置模板
+	Prompts *prompts.Set
+}
+
+// collector 只能调用单个 service 的采集 agent
+type collector struct {
+	service service.Service
+	agent   blades.Agent
+	timeout time.Duration
+}
+
+// collectionAgent 并行运行各 service 的 collector，并将结果合并为一条采集证据消息
+// collector 的中间消息（工具调用等）不会写入会话，后续 agent 只看到合并后的证据。
+type collectionAgent struct {
+	collectors []*collector
+}
+
+func NewCollectionAgent(cfg CollectionConfig) (blades.Agent, error) {
+	if len(cfg.Services) == 0 {
+		return nil, fmt.Errorf("collection agent requires at least one service")
+	}
+
+	collectors := make([]*collector, 0, len(cfg.Services))
+	for _, s := range cfg.Services {
+		tool, err := s.AsTool()
+		if err != nil {
+			return nil, fmt.Errorf("create tool for %s: %v", s.Name(), err)
+		}
+		agent, err := blades.NewAgent(
+			"collector_"+s.Name(),
+			blades.WithDescription(fmt.Sprintf("采集 %s 的巡检数据", s.Name())),
+			blades.WithInstructionProvider(promptInstruction(cfg.Prompts, prompts.Collector, []service.Service{s})),
+			blades.WithModel(cfg.Model),
+			blades.WithTools(tool),
+			blades.WithMiddleware(
+				middleware.NewAgentLogging,
+				middleware.LoadSessionHistory(),
+			),
+		)
+		if err != nil {
+			return nil, err
+		}
+		timeout := cfg.Timeouts[s.Name()]
+		if timeout <= 0 {
+			timeout = DefaultCollectTimeout
+		}
+		collectors = append(collectors, &collector{service: s, agent: agent, timeout: timeout})
+	}
+	return &collectionAgent{collectors: collectors}, nil
+}
+
+func (a *collectionAgent) Name() string {
+	return consts.AgentNameCollection
+}
+
+func (a *collectionAgent) Description() string {
+	return consts.CollectionAgentDescription
+}
+
+func (a *collectionAgent) Run(ctx context.Context, invocation *blades.Invocation) blades.Generator[*blades.Message, error] {
+	return func(yield func(*blades.Message, error) bool) {
+		start := time.Now()
+		bundle := &EvidenceBundle{
+			CollectedAt: start,
+			Evidence:    make([]Evidence, len(a.collectors)),
+		}
+
+		var wg sync.WaitGroup
+		for i, c := range a.collectors {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				bundle.Evidence[i] = c.collect(ctx, invocation.Clone())
+			}()
+		}
+		wg.Wait()
+
+		// 整个流程被取消时不产出不完整的证据
+		if err := ctx.Err(); err != nil {
+			yield(nil, err)
+			return
+		}
+
+		failed := 0
+		for _, e := range bundle.Evidence {
+			if e.Status != EvidenceOK {
+				failed++
+			}
+		}
+		slog.Info("collection.complete",
+			"collectors", len(a.collectors),
+			"failed", failed,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+
+		msg := blades.NewAssistantMessage(blades.StatusCompleted)
+		msg.Author = a.Name()
+		msg.InvocationID = invocation.ID
+		msg.Parts = []blades.Part{blades.TextPart{Text: bundle.Render()}}
+		msg.Metadata[MetadataEvidenceBundle] = bundle
+		yield(msg, nil)
+	}
+}
+
+// collect 在独立的超时内运行 collector，取最后一条完成的 assistant 消息作为采集摘要
+func (c *collector) collect(ctx context.Context, invocation *blades.Invocation) Evidence {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	var (
+		last *blades.Message
+		err  error
+	)
+	for msg, e := range c.agent.Run(ctx, invocation) {
+		if e != nil {
+			err = e
+			break
+		}
+		if msg != nil && msg.Role == blades.RoleAssistant && msg.Status == blades.StatusCompleted {
+			last = msg
+		}
+	}
+
+	evidence := Evidence{
+		Service:    c.service.Name(),
+		Type:       string(c.service.Type()),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		evidence.Status = EvidenceTimeout
+		evidence.Error = fmt.Sprintf("collection timed out after %s", c.timeout)
+	case err != nil:
+		evidence.Status = EvidenceError
+		evidence.Error = err.Error()
+	case last == nil || strings.TrimSpace(last.Text()) == "":
+		evidence.Status = EvidenceError
+		evidence.Error = "collector returned no output"
+	default:
+		evidence.Status = EvidenceOK
+		evidence.Summary = strings.TrimSpace(last.Text())
+	}
+
+	slog.Info("collection.collector.complete",
+		"service", evidence.Service,
+		"status", evidence.Status,
+		"duration_ms", evidence.DurationMs,
+	)
+	return evidence
+}
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/internal/llm"
+	"github.com/oneblade/service"
+)
+
+func TestCollectionAgent_Run(t *testing.T) {
+	collection, err := NewCollectionAgent(CollectionConfig{
+		Model: mockModel(t, "collection.json"),
+		Services: []service.Service{
+			fakeService{name: "prom", result: `{"cpu_usage": 0.45}`},
+			fakeService{name: "pd", typ: service.PagerDuty},
+			fakeService{name: "logs", typ: service.OpenSearch, block: true},
+		},
+		Timeouts: map[string]time.Duration{"logs": 50 * time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	runner := NewInspectionRunner(collection)
+	msg, err := runner.Run(context.Background(), blades.UserMessage("开始巡检"))
+	require.NoError(t, err)
+	assert.Equal(t, consts.AgentNameCollection, msg.Author)
+
+	bundle, ok := EvidenceFromMessage(msg)
+	require.True(t, ok)
+	require.Len(t, bundle.Evidence, 3)
+
+	// 证据按 service 的配置顺序排列，与完成先后无关
+	prom, pd, logs := bundle.Evidence[0], bundle.Evidence[1], bundle.Evidence[2]
+	assert.Equal(t, "prom", prom.Service)
+	assert.Equal(t, EvidenceOK, prom.Status)
+	assert.Equal(t, "CPU 使用率 45%，未发现异常。", prom.Summary)
+
+	assert.Equal(t, "pd", pd.Service)
+	assert.Equal(t, "pagerduty", pd.Type)
+	assert.Equal(t, EvidenceError, pd.Status)
+	assert.Contains(t, pd.Error, "upstream unavailable")
+
+	assert.Equal(t, "logs", logs.Service)
+	assert.Equal(t, EvidenceTimeout, logs.Status)
+	assert.Equal(t, "collection timed out after 50ms", logs.Error)
+
+	text := msg.Text()
+	assert.Contains(t, text, "## 采集证据")
+	assert.Contains(t, text, "### prom (prometheus)")
+	assert.Contains(t, text, "**状态**：超时")
+}
+
+func TestCollectionAgent_Cancelled(t *testing.T) {
+	collection, err := NewCollectionAgent(CollectionConfig{
+		Model:    mockModel(t, "collection.json"),
+		Services: []service.Service{fakeService{name: "logs", typ: service.OpenSearch, block: true}},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = NewInspectionRunner(collection).Run(ctx, blades.UserMessage("开始巡检"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNewCollectionAgent_RequiresServices(t *testing.T) {
+	_, err := NewCollectionAgent(CollectionConfig{Model: mockModel(t, "collection.json")})
+	require.Error(t, err)
+}
+
+func TestNewOrchestratorAgent_AnalysisFlowCollectsEvidence(t *testing.T) {
+	registry := llm.NewModelRegistry()
+	for _, name := range []string{
+		consts.AgentNameOrchestrator,
+		consts.AgentNameService,
+		consts.AgentNamePrediction,
+		consts.AgentNameReport,
+	} {
+		registry.Register(name, mockModel(t, "analysis_flow.json"))
+	}
+
+	orchestrator, err := NewOrchestratorAgent(OrchestratorConfig{
+		ModelRegistry: registry,
+		Services:      []service.Service{fakeService{name: "prom", result: `{"cpu_usage": 0.45}`}},
+		EnabledAgents: []string{consts.AgentNameService, consts.AgentNamePrediction, consts.AgentNameReport},
+	})
+	require.NoError(t, err)
+
+	session := blades.NewSession()
+	runner := NewInspectionRunner(orchestrator)
+	msg, err := runner.Run(context.Background(), blades.UserMessage("执行一次完整巡检"), blades.WithSession(session))
+	require.NoError(t, err)
+	assert.Equal(t, consts.AgentNameReport, msg.Author)
+	assert.Contains(t, msg.Text(), "所有服务运行正常")
+
+	// 会话中只保留合并后的证据，不包含 collector 的工具调用过程
+	var authors []string
+	for _, m := range session.History() {
+		if m.Role == blades.RoleAssistant {
+			authors = append(authors, m.Author)
+		}
+	}
+	assert.Equal(t, []string{consts.AgentNameCollection, consts.AgentNamePrediction, consts.AgentNameReport}, authors)
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tools"
+
+	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/service"
+)
+
+// CustomAgentSpec 配置中声明的自定义 agent
+type CustomAgentSpec struct {
+	Description string
+	Instruction string
+	// Services 允许调用的 service 名称
+	Services []string
+	// Tools 允许调用的内置工具名称
+	Tools []string
+	// Middleware 启用的中间件名称，nil 时使用 defaultCustomMiddleware
+	Middleware []string
+}
+
+type CustomAgentConfig struct {
+	Name  string
+	Model blades.ModelProvider
+	Spec  CustomAgentSpec
+	// Services 与 Tools 为全部可用的 service 和内置工具，按 Spec 中的名称筛选
+	Services []service.Service
+	Tools    []tools.Tool
+}
+
+// customMiddlewares 自定义 agent 可通过名称启用的中间件
+var customMiddlewares = map[string]blades.Middleware{
+	"logging": middleware.NewAgentLogging,
+	"history": middleware.LoadSessionHistory(),
+}
+
+// defaultCustomMiddleware 与内置 agent 保持一致：记录日志并加载会话历史
+var defaultCustomMiddleware = []string{"logging", "history"}
+
+func NewCustomAgent(cfg CustomAgentConfig) (blades.Agent, error) {
+	var services []service.Service
+	for _, name := range cfg.Spec.Services {
+		i := slices.IndexFunc(cfg.Services, func(s service.Service) bool { return s.Name() == name })
+		if i < 0 {
+			return nil, fmt.Errorf("agent %s: service %s not found", cfg.Name, name)
+		}
+		services = append(services, cfg.Services[i])
+	}
+	agentTools, serviceDescriptions, err := buildServiceTools(services)
+	if err != nil {
+		return nil, fmt.Errorf("agent %s: %w", cfg.Name, err)
+	}
+
+	for _, name := range cfg.Spec.Tools {
+		i := slices.IndexFunc(cfg.Tools, func(t tools.Tool) bool { return t.Name() == name })
+		if i < 0 {
+			return nil, fmt.Errorf("agent %s: tool %s not found", cfg.Name, name)
+		}
+		agentTools = append(agentTools, cfg.Tools[i])
+	}
+
+	instruction := cfg.Spec.Instruction
+	if len(serviceDescriptions) > 0 {
+		instruction += "\n\n可用的服务工具：\n" + strings.Join(serviceDescriptions, "\n")
+	}
+
+	names := cfg.Spec.Middleware
+	if names == nil {
+		names = defaultCustomMiddleware
+	}
+	var middlewares []blades.Middleware
+	for _, name := range names {
+		m, ok := customMiddlewares[name]
+		if !ok {
+			return nil, fmt.Errorf("agent %s: unknown middleware %s", cfg.Name, name)
+		}
+		middlewares = append(middlewares, m)
+	}
+
+	return blades.NewAgent(
+		cfg.Name,
+		blades.WithDescription(cfg.Spec.Description),
+		blades.WithInstruction(instruction),
+		blades.WithModel(cfg.Model),
+		blades.WithTools(agentTools...),
+		blades.WithMiddleware(middlewares...),
+	)
+}
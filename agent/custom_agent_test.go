@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/internal/llm"
+	"github.com/oneblade/service"
+)
+
+// fakeService 以固定结果响应工具调用的 service
+type fakeService struct {
+	name   string
+	typ    service.ServiceType
+	result string
+	// block 为 true 时工具调用阻塞直到 ctx 结束，用于模拟超时
+	block bool
+}
+
+func (s fakeService) Name() string { return s.name }
+func (s fakeService) Type() service.ServiceType {
+	if s.typ == "" {
+		return service.Prometheus
+	}
+	return s.typ
+}
+func (s fakeService) Description() string              { return "核心集群监控" }
+func (s fakeService) Health(ctx context.Context) error { return nil }
+func (s fakeService) Close() error                     { return nil }
+func (s fakeService) AsTool() (tools.Tool, error) {
+	return tools.NewTool(s.name, s.Description(), tools.HandleFunc(func(ctx context.Context, _ string) (string, error) {
+		if s.block {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return s.result, nil
+	})), nil
+}
+
+func TestNewOrchestratorAgent_HandoffToCustomAgent(t *testing.T) {
+	registry := llm.NewModelRegistry()
+	registry.Register(consts.AgentNameOrchestrator, mockModel(t, "handoff_custom.json"))
+	registry.Register("capacity_agent", mockModel(t, "handoff_custom.json"))
+
+	orchestrator, err := NewOrchestratorAgent(OrchestratorConfig{
+		ModelRegistry: registry,
+		Services:      []service.Service{fakeService{name: "prom", result: `{"disk_usage": 0.72}`}},
+		EnabledAgents: []string{"capacity_agent"},
+		CustomAgents: map[string]CustomAgentSpec{
+			"capacity_agent": {
+				Description: "容量规划与扩容评估",
+				Instruction: "你是容量规划专家。",
+				Services:    []string{"prom"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	runner := NewInspectionRunner(orchestrator)
+	msg, err := runner.Run(context.Background(), blades.UserMessage("评估磁盘容量"))
+	require.NoError(t, err)
+	assert.Equal(t, "capacity_agent", msg.Author)
+	assert.Contains(t, msg.Text(), "无需扩容")
+}
+
+func TestNewOrchestratorAgent_UndeclaredAgent(t *testing.T) {
+	registry := llm.NewModelRegistry()
+	registry.Register(consts.AgentNameOrchestrator, mockModel(t, "handoff_custom.json"))
+	registry.Register("capacity_agent", mockModel(t, "handoff_custom.json"))
+
+	_, err := NewOrchestratorAgent(OrchestratorConfig{
+		ModelRegistry: registry,
+		EnabledAgents: []string{"capacity_agent"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "neither built-in nor declared")
+}
+
+func TestNewCustomAgent(t *testing.T) {
+	memoryTool := tools.NewTool("Memory", "memory", nil)
+
+	tests := []struct {
+		name    string
+		spec    CustomAgentSpec
+		wantErr string
+	}{
+		{
+			name: "tools and middleware",
+			spec: CustomAgentSpec{
+				Description: "d",
+				Instruction: "i",
+				Tools:       []string{"Memory"},
+				Middleware:  []string{"logging"},
+			},
+		},
+		{
+			name:    "unknown service",
+			spec:    CustomAgentSpec{Description: "d", Instruction: "i", Services: []string{"missing"}},
+			wantErr: "service missing not found",
+		},
+		{
+			name:    "unknown tool",
+			spec:    CustomAgentSpec{Description: "d", Instruction: "i", Tools: []string{"missing"}},
+			wantErr: "tool missing not found",
+		},
+		{
+			name:    "unknown middleware",
+			spec:    CustomAgentSpec{Description: "d", Instruction: "i", Middleware: []string{"tracing"}},
+			wantErr: "unknown middleware tracing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent, err := NewCustomAgent(CustomAgentConfig{
+				Name:  "capacity_agent",
+				Model: llm.NewMockProvider("mock", &llm.Fixture{}),
+				Spec:  tt.spec,
+				Tools: []tools.Tool{memoryTool},
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "capacity_agent", agent.Name())
+			assert.Equal(t, tt.spec.Description, agent.Description())
+		})
+	}
+}
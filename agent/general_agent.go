@@ -8,11 +8,14 @@ import (
 
 	"github.com/oneblade/internal/consts"
 	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/internal/prompts"
 )
 
 type GeneralAgentConfig struct {
 	Model blades.ModelProvider
 	Tools []tools.Tool
+	// Prompts 提示词模板，为空时使用内置模板
+	Prompts *prompts.Set
 }
 
 func NewGeneralAgent(cfg GeneralAgentConfig) (blades.Agent, error) {
@@ -23,7 +26,7 @@ func NewGeneralAgent(cfg GeneralAgentConfig) (blades.Agent, error) {
 	return blades.NewAgent(
 		consts.AgentNameGeneral,
 		blades.WithDescription(consts.GeneralAgentDescription),
-		blades.WithInstruction(consts.GeneralAgentInstruction),
+		blades.WithInstructionProvider(promptInstruction(cfg.Prompts, prompts.GeneralAgent, nil)),
 		blades.WithModel(cfg.Model),
 		blades.WithTools(cfg.Tools...),
 		blades.WithMiddleware(
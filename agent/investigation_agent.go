@@ -0,0 +1,524 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tools"
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/internal/prompts"
+	"github.com/oneblade/service"
+)
+
+// 事件调查的默认参数
+const (
+	// DefaultInvestigationIterations 调查阶段默认最多执行的查询次数
+	DefaultInvestigationIterations = 8
+	// DefaultInvestigationWindow 默认以事件触发时间为中心前后各查询的时长
+	DefaultInvestigationWindow = 30 * time.Minute
+)
+
+// 事件调查在消息 Metadata 中的 key，值分别为 *Incident、[]InvestigationQuery 与 *InvestigationFindings
+const (
+	MetadataIncident              = "incident"
+	MetadataInvestigationQueries  = "investigation_queries"
+	MetadataInvestigationFindings = "investigation_findings"
+)
+
+// EvidenceRefIncident 引用事件本身信息的证据编号
+const EvidenceRefIncident = "incident"
+
+// Incident 调查的 PagerDuty 事件
+type Incident struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Status      string `json:"status"`
+	Urgency     string `json:"urgency"`
+	ServiceName string `json:"service_name"`
+	CreatedAt   string `json:"created_at"`
+	HTMLURL     string `json:"html_url"`
+}
+
+// InvestigationQuery 调查阶段的一次查询
+type InvestigationQuery struct {
+	ID      string `json:"id"`
+	Service string `json:"service"`
+	Type    string `json:"type"`
+	Request string `json:"request"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	Link    string `json:"link,omitempty"`
+}
+
+// InvestigationFindings investigation_agent 的结构化调查结论
+type InvestigationFindings struct {
+	IncidentID      string       `json:"incident_id" jsonschema:"调查的 PagerDuty incident ID"`
+	Summary         string       `json:"summary" jsonschema:"调查过程与结论摘要"`
+	RootCause       string       `json:"root_cause" jsonschema:"最可能的根因；没有假设被证实时说明根因尚未确定"`
+	Confidence      float64      `json:"confidence" jsonschema:"对根因结论的置信度，0-1"`
+	Hypotheses      []Hypothesis `json:"hypotheses" jsonschema:"提出并验证过的假设"`
+	Recommendations []string     `json:"recommendations" jsonschema:"止血与后续改进建议"`
+}
+
+// Hypothesis 调查中的单个假设
+type Hypothesis struct {
+	Statement string        `json:"statement" jsonschema:"假设内容"`
+	Status    string        `json:"status" jsonschema:"验证结果"`
+	Evidence  []EvidenceRef `json:"evidence" jsonschema:"支持或否定该假设的证据"`
+}
+
+// EvidenceRef 引用查询记录的证据；Link 由查询记录补全，不由模型生成
+type EvidenceRef struct {
+	Ref     string `json:"ref" jsonschema:"查询编号，如 Q1；来自事件本身的信息为 incident"`
+	Summary string `json:"summary" jsonschema:"该证据说明了什么"`
+	Link    string `json:"link,omitempty"`
+}
+
+var investigationFindingsSchema = mustSchema[InvestigationFindings]("investigation_findings", "事件调查结论", func(s *jsonschema.Schema) {
+	s.Properties["confidence"].Minimum = float64Ptr(0)
+	s.Properties["confidence"].Maximum = float64Ptr(1)
+	hypothesis := s.Properties["hypotheses"].Items
+	hypothesis.Properties["status"].Enum = []any{"confirmed", "rejected", "inconclusive"}
+	delete(hypothesis.Properties["evidence"].Items.Properties, "link")
+})
+
+// FindingsFromMessage 返回 investigation_agent 消息中的结构化调查结论
+func FindingsFromMessage(msg *blades.Message) (*InvestigationFindings, bool) {
+	findings, ok := msg.Metadata[MetadataInvestigationFindings].(*InvestigationFindings)
+	return findings, ok
+}
+
+// Render 将调查结论渲染为 Markdown
+func (f *InvestigationFindings) Render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## 事件调查结论：%s\n\n**根因**：%s（置信度 %.0f%%）\n\n%s\n", f.IncidentID, f.RootCause, f.Confidence*100, f.Summary)
+	if len(f.Hypotheses) > 0 {
+		b.WriteString("\n### 假设验证\n\n")
+		for _, h := range f.Hypotheses {
+			fmt.Fprintf(&b, "- [%s] %s\n", hypothesisLabel(h.Status), h.Statement)
+			for _, e := range h.Evidence {
+				fmt.Fprintf(&b, "  - [%s] %s", e.Ref, e.Summary)
+				if e.Link != "" {
+					fmt.Fprintf(&b, "（[链接](%s)）", e.Link)
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+	writeList(&b, "建议", f.Recommendations)
+	return b.String()
+}
+
+func hypothesisLabel(s string) string {
+	return label(s, map[string]string{"confirmed": "证实", "rejected": "排除", "inconclusive": "无法确定"})
+}
+
+type InvestigationConfig struct {
+	Model blades.ModelProvider
+	// Services 已启用的 service，必须包含 PagerDuty；全部作为调查阶段的查询工具
+	Services []service.Service
+	// Prompts 提示词模板，为空时使用内置模板
+	Prompts *prompts.Set
+	// MaxIterations 调查阶段最多执行的查询次数，默认 DefaultInvestigationIterations
+	MaxIterations int
+	// Window 以事件触发时间为中心前后各查询的时长，默认 DefaultInvestigationWindow
+	Window time.Duration
+}
+
+// investigationAgent 调查单个 PagerDuty 事件：
+// 先直接拉取事件详情，再由调查员在查询预算内提出并验证假设，最后汇总为结构化结论。
+type investigationAgent struct {
+	pagerduty     tools.Tool
+	investigator  blades.Agent
+	findings      blades.Agent
+	maxIterations int
+	window        time.Duration
+}
+
+func NewInvestigationAgent(cfg InvestigationConfig) (blades.Agent, error) {
+	var pagerduty tools.Tool
+	queryTools := make([]tools.Tool, 0, len(cfg.Services))
+	for _, s := range cfg.Services {
+		tool, err := s.AsTool()
+		if err != nil {
+			return nil, fmt.Errorf("create tool for %s: %v", s.Name(), err)
+		}
+		if s.Type() == service.PagerDuty && pagerduty == nil {
+			pagerduty = tool
+		}
+		if _, ok := investigationOperations[s.Type()]; ok {
+			queryTools = append(queryTools, &budgetTool{Tool: tool, service: s})
+		}
+	}
+	if pagerduty == nil {
+		return nil, fmt.Errorf("investigation agent requires a pagerduty service")
+	}
+
+	maxIterations := cfg.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultInvestigationIterations
+	}
+	window := cfg.Window
+	if window <= 0 {
+		window = DefaultInvestigationWindow
+	}
+
+	investigator, err := blades.NewAgent(
+		prompts.Investigator,
+		blades.WithDescription(consts.InvestigationAgentDescription),
+		blades.WithInstructionProvider(promptInstruction(cfg.Prompts, prompts.Investigator, cfg.Services)),
+		blades.WithModel(cfg.Model),
+		blades.WithTools(queryTools...),
+		// 每次查询占用一轮模型调用，额外留出得出结论的轮次
+		blades.WithMaxIterations(maxIterations+2),
+		blades.WithMiddleware(
+			middleware.NewAgentLogging,
+			middleware.LoadSessionHistory(),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	findings, err := blades.NewAgent(
+		prompts.InvestigationFindings,
+		blades.WithDescription(consts.InvestigationAgentDescription),
+		blades.WithInstructionProvider(promptInstruction(cfg.Prompts, prompts.InvestigationFindings, cfg.Services)),
+		blades.WithModel(cfg.Model),
+		blades.WithOutputSchema(investigationFindingsSchema),
+		blades.WithMiddleware(
+			middleware.NewAgentLogging,
+			middleware.LoadSessionHistory(),
+			structuredOutput[InvestigationFindings](MetadataInvestigationFindings),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &investigationAgent{
+		pagerduty:     pagerduty,
+		investigator:  investigator,
+		findings:      findings,
+		maxIterations: maxIterations,
+		window:        window,
+	}, nil
+}
+
+func (a *investigationAgent) Name() string {
+	return consts.AgentNameInvestigation
+}
+
+func (a *investigationAgent) Description() string {
+	return consts.InvestigationAgentDescription
+}
+
+func (a *investigationAgent) Run(ctx context.Context, invocation *blades.Invocation) blades.Generator[*blades.Message, error] {
+	return func(yield func(*blades.Message, error) bool) {
+		var text string
+		if invocation.Message != nil {
+			text = invocation.Message.Text()
+		}
+		id := incidentID(text)
+		if id == "" {
+			msg := a.message(invocation, "请提供要调查的 PagerDuty incident ID。")
+			msg.Metadata[MetadataClarification] = true
+			yield(msg, nil)
+			return
+		}
+
+		incident, err := fetchIncident(ctx, a.pagerduty, id)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		brief := a.message(invocation, a.renderBrief(incident))
+		brief.Metadata[MetadataIncident] = incident
+		if !yield(brief, nil) {
+			return
+		}
+
+		log := &queryLog{limit: a.maxIterations}
+		ctx = context.WithValue(ctx, queryLogKey{}, log)
+		for msg, err := range a.investigator.Run(ctx, invocation.Clone()) {
+			// 模型在预算用尽后仍继续调用工具时达到迭代上限，视为调查结束，基于已有证据给出结论
+			if errors.Is(err, blades.ErrMaxIterationsExceeded) {
+				slog.Warn("investigation.iterations.exceeded",
+					"incident", incident.ID,
+					"limit", a.maxIterations,
+				)
+				break
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(msg, nil) {
+				return
+			}
+		}
+
+		queries := log.snapshot()
+		slog.Info("investigation.queries.complete",
+			"incident", incident.ID,
+			"queries", len(queries),
+			"limit", a.maxIterations,
+		)
+		record := a.message(invocation, renderQueries(queries))
+		record.Metadata[MetadataInvestigationQueries] = queries
+		if !yield(record, nil) {
+			return
+		}
+
+		// 结论阶段只输出结构化 JSON，不继承路由注入的指令与工具
+		findingsInvocation := invocation.Clone()
+		findingsInvocation.Instruction = nil
+		findingsInvocation.Tools = nil
+		for msg, err := range a.findings.Run(ctx, findingsInvocation) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if findings, ok := FindingsFromMessage(msg); ok {
+				linkEvidence(findings, incident, queries)
+				msg.Author = a.Name()
+				msg.Parts = []blades.Part{blades.TextPart{Text: findings.Render()}}
+			}
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	}
+}
+
+// fetchIncident 直接调用 PagerDuty 获取事件详情，不经过模型
+func fetchIncident(ctx context.Context, pagerduty tools.Tool, id string) (*Incident, error) {
+	input, err := json.Marshal(map[string]any{
+		"operation":    "get_incident",
+		"get_incident": map[string]string{"incident_id": id},
+	})
+	if err != nil {
+		return nil, err
+	}
+	output, err := pagerduty.Handle(ctx, string(input))
+	if err != nil {
+		return nil, fmt.Errorf("get incident %s: %w", id, err)
+	}
+	var resp struct {
+		Success  bool      `json:"success"`
+		Message  string    `json:"message"`
+		Incident *Incident `json:"incident"`
+	}
+	if err := json.Unmarshal([]byte(output), &resp); err != nil {
+		return nil, fmt.Errorf("get incident %s: decode response: %w", id, err)
+	}
+	if !resp.Success || resp.Incident == nil {
+		return nil, fmt.Errorf("get incident %s failed: %s", id, resp.Message)
+	}
+	return resp.Incident, nil
+}
+
+// renderBrief 渲染事件基本信息与调查约束，作为调查员与结论阶段的输入
+func (a *investigationAgent) renderBrief(incident *Incident) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## 事件调查：%s\n\n", incident.Title)
+	fmt.Fprintf(&b, "- **Incident ID**：%s\n", incident.ID)
+	fmt.Fprintf(&b, "- **服务**：%s\n", incident.ServiceName)
+	fmt.Fprintf(&b, "- **状态**：%s（紧急程度 %s）\n", incident.Status, incident.Urgency)
+	fmt.Fprintf(&b, "- **触发时间**：%s\n", incident.CreatedAt)
+	if incident.HTMLURL != "" {
+		fmt.Fprintf(&b, "- **链接**：%s\n", incident.HTMLURL)
+	}
+	if triggered, err := time.Parse(time.RFC3339, incident.CreatedAt); err == nil {
+		fmt.Fprintf(&b, "- **调查时间窗口**：%s ~ %s\n",
+			triggered.Add(-a.window).Format(time.RFC3339),
+			triggered.Add(a.window).Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "- **查询预算**：%d 次\n", a.maxIterations)
+	return b.String()
+}
+
+func (a *investigationAgent) message(invocation *blades.Invocation, text string) *blades.Message {
+	msg := blades.NewAssistantMessage(blades.StatusCompleted)
+	msg.Author = a.Name()
+	msg.InvocationID = invocation.ID
+	msg.Parts = []blades.Part{blades.TextPart{Text: text}}
+	return msg
+}
+
+// incidentContext 匹配 incident / 事件 / # 之后的 ID，如 "incident p1abc23"、"事件：PABC123"、"#Q1P3ROB6JVKTZQ"
+var incidentContext = regexp.MustCompile(`(?i)(?:incident|事件|#)\s*(?:id)?\s*[:：]?\s*([a-z0-9]{6,})\b`)
+
+// incidentCandidate 匹配没有上下文的 PagerDuty incident ID：以 P 或 Q 开头的字母数字串，忽略大小写
+var incidentCandidate = regexp.MustCompile(`(?i)\b[PQ][A-Z0-9]{5,}\b`)
+
+// incidentID 返回消息中的 PagerDuty incident ID（统一转为大写）
+// 优先取 incident / 事件 / # 之后的 ID，否则取第一个 P/Q 开头的候选；候选必须同时包含字母与数字，
+// 避免把 CHECKOUT、HTTP500X、v2026rc1 之类的单词或版本号当作 ID。
+func incidentID(text string) string {
+	for _, m := range incidentContext.FindAllStringSubmatch(text, -1) {
+		if isIncidentID(m[1]) {
+			return strings.ToUpper(m[1])
+		}
+	}
+	for _, candidate := range incidentCandidate.FindAllString(text, -1) {
+		if isIncidentID(candidate) {
+			return strings.ToUpper(candidate)
+		}
+	}
+	return ""
+}
+
+func isIncidentID(s string) bool {
+	return strings.ContainsAny(s, "0123456789") && strings.IndexFunc(s, unicode.IsLetter) >= 0
+}
+
+// renderQueries 渲染查询记录，结论阶段据此引用证据编号
+func renderQueries(queries []InvestigationQuery) string {
+	var b strings.Builder
+	b.WriteString("## 调查查询记录\n")
+	if len(queries) == 0 {
+		b.WriteString("\n本次调查没有执行查询。\n")
+		return b.String()
+	}
+	b.WriteString("\n| 编号 | 服务 | 参数 | 结果 |\n|------|------|------|------|\n")
+	for _, q := range queries {
+		result := "成功"
+		if !q.Success {
+			result = "失败：" + q.Error
+		}
+		fmt.Fprintf(&b, "| %s | %s (%s) | `%s` | %s |\n", q.ID, q.Service, q.Type, q.Request, result)
+	}
+	return b.String()
+}
+
+// linkEvidence 按证据编号补全链接：查询取自 service 生成的链接，incident 取事件链接
+func linkEvidence(findings *InvestigationFindings, incident *Incident, queries []InvestigationQuery) {
+	links := map[string]string{EvidenceRefIncident: incident.HTMLURL}
+	for _, q := range queries {
+		links[q.ID] = q.Link
+	}
+	if findings.IncidentID == "" {
+		findings.IncidentID = incident.ID
+	}
+	for i := range findings.Hypotheses {
+		for j := range findings.Hypotheses[i].Evidence {
+			e := &findings.Hypotheses[i].Evidence[j]
+			e.Link = links[e.Ref]
+		}
+	}
+}
+
+type queryLogKey struct{}
+
+// queryLog 记录一次调查中的查询并限制查询次数
+type queryLog struct {
+	mu      sync.Mutex
+	limit   int
+	queries []InvestigationQuery
+}
+
+// reserve 分配下一个查询编号，超出预算时返回 false
+func (l *queryLog) reserve() (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.queries) >= l.limit {
+		return 0, false
+	}
+	l.queries = append(l.queries, InvestigationQuery{ID: fmt.Sprintf("Q%d", len(l.queries)+1)})
+	return len(l.queries) - 1, true
+}
+
+func (l *queryLog) set(i int, q InvestigationQuery) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	q.ID = l.queries[i].ID
+	l.queries[i] = q
+}
+
+func (l *queryLog) snapshot() []InvestigationQuery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]InvestigationQuery(nil), l.queries...)
+}
+
+// investigationOperations 调查中允许调用的只读操作；
+// 解决事件、暂停告警、创建 issue 等会改变外部状态的操作不能交给自主调查循环
+var investigationOperations = map[service.ServiceType][]string{
+	service.Prometheus: {"query_range", "query_instant"},
+	service.OpenSearch: {"search"},
+	service.PagerDuty:  {"list_incidents", "get_incident", "list_log_entries"},
+	service.Jira:       {"list_issues", "get_issue"},
+}
+
+// budgetTool 在调查中记录每次 service 调用并为结果附上查询编号，只允许只读操作，查询预算用尽后不再调用 service
+type budgetTool struct {
+	tools.Tool
+	service service.Service
+}
+
+func (t *budgetTool) Handle(ctx context.Context, input string) (string, error) {
+	var req struct {
+		Operation string `json:"operation"`
+	}
+	_ = json.Unmarshal([]byte(input), &req)
+	allowed := investigationOperations[t.service.Type()]
+	if !slices.Contains(allowed, req.Operation) {
+		return fmt.Sprintf(`{"success": false, "message": "调查只允许只读查询操作: %s"}`, strings.Join(allowed, ", ")), nil
+	}
+
+	log, ok := ctx.Value(queryLogKey{}).(*queryLog)
+	if !ok {
+		return t.Tool.Handle(ctx, input)
+	}
+	i, ok := log.reserve()
+	if !ok {
+		return `{"success": false, "message": "查询预算已用尽，请基于已有证据给出结论"}`, nil
+	}
+
+	q := InvestigationQuery{
+		Service: t.service.Name(),
+		Type:    string(t.service.Type()),
+		Request: input,
+	}
+	if linker, ok := t.service.(service.Linker); ok {
+		q.Link, _ = linker.Link(input)
+	}
+	output, err := t.Tool.Handle(ctx, input)
+	if err != nil {
+		q.Error = err.Error()
+		log.set(i, q)
+		return "", err
+	}
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal([]byte(output), &resp) == nil {
+		q.Success, q.Error = resp.Success, resp.Message
+	}
+	log.set(i, q)
+
+	result := json.RawMessage(output)
+	if !json.Valid(result) {
+		result, _ = json.Marshal(output)
+	}
+	wrapped, err := json.Marshal(map[string]any{"query_id": fmt.Sprintf("Q%d", i+1), "result": result})
+	if err != nil {
+		return "", err
+	}
+	return string(wrapped), nil
+}
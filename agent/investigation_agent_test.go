@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/service"
+)
+
+// linkedService 为查询生成固定链接的 fakeService
+type linkedService struct {
+	fakeService
+	link string
+}
+
+func (s linkedService) Link(string) (string, bool) { return s.link, true }
+
+const incidentResult = `{"operation": "get_incident", "success": true, "incident": {"id": "PABC123", "title": "checkout 5xx 错误率过高", "status": "triggered", "urgency": "high", "service_name": "checkout", "created_at": "2026-10-18T02:03:00Z", "html_url": "https://acme.pagerduty.com/incidents/PABC123"}}`
+
+func investigationServices() []service.Service {
+	return []service.Service{
+		fakeService{name: "pd", typ: service.PagerDuty, result: incidentResult},
+		linkedService{
+			fakeService: fakeService{name: "prom", result: `{"success": true, "data": [{"metric": {}, "values": [[1792288860, "12"]]}]}`},
+			link:        "http://prom:9090/graph?g0.expr=5xx",
+		},
+		fakeService{name: "logs", typ: service.OpenSearch, result: `{"success": true}`},
+	}
+}
+
+func TestInvestigationAgent_Run(t *testing.T) {
+	investigation, err := NewInvestigationAgent(InvestigationConfig{
+		Model:         structuredModel(t, "investigation.json"),
+		Services:      investigationServices(),
+		MaxIterations: 1,
+	})
+	require.NoError(t, err)
+
+	session := blades.NewSession()
+	msg, err := NewInspectionRunner(investigation).Run(context.Background(), blades.UserMessage("调查 PABC123 的根因"), blades.WithSession(session))
+	require.NoError(t, err)
+	assert.Equal(t, consts.AgentNameInvestigation, msg.Author)
+
+	findings, ok := FindingsFromMessage(msg)
+	require.True(t, ok)
+	assert.Equal(t, "02:00 发布的 checkout v2.3 引入错误", findings.RootCause)
+	require.Len(t, findings.Hypotheses, 1)
+	assert.Equal(t, []EvidenceRef{
+		{Ref: "Q1", Summary: "02:01 起 5xx 速率从 0.1/s 升至 12/s", Link: "http://prom:9090/graph?g0.expr=5xx"},
+		{Ref: "incident", Summary: "告警于 02:03 触发", Link: "https://acme.pagerduty.com/incidents/PABC123"},
+	}, findings.Hypotheses[0].Evidence)
+	assert.Equal(t, findings.Render(), msg.Text())
+	assert.Contains(t, msg.Text(), "- [证实] 02:00 发布引入 5xx")
+
+	// 事件详情与查询记录写入会话；超出预算的第二次查询没有执行
+	var incident *Incident
+	var queries []InvestigationQuery
+	for _, m := range session.History() {
+		if v, ok := m.Metadata[MetadataIncident].(*Incident); ok {
+			incident = v
+			assert.Contains(t, m.Text(), "**调查时间窗口**：2026-10-18T01:33:00Z ~ 2026-10-18T02:33:00Z")
+		}
+		if v, ok := m.Metadata[MetadataInvestigationQueries].([]InvestigationQuery); ok {
+			queries = v
+		}
+	}
+	require.NotNil(t, incident)
+	assert.Equal(t, "checkout", incident.ServiceName)
+	require.Len(t, queries, 1)
+	assert.Equal(t, "Q1", queries[0].ID)
+	assert.Equal(t, "prom", queries[0].Service)
+	assert.True(t, queries[0].Success)
+}
+
+func TestInvestigationAgent_ModelIgnoresBudget(t *testing.T) {
+	investigation, err := NewInvestigationAgent(InvestigationConfig{
+		Model:         structuredModel(t, "investigation_overrun.json"),
+		Services:      investigationServices(),
+		MaxIterations: 1,
+	})
+	require.NoError(t, err)
+
+	// 模型在预算用尽后仍不断调用工具，达到迭代上限后照常进入结论阶段
+	msg, err := NewInspectionRunner(investigation).Run(context.Background(), blades.UserMessage("调查 PABC123"))
+	require.NoError(t, err)
+	findings, ok := FindingsFromMessage(msg)
+	require.True(t, ok)
+	assert.Equal(t, "查询预算内未能确定根因。", findings.Summary)
+	require.Len(t, findings.Hypotheses, 1)
+	assert.Equal(t, "inconclusive", findings.Hypotheses[0].Status)
+}
+
+func TestBudgetTool_ReadOnly(t *testing.T) {
+	pd := fakeService{name: "pd", typ: service.PagerDuty, result: incidentResult}
+	tool, err := pd.AsTool()
+	require.NoError(t, err)
+	budget := &budgetTool{Tool: tool, service: pd}
+
+	log := &queryLog{limit: 5}
+	ctx := context.WithValue(context.Background(), queryLogKey{}, log)
+
+	for _, op := range []string{"resolve_incident", "acknowledge_incident", "snooze_alert", ""} {
+		output, err := budget.Handle(ctx, `{"operation": "`+op+`"}`)
+		require.NoError(t, err)
+		assert.Contains(t, output, "调查只允许只读查询操作", op)
+	}
+	assert.Empty(t, log.snapshot(), "被拒绝的操作不占用查询预算")
+
+	output, err := budget.Handle(ctx, `{"operation": "get_incident", "get_incident": {"incident_id": "PABC123"}}`)
+	require.NoError(t, err)
+	assert.Contains(t, output, `"query_id":"Q1"`)
+	assert.Len(t, log.snapshot(), 1)
+}
+
+func TestInvestigationAgent_MissingIncidentID(t *testing.T) {
+	investigation, err := NewInvestigationAgent(InvestigationConfig{
+		Model:    scriptedModel(),
+		Services: investigationServices(),
+	})
+	require.NoError(t, err)
+
+	msg, err := NewInspectionRunner(investigation).Run(context.Background(), blades.UserMessage("最近的故障是什么原因"))
+	require.NoError(t, err)
+	assert.Equal(t, true, msg.Metadata[MetadataClarification])
+	assert.Contains(t, msg.Text(), "incident ID")
+}
+
+func TestInvestigationAgent_IncidentNotFound(t *testing.T) {
+	investigation, err := NewInvestigationAgent(InvestigationConfig{
+		Model: scriptedModel(),
+		Services: []service.Service{
+			fakeService{name: "pd", typ: service.PagerDuty, result: `{"operation": "get_incident", "success": false, "message": "incident not found"}`},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = NewInspectionRunner(investigation).Run(context.Background(), blades.UserMessage("调查 PNOPE42"))
+	require.Error(t, err)
+	assert.Equal(t, "get incident PNOPE42 failed: incident not found", err.Error())
+}
+
+func TestNewInvestigationAgent_RequiresPagerDuty(t *testing.T) {
+	_, err := NewInvestigationAgent(InvestigationConfig{
+		Model:    scriptedModel(),
+		Services: []service.Service{fakeService{name: "prom"}},
+	})
+	require.Error(t, err)
+	assert.Equal(t, "investigation agent requires a pagerduty service", err.Error())
+}
+
+func TestIncidentID(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "调查 PABC123 的根因", want: "PABC123"},
+		{text: "incident Q1P3ROB6JVKTZQ 为什么触发", want: "Q1P3ROB6JVKTZQ"},
+		{text: "调查事件PT4KHLK9", want: "PT4KHLK9"},
+		{text: "CRITICAL 告警 PXYZ789 的原因", want: "PXYZ789"},
+		{text: "CHECKOUT 服务的 5xx", want: ""},
+		{text: "看下 p1abc23 怎么回事", want: "P1ABC23"},
+		{text: "incident: pt4khlk9", want: "PT4KHLK9"},
+		{text: "HTTP500X 错误激增，参考 #PXYZ789", want: "PXYZ789"},
+		{text: "HTTP500X 错误激增", want: ""},
+		{text: "升级到 V2026RC1 后 5xx 变多", want: ""},
+		{text: "checkout 1.24.3 版本 ABC1234 构建", want: ""},
+		{text: "incident summary 在哪看", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, incidentID(tt.text))
+		})
+	}
+}
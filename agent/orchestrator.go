@@ -3,6 +3,8 @@ package agent
 import (
 	"fmt"
 	"log/slog"
+	"slices"
+	"time"
 
 	"github.com/go-kratos/blades"
 	"github.com/go-kratos/blades/flow"
@@ -10,6 +12,8 @@ import (
 
 	"github.com/oneblade/internal/consts"
 	"github.com/oneblade/internal/llm"
+	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/internal/prompts"
 	"github.com/oneblade/service"
 )
 
@@ -19,6 +23,27 @@ type OrchestratorConfig struct {
 	EnabledAgents          []string
 	Tools                  []toolkit.Tool
 	ConversationMaxMessage int
+	// StructuredOutputAgents 启用结构化输出的 agent（report_agent / prediction_agent）
+	StructuredOutputAgents []string
+	// CustomAgents 配置中声明的自定义 agent，key 为 agent 名称
+	CustomAgents map[string]CustomAgentSpec
+	// Prompts 内置 agent 的提示词模板，为空时使用内置模板
+	Prompts *prompts.Set
+	// CollectTimeouts 分析流程采集阶段按 service 名称配置的超时，未配置时使用 DefaultCollectTimeout
+	CollectTimeouts map[string]time.Duration
+	// MaxHops 单次请求允许的最大 handoff 次数，默认 DefaultMaxHops
+	MaxHops int
+	// DefaultAgent handoff 目标不存在时回退到的子 agent，为空时返回错误
+	DefaultAgent string
+	// RoutingRules 在 orchestrator 模型路由之前按顺序匹配的规则
+	RoutingRules []RoutingRule
+	// RoutingMetrics 规则命中计数，由调用方持有以便在重建 orchestrator 时保留
+	RoutingMetrics *middleware.RoutingMetrics
+	// InvestigationMaxIterations 与 InvestigationWindow 为 investigation_agent 的查询次数上限与时间窗口
+	InvestigationMaxIterations int
+	InvestigationWindow        time.Duration
+	// Postmortem postmortem_agent 创建复盘 issue 的项目与类型，仅 Project / IssueType / SubtaskType 生效
+	Postmortem PostmortemConfig
 }
 
 func NewOrchestratorAgent(cfg OrchestratorConfig) (blades.Agent, error) {
@@ -42,13 +67,52 @@ func NewOrchestratorAgent(cfg OrchestratorConfig) (blades.Agent, error) {
 		var agent blades.Agent
 		switch agentName {
 		case consts.AgentNameService:
-			agent, err = NewServiceAgent(ServiceAgent{Model: model, Services: cfg.Services})
+			agent, err = NewServiceAgent(ServiceAgent{Model: model, Services: cfg.Services, Prompts: cfg.Prompts})
 		case consts.AgentNamePrediction:
-			agent, err = NewPredictionAgent(PredictionAgentConfig{Model: model})
+			agent, err = NewPredictionAgent(PredictionAgentConfig{
+				Model:            model,
+				Services:         cfg.Services,
+				Prompts:          cfg.Prompts,
+				StructuredOutput: slices.Contains(cfg.StructuredOutputAgents, agentName),
+			})
+		case consts.AgentNameGeneral:
+			agent, err = NewGeneralAgent(GeneralAgentConfig{Model: model, Tools: cfg.Tools, Prompts: cfg.Prompts})
+		case consts.AgentNameInvestigation:
+			agent, err = NewInvestigationAgent(InvestigationConfig{
+				Model:         model,
+				Services:      cfg.Services,
+				Prompts:       cfg.Prompts,
+				MaxIterations: cfg.InvestigationMaxIterations,
+				Window:        cfg.InvestigationWindow,
+			})
+		case consts.AgentNamePostmortem:
+			agent, err = NewPostmortemAgent(PostmortemConfig{
+				Model:       model,
+				Services:    cfg.Services,
+				Prompts:     cfg.Prompts,
+				Project:     cfg.Postmortem.Project,
+				IssueType:   cfg.Postmortem.IssueType,
+				SubtaskType: cfg.Postmortem.SubtaskType,
+			})
 		case consts.AgentNameReport:
-			agent, err = NewReportAgent(ReportAgentConfig{Model: model})
+			agent, err = NewReportAgent(ReportAgentConfig{
+				Model:            model,
+				Services:         cfg.Services,
+				Prompts:          cfg.Prompts,
+				StructuredOutput: slices.Contains(cfg.StructuredOutputAgents, agentName),
+			})
 		default:
-			continue
+			spec, ok := cfg.CustomAgents[agentName]
+			if !ok {
+				return nil, fmt.Errorf("agent %s is neither built-in nor declared as a custom agent", agentName)
+			}
+			agent, err = NewCustomAgent(CustomAgentConfig{
+				Name:     agentName,
+				Model:    model,
+				Spec:     spec,
+				Services: cfg.Services,
+				Tools:    cfg.Tools,
+			})
 		}
 
 		if err != nil {
@@ -58,24 +122,31 @@ func NewOrchestratorAgent(cfg OrchestratorConfig) (blades.Agent, error) {
 		slog.Info("orchestrator.agent.created", "agent", agentName)
 	}
 
-	// generalModel, _ := cfg.ModelRegistry.Get(consts.AgentNameGeneral)
-
-	// generalAgent, err := NewGeneralAgent(GeneralAgentConfig{
-	// 	Model: generalModel,
-	// 	Tools: cfg.Tools,
-	// })
-	// if err != nil {
-	// 	return nil, fmt.Errorf("failed to create general agent: %v", err)
-	// }
-	// agentMap[consts.AgentNameGeneral] = generalAgent
-	analysisAgent := newAnalysisFlow(agentMap)
+	// 启用 service_agent 时，分析流程的数据采集由 collection_agent 并行完成
+	var collectionAgent blades.Agent
+	if _, ok := agentMap[consts.AgentNameService]; ok && len(cfg.Services) > 0 {
+		model, err := cfg.ModelRegistry.Get(consts.AgentNameService)
+		if err != nil {
+			return nil, err
+		}
+		collectionAgent, err = NewCollectionAgent(CollectionConfig{
+			Model:    model,
+			Services: cfg.Services,
+			Timeouts: cfg.CollectTimeouts,
+			Prompts:  cfg.Prompts,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	analysisAgent := newAnalysisFlow(agentMap, collectionAgent)
 
 	// 构建 subAgents 列表：包含所有独立的 agent 和 analysisAgent
 	// service_agent 需要单独列出，因为用户可能直接请求查询外部系统
 	subAgents := []blades.Agent{analysisAgent}
 	subAgentNames := []string{consts.AgentNameAnalysis}
 
-	// 添加所有独立的 agent（包括 service_agent、prediction_agent、report_agent）
+	// 添加所有独立的 agent（包括 service_agent、prediction_agent、report_agent、general_agent、investigation_agent 与自定义 agent）
 	// 这样 RoutingAgent 可以直接路由到它们，而不需要经过 analysisAgent
 	for name, agent := range agentMap {
 		subAgents = append(subAgents, agent)
@@ -91,14 +162,20 @@ func NewOrchestratorAgent(cfg OrchestratorConfig) (blades.Agent, error) {
 	description := consts.BuildOrchestratorDescription(subAgentNames)
 
 	return NewRoutingAgent(RoutingConfig{
-		Name:        consts.AgentNameOrchestrator,
-		Description: description,
-		Model:       orchestratorModel,
-		SubAgents:   subAgents,
+		Name:         consts.AgentNameOrchestrator,
+		Description:  description,
+		Model:        orchestratorModel,
+		SubAgents:    subAgents,
+		MaxHops:      cfg.MaxHops,
+		DefaultAgent: cfg.DefaultAgent,
+		Rules:        cfg.RoutingRules,
+		Metrics:      cfg.RoutingMetrics,
 	})
 }
 
-func newAnalysisFlow(agents map[string]blades.Agent) blades.Agent {
+// newAnalysisFlow 构建 采集 → 预测 → 报告 的顺序流程
+// collection 非空时替代 service_agent 作为采集阶段。
+func newAnalysisFlow(agents map[string]blades.Agent, collection blades.Agent) blades.Agent {
 	order := []string{
 		consts.AgentNameService,
 		consts.AgentNamePrediction,
@@ -107,16 +184,26 @@ func newAnalysisFlow(agents map[string]blades.Agent) blades.Agent {
 
 	var subAgents []blades.Agent
 	for _, name := range order {
+		if name == consts.AgentNameService && collection != nil {
+			subAgents = append(subAgents, collection)
+			continue
+		}
 		if agent, ok := agents[name]; ok {
 			subAgents = append(subAgents, agent)
 		}
 	}
 
-	return flow.NewSequentialAgent(flow.SequentialConfig{
+	return &flowAgent{Agent: flow.NewSequentialAgent(flow.SequentialConfig{
 		Name:        consts.AgentNameAnalysis,
 		Description: consts.AnalysisAgentDescription,
 		SubAgents:   subAgents,
-	})
+	})}
+}
+
+// flowAgent 标记按固定顺序运行的流程 agent
+// 路由时不向其注入 handoff 指令与工具，避免中间成员 handoff 导致流程中途退出。
+type flowAgent struct {
+	blades.Agent
 }
 
 func NewInspectionRunner(orchestrator blades.Agent) *blades.Runner {
@@ -1,5 +1,82 @@
 package agent
 
-// Note: NewOrchestratorAgent and NewInspectionRunner require the full blades framework
-// to be initialized, which makes them difficult to unit test. These are better suited
-// for integration tests. Unit tests are skipped here as they require full framework setup.
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	toolkit "github.com/go-kratos/blades/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/internal/llm"
+	"github.com/oneblade/internal/toolbox"
+)
+
+// mockModel 从 testdata 下的 fixture 构建 mock 模型
+func mockModel(t *testing.T, fixture string) blades.ModelProvider {
+	t.Helper()
+	f, err := llm.LoadFixture("testdata/" + fixture)
+	require.NoError(t, err)
+	return llm.NewMockProvider("mock", f)
+}
+
+func TestNewOrchestratorAgent_HandoffToReport(t *testing.T) {
+	registry := llm.NewModelRegistry()
+	registry.Register(consts.AgentNameOrchestrator, mockModel(t, "handoff_report.json"))
+	registry.Register(consts.AgentNameReport, mockModel(t, "handoff_report.json"))
+
+	orchestrator, err := NewOrchestratorAgent(OrchestratorConfig{
+		ModelRegistry: registry,
+		EnabledAgents: []string{consts.AgentNameReport},
+	})
+	require.NoError(t, err)
+
+	runner := NewInspectionRunner(orchestrator)
+	msg, err := runner.Run(context.Background(), blades.UserMessage("生成巡检报告"))
+	require.NoError(t, err)
+	assert.Equal(t, consts.AgentNameReport, msg.Author)
+	assert.Contains(t, msg.Text(), "所有服务运行正常")
+}
+
+func TestNewOrchestratorAgent_RequiresRegistry(t *testing.T) {
+	_, err := NewOrchestratorAgent(OrchestratorConfig{})
+	require.Error(t, err)
+}
+
+func TestNewOrchestratorAgent_GeneralAgent(t *testing.T) {
+	timeTool, err := toolbox.NewTimeTool()
+	require.NoError(t, err)
+
+	registry := llm.NewModelRegistry()
+	registry.Register(consts.AgentNameOrchestrator, scriptedModel(llm.FixtureTurn{Handoff: consts.AgentNameGeneral}))
+	registry.Register(consts.AgentNameGeneral, scriptedModel(
+		llm.FixtureTurn{ToolCalls: []llm.FixtureToolCall{{Name: "Time", Arguments: []byte(`{"timezone": "UTC"}`)}}},
+		llm.FixtureTurn{Text: "现在是 UTC 时间"},
+	))
+
+	orchestrator, err := NewOrchestratorAgent(OrchestratorConfig{
+		ModelRegistry: registry,
+		EnabledAgents: []string{consts.AgentNameGeneral},
+		Tools:         []toolkit.Tool{timeTool},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, orchestrator.Description(), "路由到 general_agent")
+
+	msg, err := NewInspectionRunner(orchestrator).Run(context.Background(), blades.UserMessage("现在几点了"))
+	require.NoError(t, err)
+	assert.Equal(t, consts.AgentNameGeneral, msg.Author)
+	assert.Equal(t, "现在是 UTC 时间", msg.Text())
+}
+
+func TestNewOrchestratorAgent_GeneralAgentRequiresTools(t *testing.T) {
+	registry := llm.NewModelRegistry()
+	registry.Register(consts.AgentNameGeneral, scriptedModel())
+
+	_, err := NewOrchestratorAgent(OrchestratorConfig{
+		ModelRegistry: registry,
+		EnabledAgents: []string{consts.AgentNameGeneral},
+	})
+	require.Error(t, err)
+}
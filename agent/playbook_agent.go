@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/flow"
+
+	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/internal/playbook"
+	"github.com/oneblade/service"
+)
+
+// MetadataPlaybookReport 巡检清单结果在消息 Metadata 中的 key
+const MetadataPlaybookReport = "playbook_report"
+
+// PlaybookReportFromMessage 返回 playbook_agent 消息中的巡检清单结果
+func PlaybookReportFromMessage(msg *blades.Message) (*playbook.Report, bool) {
+	report, ok := msg.Metadata[MetadataPlaybookReport].(*playbook.Report)
+	return report, ok
+}
+
+type PlaybookAgentConfig struct {
+	Playbook *playbook.Playbook
+	Services []service.Service
+	// Report 汇总检查结果生成巡检报告的 agent，为空时只输出检查结果
+	Report blades.Agent
+}
+
+// playbookAgent 按巡检清单直接调用 service 执行检查，不经过模型
+type playbookAgent struct {
+	runner *playbook.Runner
+}
+
+// NewPlaybookAgent 构建执行巡检清单的 agent；配置了 Report 时检查结果交由其生成报告
+func NewPlaybookAgent(cfg PlaybookAgentConfig) (blades.Agent, error) {
+	if cfg.Playbook == nil {
+		return nil, fmt.Errorf("playbook is required")
+	}
+	runner, err := playbook.NewRunner(cfg.Playbook, cfg.Services)
+	if err != nil {
+		return nil, fmt.Errorf("playbook %s: %w", cfg.Playbook.Name, err)
+	}
+
+	agent := &playbookAgent{runner: runner}
+	if cfg.Report == nil {
+		return agent, nil
+	}
+	return flow.NewSequentialAgent(flow.SequentialConfig{
+		Name:        consts.AgentNamePlaybook,
+		Description: consts.PlaybookAgentDescription,
+		SubAgents:   []blades.Agent{agent, cfg.Report},
+	}), nil
+}
+
+func (a *playbookAgent) Name() string {
+	return consts.AgentNamePlaybook
+}
+
+func (a *playbookAgent) Description() string {
+	return consts.PlaybookAgentDescription
+}
+
+func (a *playbookAgent) Run(ctx context.Context, invocation *blades.Invocation) blades.Generator[*blades.Message, error] {
+	return func(yield func(*blades.Message, error) bool) {
+		report, err := a.runner.Run(ctx)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		msg := blades.NewAssistantMessage(blades.StatusCompleted)
+		msg.Author = a.Name()
+		msg.InvocationID = invocation.ID
+		msg.Parts = []blades.Part{blades.TextPart{Text: report.Render()}}
+		msg.Metadata[MetadataPlaybookReport] = report
+		yield(msg, nil)
+	}
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/internal/playbook"
+	"github.com/oneblade/service"
+)
+
+func TestNewPlaybookAgent_FeedsReport(t *testing.T) {
+	report, err := NewReportAgent(ReportAgentConfig{Model: mockModel(t, "playbook.json")})
+	require.NoError(t, err)
+
+	warn, fail := 0.01, 0.05
+	pb, err := NewPlaybookAgent(PlaybookAgentConfig{
+		Playbook: &playbook.Playbook{
+			Name: "daily-core",
+			Checks: []playbook.Check{
+				{Name: "5xx 错误率", Service: "prom", Operation: "query_instant", Warn: &warn, Fail: &fail},
+			},
+		},
+		Services: []service.Service{fakeService{
+			name:   "prom",
+			result: `{"success": true, "data": [{"metric": {}, "value": [1760000000, "0.03"]}]}`,
+		}},
+		Report: report,
+	})
+	require.NoError(t, err)
+
+	session := blades.NewSession()
+	msg, err := NewInspectionRunner(pb).Run(context.Background(), blades.UserMessage("执行巡检清单 daily-core"), blades.WithSession(session))
+	require.NoError(t, err)
+	assert.Equal(t, consts.AgentNameReport, msg.Author)
+	assert.Contains(t, msg.Text(), "5xx 错误率超过警告阈值")
+
+	// 检查结果以一条消息写入会话，供报告阶段使用
+	var results *blades.Message
+	for _, m := range session.History() {
+		if m.Author == consts.AgentNamePlaybook {
+			results = m
+		}
+	}
+	require.NotNil(t, results)
+	got, ok := PlaybookReportFromMessage(results)
+	require.True(t, ok)
+	require.Len(t, got.Results, 1)
+	assert.Equal(t, playbook.StatusWarn, got.Results[0].Status)
+	assert.Contains(t, results.Text(), "## 巡检清单结果：daily-core")
+}
+
+func TestNewPlaybookAgent_UnknownService(t *testing.T) {
+	fail := 1.0
+	_, err := NewPlaybookAgent(PlaybookAgentConfig{
+		Playbook: &playbook.Playbook{
+			Name:   "daily-core",
+			Checks: []playbook.Check{{Name: "cpu", Service: "prom", Operation: "query_instant", Fail: &fail}},
+		},
+	})
+	require.Error(t, err)
+	assert.Equal(t, "playbook daily-core: check cpu: service prom not found or not enabled", err.Error())
+}
@@ -0,0 +1,543 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tools"
+
+	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/internal/prompts"
+	"github.com/oneblade/internal/session"
+	"github.com/oneblade/service"
+)
+
+// 复盘 issue 的默认类型
+const (
+	DefaultPostmortemIssueType   = "Task"
+	DefaultPostmortemSubtaskType = "Sub-task"
+)
+
+// PostmortemLabel 复盘 issue 与改进项子任务的标签
+const PostmortemLabel = "postmortem"
+
+// 事件复盘在消息 Metadata 中的 key，值分别为 *Postmortem 与 *PostmortemFiling
+const (
+	MetadataPostmortem       = "postmortem"
+	MetadataPostmortemFiling = "postmortem_filing"
+)
+
+// maxRelatedIssues 起草时最多引用的相关 Jira issue 数量
+const maxRelatedIssues = 20
+
+// Postmortem postmortem_agent 起草的结构化复盘
+type Postmortem struct {
+	IncidentID    string          `json:"incident_id" jsonschema:"复盘的 PagerDuty incident ID"`
+	Title         string          `json:"title" jsonschema:"复盘标题，一句话概括故障"`
+	Summary       string          `json:"summary" jsonschema:"发生了什么、如何恢复"`
+	Impact        string          `json:"impact" jsonschema:"受影响的服务、用户与持续时长"`
+	Timeline      []TimelineEntry `json:"timeline" jsonschema:"按时间先后排列的关键事件"`
+	RootCause     string          `json:"root_cause" jsonschema:"根因；尚未确定时明确说明"`
+	ActionItems   []ActionItem    `json:"action_items" jsonschema:"改进项，每项创建为一个 Jira 子任务"`
+	RelatedIssues []string        `json:"related_issues" jsonschema:"相关的 Jira issue key"`
+}
+
+// TimelineEntry 复盘时间线中的一条记录
+type TimelineEntry struct {
+	Time  string `json:"time" jsonschema:"发生时间"`
+	Event string `json:"event" jsonschema:"发生了什么"`
+}
+
+// ActionItem 复盘中的改进项
+type ActionItem struct {
+	Summary     string `json:"summary" jsonschema:"改进项概述，作为子任务标题"`
+	Description string `json:"description,omitempty" jsonschema:"改进项的细节与验收标准"`
+}
+
+// PostmortemFiling 确认后在 Jira 创建的 issue
+type PostmortemFiling struct {
+	// Issue 复盘 issue 的 key
+	Issue string `json:"issue"`
+	// ActionItems 已创建的改进项子任务 key
+	ActionItems []string `json:"action_items,omitempty"`
+	// Failed 创建失败的改进项概述
+	Failed []string `json:"failed,omitempty"`
+}
+
+// IncidentLogEntry PagerDuty 事件日志中的一条记录
+type IncidentLogEntry struct {
+	Type      string `json:"type"`
+	Summary   string `json:"summary"`
+	CreatedAt string `json:"created_at"`
+	Agent     string `json:"agent,omitempty"`
+}
+
+// RelatedIssue 与事件相关的 Jira issue
+type RelatedIssue struct {
+	Key     string `json:"key"`
+	Summary string `json:"summary"`
+	Status  string `json:"status"`
+}
+
+var postmortemSchema = mustSchema[Postmortem]("postmortem", "事件复盘草稿", nil)
+
+// PostmortemFromMessage 返回 postmortem_agent 消息中的复盘草稿
+func PostmortemFromMessage(msg *blades.Message) (*Postmortem, bool) {
+	pm, ok := msg.Metadata[MetadataPostmortem].(*Postmortem)
+	return pm, ok
+}
+
+// Render 将复盘渲染为 Markdown，同时作为复盘 issue 的描述
+func (p *Postmortem) Render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## 事件复盘：%s\n\n- **Incident ID**：%s\n", p.Title, p.IncidentID)
+	if len(p.RelatedIssues) > 0 {
+		fmt.Fprintf(&b, "- **相关 issue**：%s\n", strings.Join(p.RelatedIssues, "、"))
+	}
+	fmt.Fprintf(&b, "\n### 摘要\n\n%s\n\n### 影响\n\n%s\n", p.Summary, p.Impact)
+	if len(p.Timeline) > 0 {
+		b.WriteString("\n### 时间线\n\n| 时间 | 事件 |\n|------|------|\n")
+		for _, e := range p.Timeline {
+			fmt.Fprintf(&b, "| %s | %s |\n", e.Time, e.Event)
+		}
+	}
+	fmt.Fprintf(&b, "\n### 根因\n\n%s\n", p.RootCause)
+	if len(p.ActionItems) > 0 {
+		b.WriteString("\n### 改进项\n\n")
+		for _, item := range p.ActionItems {
+			fmt.Fprintf(&b, "- %s", item.Summary)
+			if item.Description != "" {
+				fmt.Fprintf(&b, "：%s", item.Description)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Render 将创建结果渲染为 Markdown
+func (f *PostmortemFiling) Render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "已在 Jira 创建复盘 issue %s", f.Issue)
+	if len(f.ActionItems) > 0 {
+		fmt.Fprintf(&b, "，改进项子任务：%s", strings.Join(f.ActionItems, "、"))
+	}
+	b.WriteString("。\n")
+	if len(f.Failed) > 0 {
+		b.WriteString("\n以下改进项创建失败，请手动补充：\n\n")
+		for _, item := range f.Failed {
+			fmt.Fprintf(&b, "- %s\n", item)
+		}
+	}
+	return b.String()
+}
+
+type PostmortemConfig struct {
+	Model blades.ModelProvider
+	// Services 已启用的 service，必须包含 PagerDuty 与 Jira
+	Services []service.Service
+	// Prompts 提示词模板，为空时使用内置模板
+	Prompts *prompts.Set
+	// Project 复盘 issue 所在的 Jira 项目，为空时使用 jira service 的默认项目
+	Project string
+	// IssueType 复盘 issue 的类型，默认 DefaultPostmortemIssueType
+	IssueType string
+	// SubtaskType 改进项子任务的类型，默认 DefaultPostmortemSubtaskType
+	SubtaskType string
+}
+
+// postmortemAgent 为已解决的 PagerDuty 事件起草复盘：
+// 直接拉取事件详情、事件日志与相关 Jira issue，结合会话记录生成结构化草稿；
+// 草稿保存在会话状态中，用户确认后创建复盘 issue 与改进项子任务，也可以继续提出修改意见或放弃。
+type postmortemAgent struct {
+	pagerduty   tools.Tool
+	jira        tools.Tool
+	drafter     blades.Agent
+	project     string
+	issueType   string
+	subtaskType string
+}
+
+func NewPostmortemAgent(cfg PostmortemConfig) (blades.Agent, error) {
+	var pagerduty, jira tools.Tool
+	for _, s := range cfg.Services {
+		switch {
+		case s.Type() == service.PagerDuty && pagerduty == nil:
+			tool, err := s.AsTool()
+			if err != nil {
+				return nil, fmt.Errorf("create tool for %s: %v", s.Name(), err)
+			}
+			pagerduty = tool
+		case s.Type() == service.Jira && jira == nil:
+			tool, err := s.AsTool()
+			if err != nil {
+				return nil, fmt.Errorf("create tool for %s: %v", s.Name(), err)
+			}
+			jira = tool
+		}
+	}
+	if pagerduty == nil || jira == nil {
+		return nil, fmt.Errorf("postmortem agent requires a pagerduty and a jira service")
+	}
+
+	drafter, err := blades.NewAgent(
+		prompts.PostmortemDraft,
+		blades.WithDescription(consts.PostmortemAgentDescription),
+		blades.WithInstructionProvider(promptInstruction(cfg.Prompts, prompts.PostmortemDraft, cfg.Services)),
+		blades.WithModel(cfg.Model),
+		blades.WithOutputSchema(postmortemSchema),
+		blades.WithMiddleware(
+			middleware.NewAgentLogging,
+			middleware.LoadSessionHistory(),
+			structuredOutput[Postmortem](MetadataPostmortem),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	issueType := cfg.IssueType
+	if issueType == "" {
+		issueType = DefaultPostmortemIssueType
+	}
+	subtaskType := cfg.SubtaskType
+	if subtaskType == "" {
+		subtaskType = DefaultPostmortemSubtaskType
+	}
+	return &postmortemAgent{
+		pagerduty:   pagerduty,
+		jira:        jira,
+		drafter:     drafter,
+		project:     cfg.Project,
+		issueType:   issueType,
+		subtaskType: subtaskType,
+	}, nil
+}
+
+func (a *postmortemAgent) Name() string {
+	return consts.AgentNamePostmortem
+}
+
+func (a *postmortemAgent) Description() string {
+	return consts.PostmortemAgentDescription
+}
+
+func (a *postmortemAgent) Run(ctx context.Context, invocation *blades.Invocation) blades.Generator[*blades.Message, error] {
+	return func(yield func(*blades.Message, error) bool) {
+		var text string
+		if invocation.Message != nil {
+			text = invocation.Message.Text()
+		}
+
+		pending := pendingPostmortem(ctx)
+		if pending != nil {
+			switch reply := normalizeReply(text); {
+			case slices.Contains(confirmReplies, reply):
+				filing, err := a.file(ctx, pending)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				setPendingPostmortem(ctx, nil)
+				msg := a.message(invocation, filing.Render())
+				msg.Metadata[MetadataPostmortemFiling] = filing
+				yield(msg, nil)
+				return
+			case slices.Contains(cancelReplies, reply):
+				setPendingPostmortem(ctx, nil)
+				yield(a.message(invocation, fmt.Sprintf("已放弃事件 %s 的复盘草稿。", pending.IncidentID)), nil)
+				return
+			case slices.Contains(vagueReplies, reply):
+				// 创建 Jira issue 不可撤销，含糊的答复不视为确认
+				msg := a.message(invocation, "请回复「确认」在 Jira 创建复盘 issue，或回复「取消」放弃草稿。")
+				msg.Metadata[MetadataClarification] = true
+				yield(msg, nil)
+				return
+			}
+		}
+
+		// 没有指定新的事件时，视为对当前草稿的修改意见
+		id := incidentID(text)
+		if id == "" && pending != nil {
+			id = pending.IncidentID
+		}
+		if id == "" {
+			msg := a.message(invocation, "请提供要复盘的 PagerDuty incident ID。")
+			msg.Metadata[MetadataClarification] = true
+			yield(msg, nil)
+			return
+		}
+
+		incident, err := fetchIncident(ctx, a.pagerduty, id)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		// 事件未解决时时间线与影响尚不完整，不起草复盘
+		if incident.Status != incidentStatusResolved {
+			yield(a.message(invocation, fmt.Sprintf("事件 %s 当前状态为 %s，尚未解决，请在事件解决后再复盘。", incident.ID, incident.Status)), nil)
+			return
+		}
+		entries, err := a.logEntries(ctx, id)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		related := a.relatedIssues(ctx, id)
+		brief := a.message(invocation, renderPostmortemBrief(incident, entries, related))
+		brief.Metadata[MetadataIncident] = incident
+		if !yield(brief, nil) {
+			return
+		}
+
+		// 起草阶段只输出结构化 JSON，不继承路由注入的指令与工具
+		draftInvocation := invocation.Clone()
+		draftInvocation.Instruction = nil
+		draftInvocation.Tools = nil
+		for msg, err := range a.drafter.Run(ctx, draftInvocation) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if pm, ok := PostmortemFromMessage(msg); ok {
+				pm.IncidentID = incident.ID
+				pm.RelatedIssues = knownIssues(pm.RelatedIssues, related)
+				setPendingPostmortem(ctx, pm)
+				slog.Info("postmortem.drafted",
+					"incident", incident.ID,
+					"timeline", len(pm.Timeline),
+					"action_items", len(pm.ActionItems),
+				)
+				msg.Author = a.Name()
+				msg.Parts = []blades.Part{blades.TextPart{Text: pm.Render() + postmortemConfirmHint}}
+			}
+			if !yield(msg, nil) {
+				return
+			}
+		}
+	}
+}
+
+const postmortemConfirmHint = "\n---\n回复「确认」在 Jira 创建复盘 issue 与改进项子任务，回复「取消」放弃草稿，也可以直接说明需要修改的内容。\n"
+
+// incidentStatusResolved PagerDuty 中已解决事件的状态
+const incidentStatusResolved = "resolved"
+
+// 确认与放弃草稿的回复，比较前去掉首尾空白与标点并转为小写
+// vagueReplies 为含糊的答复，需要用户明确回复确认后才会创建 issue。
+var (
+	confirmReplies = []string{"确认", "确认创建", "confirm"}
+	cancelReplies  = []string{"取消", "放弃", "cancel", "discard"}
+	vagueReplies   = []string{"确定", "好", "好的", "可以", "创建", "yes", "ok", "okay"}
+)
+
+func normalizeReply(text string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(text), "。.！!，, "))
+}
+
+// file 创建复盘 issue 与改进项子任务；复盘 issue 创建失败时返回错误并保留草稿，改进项失败只记录
+func (a *postmortemAgent) file(ctx context.Context, pm *Postmortem) (*PostmortemFiling, error) {
+	key, err := a.createIssue(ctx, map[string]any{
+		"project":     a.project,
+		"type":        a.issueType,
+		"summary":     fmt.Sprintf("[Postmortem] %s: %s", pm.IncidentID, pm.Title),
+		"description": pm.Render(),
+		"labels":      []string{PostmortemLabel},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create postmortem issue for %s: %w", pm.IncidentID, err)
+	}
+
+	filing := &PostmortemFiling{Issue: key}
+	for _, item := range pm.ActionItems {
+		sub, err := a.createIssue(ctx, map[string]any{
+			"project":     a.project,
+			"type":        a.subtaskType,
+			"summary":     item.Summary,
+			"description": item.Description,
+			"labels":      []string{PostmortemLabel},
+			"parent":      key,
+		})
+		if err != nil {
+			slog.Warn("postmortem.action_item.failed", "issue", key, "action_item", item.Summary, "error", err)
+			filing.Failed = append(filing.Failed, item.Summary)
+			continue
+		}
+		filing.ActionItems = append(filing.ActionItems, sub)
+	}
+	slog.Info("postmortem.filed",
+		"incident", pm.IncidentID,
+		"issue", key,
+		"action_items", len(filing.ActionItems),
+		"failed", len(filing.Failed),
+	)
+	return filing, nil
+}
+
+func (a *postmortemAgent) createIssue(ctx context.Context, params map[string]any) (string, error) {
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Issue   *struct {
+			Key string `json:"key"`
+		} `json:"issue"`
+	}
+	req := map[string]any{"operation": "create_issue", "create_issue_params": params}
+	if err := handleJSON(ctx, a.jira, req, &resp); err != nil {
+		return "", err
+	}
+	if !resp.Success || resp.Issue == nil {
+		return "", errors.New(resp.Message)
+	}
+	return resp.Issue.Key, nil
+}
+
+// logEntries 获取事件日志，按发生顺序排列
+func (a *postmortemAgent) logEntries(ctx context.Context, id string) ([]IncidentLogEntry, error) {
+	var resp struct {
+		Success    bool               `json:"success"`
+		Message    string             `json:"message"`
+		LogEntries []IncidentLogEntry `json:"log_entries"`
+	}
+	req := map[string]any{
+		"operation":        "list_log_entries",
+		"list_log_entries": map[string]string{"incident_id": id},
+	}
+	if err := handleJSON(ctx, a.pagerduty, req, &resp); err != nil {
+		return nil, fmt.Errorf("list log entries of %s: %w", id, err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("list log entries of %s failed: %s", id, resp.Message)
+	}
+	return resp.LogEntries, nil
+}
+
+// relatedIssues 查找提到该事件的 Jira issue；查询失败不影响起草
+func (a *postmortemAgent) relatedIssues(ctx context.Context, id string) []RelatedIssue {
+	var resp struct {
+		Success bool           `json:"success"`
+		Message string         `json:"message"`
+		Issues  []RelatedIssue `json:"issues"`
+	}
+	req := map[string]any{
+		"operation": "list_issues",
+		"list_issues_params": map[string]any{
+			"jql":         fmt.Sprintf("text ~ %q ORDER BY created ASC", id),
+			"max_results": maxRelatedIssues,
+		},
+	}
+	err := handleJSON(ctx, a.jira, req, &resp)
+	if err == nil && !resp.Success {
+		err = errors.New(resp.Message)
+	}
+	if err != nil {
+		slog.Warn("postmortem.related_issues.failed", "incident", id, "error", err)
+		return nil
+	}
+	return resp.Issues
+}
+
+func (a *postmortemAgent) message(invocation *blades.Invocation, text string) *blades.Message {
+	msg := blades.NewAssistantMessage(blades.StatusCompleted)
+	msg.Author = a.Name()
+	msg.InvocationID = invocation.ID
+	msg.Parts = []blades.Part{blades.TextPart{Text: text}}
+	return msg
+}
+
+// handleJSON 以 JSON 调用 service 工具并解析响应，不经过模型
+func handleJSON(ctx context.Context, tool tools.Tool, req, resp any) error {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	output, err := tool.Handle(ctx, string(input))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(output), resp); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// renderPostmortemBrief 渲染事件详情、事件日志与相关 issue，作为起草阶段的输入
+func renderPostmortemBrief(incident *Incident, entries []IncidentLogEntry, related []RelatedIssue) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## 事件复盘：%s\n\n", incident.Title)
+	fmt.Fprintf(&b, "- **Incident ID**：%s\n", incident.ID)
+	fmt.Fprintf(&b, "- **服务**：%s\n", incident.ServiceName)
+	fmt.Fprintf(&b, "- **状态**：%s（紧急程度 %s）\n", incident.Status, incident.Urgency)
+	fmt.Fprintf(&b, "- **触发时间**：%s\n", incident.CreatedAt)
+	if incident.HTMLURL != "" {
+		fmt.Fprintf(&b, "- **链接**：%s\n", incident.HTMLURL)
+	}
+
+	b.WriteString("\n### 事件日志\n\n")
+	if len(entries) == 0 {
+		b.WriteString("没有事件日志。\n")
+	} else {
+		b.WriteString("| 时间 | 类型 | 内容 |\n|------|------|------|\n")
+		for _, e := range entries {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", e.CreatedAt, e.Type, e.Summary)
+		}
+	}
+
+	b.WriteString("\n### 相关 Jira issue\n\n")
+	if len(related) == 0 {
+		b.WriteString("没有找到提到该事件的 issue。\n")
+	}
+	for _, issue := range related {
+		fmt.Fprintf(&b, "- %s [%s] %s\n", issue.Key, issue.Status, issue.Summary)
+	}
+	return b.String()
+}
+
+// knownIssues 只保留实际查询到的 issue key，避免草稿引用不存在的 issue
+func knownIssues(keys []string, related []RelatedIssue) []string {
+	var out []string
+	for _, key := range keys {
+		if slices.ContainsFunc(related, func(issue RelatedIssue) bool { return issue.Key == key }) {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+// pendingPostmortem 返回会话中等待确认的复盘草稿；会话从持久化加载时草稿为 JSON 解码后的 map
+func pendingPostmortem(ctx context.Context) *Postmortem {
+	s, ok := blades.FromSessionContext(ctx)
+	if !ok || s == nil {
+		return nil
+	}
+	switch v := s.State()[session.StateKeyPostmortemDraft].(type) {
+	case *Postmortem:
+		return v
+	case map[string]any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var pm Postmortem
+		if err := json.Unmarshal(data, &pm); err != nil || pm.IncidentID == "" {
+			return nil
+		}
+		return &pm
+	default:
+		return nil
+	}
+}
+
+// setPendingPostmortem 保存等待确认的草稿；pm 为 nil 时以 typed nil 清空，blades 内存会话不接受 nil 值
+func setPendingPostmortem(ctx context.Context, pm *Postmortem) {
+	if s, ok := blades.FromSessionContext(ctx); ok && s != nil {
+		s.SetState(session.StateKeyPostmortemDraft, pm)
+	}
+}
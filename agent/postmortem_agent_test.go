@@ -0,0 +1,264 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/internal/session"
+	"github.com/oneblade/service"
+)
+
+// operationService 按请求中的 operation 返回结果，并记录每次请求
+type operationService struct {
+	fakeService
+	handle   func(req map[string]any) string
+	requests *[]map[string]any
+}
+
+func (s operationService) AsTool() (tools.Tool, error) {
+	return tools.NewTool(s.name, s.Description(), tools.HandleFunc(func(ctx context.Context, input string) (string, error) {
+		var req map[string]any
+		if err := json.Unmarshal([]byte(input), &req); err != nil {
+			return "", err
+		}
+		*s.requests = append(*s.requests, req)
+		return s.handle(req), nil
+	})), nil
+}
+
+// postmortemJira 模拟 Jira：list_issues 返回固定 issue，create_issue 依次分配 OPS-100、OPS-101…
+func postmortemJira(requests *[]map[string]any, failCreate bool) service.Service {
+	created := 0
+	return operationService{
+		fakeService: fakeService{name: "jira", typ: service.Jira},
+		requests:    requests,
+		handle: func(req map[string]any) string {
+			switch req["operation"] {
+			case "list_issues":
+				return `{"success": true, "issues": [{"key": "OPS-7", "summary": "checkout v2.3 回滚", "status": "Done"}]}`
+			case "create_issue":
+				if failCreate {
+					return `{"success": false, "message": "issue type Postmortem not found"}`
+				}
+				key := fmt.Sprintf("OPS-%d", 100+created)
+				created++
+				return fmt.Sprintf(`{"success": true, "issue": {"key": %q}}`, key)
+			}
+			return `{"success": false, "message": "unexpected operation"}`
+		},
+	}
+}
+
+// resolvedIncidentResult 已解决的事件，复盘只针对已解决的事件
+var resolvedIncidentResult = strings.Replace(incidentResult, `"status": "triggered"`, `"status": "resolved"`, 1)
+
+func postmortemServices(jira service.Service) []service.Service {
+	return postmortemServicesWithIncident(jira, resolvedIncidentResult)
+}
+
+func postmortemServicesWithIncident(jira service.Service, incident string) []service.Service {
+	var requests []map[string]any
+	return []service.Service{
+		operationService{
+			fakeService: fakeService{name: "pd", typ: service.PagerDuty},
+			requests:    &requests,
+			handle: func(req map[string]any) string {
+				if req["operation"] == "list_log_entries" {
+					return `{"success": true, "log_entries": [
+						{"type": "trigger_log_entry", "summary": "Triggered through the API", "created_at": "2026-10-18T02:03:00Z"},
+						{"type": "resolve_log_entry", "summary": "Resolved by Alice", "created_at": "2026-10-18T02:30:00Z", "agent": "Alice"}
+					]}`
+				}
+				return incident
+			},
+		},
+		jira,
+	}
+}
+
+func TestPostmortemAgent_DraftAndFile(t *testing.T) {
+	var jiraRequests []map[string]any
+	postmortem, err := NewPostmortemAgent(PostmortemConfig{
+		Model:    structuredModel(t, "postmortem.json"),
+		Services: postmortemServices(postmortemJira(&jiraRequests, false)),
+		Project:  "OPS",
+	})
+	require.NoError(t, err)
+
+	sess := blades.NewSession()
+	runner := NewInspectionRunner(postmortem)
+	msg, err := runner.Run(context.Background(), blades.UserMessage("为 PABC123 写复盘"), blades.WithSession(sess))
+	require.NoError(t, err)
+	assert.Equal(t, consts.AgentNamePostmortem, msg.Author)
+
+	draft, ok := PostmortemFromMessage(msg)
+	require.True(t, ok)
+	assert.Equal(t, "PABC123", draft.IncidentID)
+	assert.Equal(t, []string{"OPS-7"}, draft.RelatedIssues, "只保留实际查询到的 issue")
+	assert.Len(t, draft.ActionItems, 2)
+	assert.Contains(t, msg.Text(), "回复「确认」")
+	assert.Same(t, draft, sess.State()[session.StateKeyPostmortemDraft])
+
+	// 事件日志与相关 issue 写入会话，供起草阶段使用
+	var brief string
+	for _, m := range sess.History() {
+		if _, ok := m.Metadata[MetadataIncident].(*Incident); ok {
+			brief = m.Text()
+		}
+	}
+	assert.Contains(t, brief, "| 2026-10-18T02:30:00Z | resolve_log_entry | Resolved by Alice |")
+	assert.Contains(t, brief, "- OPS-7 [Done] checkout v2.3 回滚")
+	require.Len(t, jiraRequests, 1)
+	assert.Equal(t, "list_issues", jiraRequests[0]["operation"])
+
+	msg, err = runner.Run(context.Background(), blades.UserMessage("确认"), blades.WithSession(sess))
+	require.NoError(t, err)
+	filing, ok := msg.Metadata[MetadataPostmortemFiling].(*PostmortemFiling)
+	require.True(t, ok)
+	assert.Equal(t, &PostmortemFiling{Issue: "OPS-100", ActionItems: []string{"OPS-101", "OPS-102"}}, filing)
+	assert.Equal(t, filing.Render(), msg.Text())
+	assert.Nil(t, sess.State()[session.StateKeyPostmortemDraft])
+
+	require.Len(t, jiraRequests, 4)
+	issue := jiraRequests[1]["create_issue_params"].(map[string]any)
+	assert.Equal(t, "OPS", issue["project"])
+	assert.Equal(t, DefaultPostmortemIssueType, issue["type"])
+	assert.Equal(t, "[Postmortem] PABC123: checkout 发布后 5xx 错误率过高", issue["summary"])
+	assert.Equal(t, draft.Render(), issue["description"])
+	for i, want := range []string{"为 checkout 增加发布前冒烟测试", "5xx 告警接入自动回滚"} {
+		sub := jiraRequests[2+i]["create_issue_params"].(map[string]any)
+		assert.Equal(t, DefaultPostmortemSubtaskType, sub["type"])
+		assert.Equal(t, "OPS-100", sub["parent"])
+		assert.Equal(t, want, sub["summary"])
+	}
+}
+
+func TestPostmortemAgent_Revise(t *testing.T) {
+	var jiraRequests []map[string]any
+	postmortem, err := NewPostmortemAgent(PostmortemConfig{
+		Model:    structuredModel(t, "postmortem.json"),
+		Services: postmortemServices(postmortemJira(&jiraRequests, false)),
+	})
+	require.NoError(t, err)
+
+	// 会话从持久化加载时草稿为 JSON 解码后的 map
+	sess := blades.NewSession(map[string]any{
+		session.StateKeyPostmortemDraft: map[string]any{"incident_id": "PABC123", "title": "旧草稿"},
+	})
+	msg, err := NewInspectionRunner(postmortem).Run(context.Background(), blades.UserMessage("影响改为约 1200 笔订单失败"), blades.WithSession(sess))
+	require.NoError(t, err)
+	draft, ok := PostmortemFromMessage(msg)
+	require.True(t, ok)
+	assert.Equal(t, "约 1200 笔订单失败", draft.Impact)
+	assert.Same(t, draft, sess.State()[session.StateKeyPostmortemDraft])
+}
+
+func TestPostmortemAgent_Cancel(t *testing.T) {
+	var jiraRequests []map[string]any
+	postmortem, err := NewPostmortemAgent(PostmortemConfig{
+		Model:    scriptedModel(),
+		Services: postmortemServices(postmortemJira(&jiraRequests, false)),
+	})
+	require.NoError(t, err)
+
+	sess := blades.NewSession(map[string]any{
+		session.StateKeyPostmortemDraft: &Postmortem{IncidentID: "PABC123"},
+	})
+	msg, err := NewInspectionRunner(postmortem).Run(context.Background(), blades.UserMessage("取消。"), blades.WithSession(sess))
+	require.NoError(t, err)
+	assert.Equal(t, "已放弃事件 PABC123 的复盘草稿。", msg.Text())
+	assert.Nil(t, sess.State()[session.StateKeyPostmortemDraft])
+	assert.Empty(t, jiraRequests)
+}
+
+func TestPostmortemAgent_VagueReplyDoesNotFile(t *testing.T) {
+	var jiraRequests []map[string]any
+	postmortem, err := NewPostmortemAgent(PostmortemConfig{
+		Model:    scriptedModel(),
+		Services: postmortemServices(postmortemJira(&jiraRequests, false)),
+	})
+	require.NoError(t, err)
+
+	for _, reply := range []string{"ok", "Yes!", "好的"} {
+		draft := &Postmortem{IncidentID: "PABC123"}
+		sess := blades.NewSession(map[string]any{session.StateKeyPostmortemDraft: draft})
+		msg, err := NewInspectionRunner(postmortem).Run(context.Background(), blades.UserMessage(reply), blades.WithSession(sess))
+		require.NoError(t, err)
+		assert.Equal(t, true, msg.Metadata[MetadataClarification], reply)
+		assert.Contains(t, msg.Text(), "请回复「确认」")
+		assert.Same(t, draft, sess.State()[session.StateKeyPostmortemDraft])
+	}
+	assert.Empty(t, jiraRequests)
+}
+
+func TestPostmortemAgent_UnresolvedIncident(t *testing.T) {
+	var jiraRequests []map[string]any
+	postmortem, err := NewPostmortemAgent(PostmortemConfig{
+		Model:    scriptedModel(),
+		Services: postmortemServicesWithIncident(postmortemJira(&jiraRequests, false), incidentResult),
+	})
+	require.NoError(t, err)
+
+	sess := blades.NewSession()
+	msg, err := NewInspectionRunner(postmortem).Run(context.Background(), blades.UserMessage("为 PABC123 写复盘"), blades.WithSession(sess))
+	require.NoError(t, err)
+	assert.Equal(t, "事件 PABC123 当前状态为 triggered，尚未解决，请在事件解决后再复盘。", msg.Text())
+	assert.Nil(t, sess.State()[session.StateKeyPostmortemDraft])
+	assert.Empty(t, jiraRequests)
+}
+
+func TestPostmortemAgent_FileFailureKeepsDraft(t *testing.T) {
+	var jiraRequests []map[string]any
+	postmortem, err := NewPostmortemAgent(PostmortemConfig{
+		Model:    scriptedModel(),
+		Services: postmortemServices(postmortemJira(&jiraRequests, true)),
+	})
+	require.NoError(t, err)
+
+	draft := &Postmortem{IncidentID: "PABC123", Title: "checkout 5xx"}
+	sess := blades.NewSession(map[string]any{session.StateKeyPostmortemDraft: draft})
+	_, err = NewInspectionRunner(postmortem).Run(context.Background(), blades.UserMessage("confirm"), blades.WithSession(sess))
+	require.Error(t, err)
+	assert.Equal(t, "create postmortem issue for PABC123: issue type Postmortem not found", err.Error())
+	assert.Same(t, draft, sess.State()[session.StateKeyPostmortemDraft])
+}
+
+func TestPostmortemAgent_MissingIncidentID(t *testing.T) {
+	var jiraRequests []map[string]any
+	postmortem, err := NewPostmortemAgent(PostmortemConfig{
+		Model:    scriptedModel(),
+		Services: postmortemServices(postmortemJira(&jiraRequests, false)),
+	})
+	require.NoError(t, err)
+
+	msg, err := NewInspectionRunner(postmortem).Run(context.Background(), blades.UserMessage("确认"))
+	require.NoError(t, err)
+	assert.Equal(t, true, msg.Metadata[MetadataClarification])
+	assert.Contains(t, msg.Text(), "incident ID")
+}
+
+func TestNewPostmortemAgent_RequiresServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []service.Service
+	}{
+		{name: "missing jira", services: []service.Service{fakeService{name: "pd", typ: service.PagerDuty}}},
+		{name: "missing pagerduty", services: []service.Service{fakeService{name: "jira", typ: service.Jira}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPostmortemAgent(PostmortemConfig{Model: scriptedModel(), Services: tt.services})
+			require.Error(t, err)
+			assert.Equal(t, "postmortem agent requires a pagerduty and a jira service", err.Error())
+		})
+	}
+}
@@ -1,25 +1,70 @@
 package agent
 
 import (
+	"fmt"
+
 	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tools"
 
 	"github.com/oneblade/internal/consts"
+	"github.com/oneblade/internal/forecast"
 	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/internal/prompts"
+	"github.com/oneblade/service"
 )
 
 type PredictionAgentConfig struct {
 	Model blades.ModelProvider
+	// Services 已启用的 service，作为运行时变量注入提示词；
+	// 其中的 Prometheus service 作为预测工具的数据源
+	Services []service.Service
+	// Prompts 提示词模板，为空时使用内置模板
+	Prompts *prompts.Set
+	// StructuredOutput 要求模型按 JSON Schema 输出 Forecast；
+	// 消息文本为渲染后的预测结果，结构体通过 ForecastFromMessage 获取
+	StructuredOutput bool
 }
 
 func NewPredictionAgent(cfg PredictionAgentConfig) (blades.Agent, error) {
-	return blades.NewAgent(
-		consts.AgentNamePrediction,
+	middlewares := []blades.Middleware{
+		middleware.NewAgentLogging,
+		middleware.LoadSessionHistory(),
+	}
+	opts := []blades.AgentOption{
 		blades.WithDescription(consts.PredictionAgentDescription),
-		blades.WithInstruction(consts.PredictionAgentInstruction),
+		blades.WithInstructionProvider(promptInstruction(cfg.Prompts, prompts.PredictionAgent, cfg.Services)),
 		blades.WithModel(cfg.Model),
-		blades.WithMiddleware(
-			middleware.NewAgentLogging,
-			middleware.LoadSessionHistory(),
-		),
-	)
+	}
+	forecastTools, err := buildForecastTools(cfg.Services)
+	if err != nil {
+		return nil, err
+	}
+	if len(forecastTools) > 0 {
+		opts = append(opts, blades.WithTools(forecastTools...))
+	}
+	if cfg.StructuredOutput {
+		opts = append(opts, blades.WithOutputSchema(forecastSchema))
+		middlewares = append(middlewares, structuredOutput[Forecast](MetadataForecast))
+	}
+	opts = append(opts, blades.WithMiddleware(middlewares...))
+	return blades.NewAgent(consts.AgentNamePrediction, opts...)
+}
+
+// buildForecastTools 以已启用的 Prometheus service 为数据源创建统计预测工具，没有 Prometheus 时返回空
+func buildForecastTools(services []service.Service) ([]tools.Tool, error) {
+	var sources []forecast.Source
+	for _, s := range services {
+		if s.Type() != service.Prometheus {
+			continue
+		}
+		tool, err := s.AsTool()
+		if err != nil {
+			return nil, fmt.Errorf("create tool for %s: %v", s.Name(), err)
+		}
+		sources = append(sources, forecast.Source{Name: s.Name(), Tool: tool})
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	return forecast.NewTools(sources)
 }
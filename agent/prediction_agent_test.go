@@ -1,5 +1,55 @@
 package agent
 
-// Note: NewPredictionAgent requires the full blades framework
-// to be initialized. These functions are better suited for integration tests.
-// Unit tests are skipped here as they require full framework setup.
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/service"
+)
+
+// diskMatrix 返回 query_range 结果：从 start 起每小时一个点，使用率由 0.5 每小时增长 0.01
+func diskMatrix(start time.Time, n int) string {
+	samples := make([]string, n)
+	for i := range samples {
+		samples[i] = fmt.Sprintf(`[%d, "%g"]`, start.Add(time.Duration(i)*time.Hour).Unix(), 0.5+0.01*float64(i))
+	}
+	return fmt.Sprintf(`{"operation": "query_range", "success": true, "data": [{"metric": {"mountpoint": "/data"}, "values": [%s]}]}`,
+		strings.Join(samples, ","))
+}
+
+func TestNewPredictionAgent_ForecastTools(t *testing.T) {
+	start := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	predictionAgent, err := NewPredictionAgent(PredictionAgentConfig{
+		Model: mockModel(t, "prediction.json"),
+		Services: []service.Service{
+			fakeService{name: "prom", result: diskMatrix(start, 25)},
+			fakeService{name: "logs", typ: service.OpenSearch},
+		},
+	})
+	require.NoError(t, err)
+
+	msg, err := blades.NewRunner(predictionAgent).Run(context.Background(), blades.UserMessage("/data 磁盘什么时候会满？"))
+	require.NoError(t, err)
+	assert.Equal(t, "/data 当前使用率 74%，每小时增长 1%，预计 2026-10-19 02:00 写满，建议今天内扩容。", msg.Text())
+}
+
+func TestBuildForecastTools(t *testing.T) {
+	forecastTools, err := buildForecastTools([]service.Service{fakeService{name: "logs", typ: service.OpenSearch}})
+	require.NoError(t, err)
+	assert.Empty(t, forecastTools)
+
+	forecastTools, err = buildForecastTools([]service.Service{fakeService{name: "prom"}})
+	require.NoError(t, err)
+	names := make([]string, len(forecastTools))
+	for i, tool := range forecastTools {
+		names[i] = tool.Name()
+	}
+	assert.Equal(t, []string{"ForecastLinear", "ForecastHoltWinters", "TimeToExhaustion", "DetectSeasonality"}, names)
+}
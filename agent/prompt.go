@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/blades"
+
+	"github.com/oneblade/internal/prompts"
+	"github.com/oneblade/internal/session"
+	"github.com/oneblade/service"
+)
+
+// promptInstruction 返回按会话渲染提示词模板的 InstructionProvider
+// 每次调用注入当前时间、可用的 service 与会话用户，并在会话中记录所用的模板版本；
+// set 为 nil 时使用内置的默认模板。
+func promptInstruction(set *prompts.Set, name string, services []service.Service) blades.InstructionProvider {
+	if set == nil {
+		set = prompts.Default()
+	}
+	promptServices := make([]prompts.Service, 0, len(services))
+	for _, s := range services {
+		promptServices = append(promptServices, prompts.Service{
+			Name:        s.Name(),
+			Type:        string(s.Type()),
+			Description: s.Description(),
+		})
+	}
+
+	return func(ctx context.Context) (string, error) {
+		vars := prompts.Vars{
+			Now:      time.Now(),
+			Services: promptServices,
+		}
+		if s, ok := blades.FromSessionContext(ctx); ok && s != nil {
+			vars.User, _ = s.State()[session.StateKeyUser].(string)
+			s.SetState(session.StateKeyPromptVersion, set.Version())
+		}
+		return set.Render(name, vars)
+	}
+}
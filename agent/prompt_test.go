@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/internal/prompts"
+	"github.com/oneblade/internal/session"
+	"github.com/oneblade/service"
+)
+
+func TestPromptInstruction(t *testing.T) {
+	sess := blades.NewSession(map[string]any{session.StateKeyUser: "alice"})
+	ctx := blades.NewSessionContext(context.Background(), sess)
+
+	provider := promptInstruction(nil, prompts.ReportAgent, []service.Service{fakeService{name: "prom"}})
+	text, err := provider(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, text, "巡检报告撰写专家")
+	assert.Contains(t, text, "- prom (prometheus) - 核心集群监控")
+	assert.Contains(t, text, "当前用户: alice")
+	assert.Equal(t, prompts.Default().Version(), sess.State()[session.StateKeyPromptVersion])
+}
+
+func TestPromptInstruction_Locale(t *testing.T) {
+	set, err := prompts.Load(prompts.Options{Locale: prompts.LocaleEn})
+	require.NoError(t, err)
+
+	text, err := promptInstruction(set, prompts.PredictionAgent, nil)(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, text, "You are an expert in system health forecasting.")
+	assert.NotContains(t, text, "Current user")
+}
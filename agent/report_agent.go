@@ -5,21 +5,35 @@ import (
 
 	"github.com/oneblade/internal/consts"
 	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/internal/prompts"
+	"github.com/oneblade/service"
 )
 
 type ReportAgentConfig struct {
 	Model blades.ModelProvider
+	// Services 已启用的 service，作为运行时变量注入提示词
+	Services []service.Service
+	// Prompts 提示词模板，为空时使用内置模板
+	Prompts *prompts.Set
+	// StructuredOutput 要求模型按 JSON Schema 输出 InspectionReport；
+	// 消息文本为渲染后的报告，结构体通过 ReportFromMessage 获取
+	StructuredOutput bool
 }
 
 func NewReportAgent(cfg ReportAgentConfig) (blades.Agent, error) {
-	return blades.NewAgent(
-		consts.AgentNameReport,
+	middlewares := []blades.Middleware{
+		middleware.NewAgentLogging,
+		middleware.LoadSessionHistory(),
+	}
+	opts := []blades.AgentOption{
 		blades.WithDescription(consts.ReportAgentDescription),
-		blades.WithInstruction(consts.ReportAgentInstruction),
+		blades.WithInstructionProvider(promptInstruction(cfg.Prompts, prompts.ReportAgent, cfg.Services)),
 		blades.WithModel(cfg.Model),
-		blades.WithMiddleware(
-			middleware.NewAgentLogging,
-			middleware.LoadSessionHistory(),
-		),
-	)
+	}
+	if cfg.StructuredOutput {
+		opts = append(opts, blades.WithOutputSchema(inspectionReportSchema))
+		middlewares = append(middlewares, structuredOutput[InspectionReport](MetadataInspectionReport))
+	}
+	opts = append(opts, blades.WithMiddleware(middlewares...))
+	return blades.NewAgent(consts.AgentNameReport, opts...)
 }
@@ -5,23 +5,37 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
 	"text/template"
 
 	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tools"
 	"github.com/google/jsonschema-go/jsonschema"
 
 	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/internal/session"
 )
 
 // 说明：
 // - blades/flow/routing.go 依赖 blades/internal/handoff（Go internal 机制导致本仓库无法直接 import）。
 // - 这里以“同等行为”的方式复刻 handoff tool + instruction 构建逻辑，并额外注入 middleware.LoadSessionHistory。
+// - 在此基础上支持多跳：被路由的 agent 可以继续转交或交还给 orchestrator（受 MaxHops 限制），
+//   意图不明确时通过 ask_clarification 向用户提问，目标不存在时回退到 DefaultAgent。
+// - 配置了 Rules 时先按规则确定性路由，命中则跳过 orchestrator 的模型调用。
 
 const (
-	actionHandoffToAgent = "handoff_to_agent"
+	actionHandoffToAgent   = "handoff_to_agent"
+	actionAskClarification = "ask_clarification"
 )
 
+// DefaultMaxHops 单次请求默认允许的最大 handoff 次数
+const DefaultMaxHops = 3
+
+// MetadataClarification 标记向用户提出澄清问题的消息
+const MetadataClarification = "clarification"
+
 const handoffInstructionTemplate = `You have access to the following agents:
 {{range .Targets}}
 Agent Name: {{.Name}}
@@ -30,17 +44,20 @@ Agent Description: {{.Description}}
 Your task:
 - Determine whether you are the most appropriate agent to answer the user's question based on your own description.
 - If another agent is clearly better suited to handle the user's request, you must transfer the query by calling the "handoff_to_agent" function.
+- If the query was transferred to you but another agent is better suited, transfer it onward the same way; if none of the agents above fits, transfer it back to "{{.Router}}".
+- If the user's intent is ambiguous and you cannot tell which agent should handle it, call the "ask_clarification" function with one short question for the user instead of guessing.
 - If no other agent is more suitable, respond to the user directly as a helpful assistant, providing clear, detailed, and accurate information.
 
 Important rules:
-- When transferring a query, output only the function call, and nothing else.
+- When transferring a query or asking for clarification, output only the function call, and nothing else.
 - Do not include explanations, reasoning, or any additional text outside of the function call.`
 
 var handoffToAgentPromptTmpl = template.Must(template.New("handoff_to_agent_prompt").Parse(handoffInstructionTemplate))
 
-func buildHandoffInstruction(targets []blades.Agent) (string, error) {
+func buildHandoffInstruction(router string, targets []blades.Agent) (string, error) {
 	var buf bytes.Buffer
 	if err := handoffToAgentPromptTmpl.Execute(&buf, map[string]any{
+		"Router":  router,
 		"Targets": targets,
 	}); err != nil {
 		return "", err
@@ -85,30 +102,83 @@ func (h *handoffTool) Handle(ctx context.Context, input string) (string, error)
 	return "", nil
 }
 
+// clarificationTool 意图不明确时向用户提出澄清问题，本轮路由随之结束
+type clarificationTool struct{}
+
+func (c *clarificationTool) Name() string { return actionAskClarification }
+func (c *clarificationTool) Description() string {
+	return `Ask the user a clarifying question.
+Use this tool when the user's intent is ambiguous and no agent can be chosen with confidence.`
+}
+func (c *clarificationTool) InputSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:     "object",
+		Required: []string{"question"},
+		Properties: map[string]*jsonschema.Schema{
+			"question": {
+				Type:        "string",
+				Description: "One short question that resolves the ambiguity.",
+			},
+		},
+	}
+}
+func (c *clarificationTool) OutputSchema() *jsonschema.Schema { return nil }
+func (c *clarificationTool) Handle(ctx context.Context, input string) (string, error) {
+	args := map[string]string{}
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return "", err
+	}
+	question := strings.TrimSpace(args["question"])
+	if question == "" {
+		return "", fmt.Errorf("question must be a non-empty string")
+	}
+	toolCtx, ok := blades.FromToolContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("tool context not found in context")
+	}
+	toolCtx.SetAction(actionAskClarification, question)
+	return "", nil
+}
+
 type RoutingConfig struct {
 	Name        string
 	Description string
 	Model       blades.ModelProvider
 	SubAgents   []blades.Agent
+	// MaxHops 单次请求允许的最大 handoff 次数，默认 DefaultMaxHops
+	MaxHops int
+	// DefaultAgent handoff 目标不存在时回退到的子 agent 名称，为空时返回错误
+	DefaultAgent string
+	// Rules 在 orchestrator 模型路由之前按顺序匹配的规则
+	Rules []RoutingRule
+	// Metrics 规则命中计数，为空时使用独立的计数；热加载时传入同一实例以保留计数
+	Metrics *middleware.RoutingMetrics
 }
 
 type routingAgent struct {
 	blades.Agent
 	targets map[string]blades.Agent
+	// instruction 与 tools 随 handoff 传递给被路由的 agent，使其可以继续转交或提问
+	instruction  string
+	tools        []tools.Tool
+	maxHops      int
+	defaultAgent blades.Agent
+	rules        *ruleRouter
 }
 
 func NewRoutingAgent(config RoutingConfig) (blades.Agent, error) {
-	instruction, err := buildHandoffInstruction(config.SubAgents)
+	instruction, err := buildHandoffInstruction(config.Name, config.SubAgents)
 	if err != nil {
 		return nil, err
 	}
+	routingTools := []tools.Tool{&handoffTool{}, &clarificationTool{}}
 
 	rootAgent, err := blades.NewAgent(
 		config.Name,
 		blades.WithModel(config.Model),
 		blades.WithDescription(config.Description),
 		blades.WithInstruction(instruction),
-		blades.WithTools(&handoffTool{}),
+		blades.WithTools(routingTools...),
 		blades.WithMiddleware(
 			middleware.NewAgentLogging,
 			middleware.LoadSessionHistory(),
@@ -122,54 +192,186 @@ func NewRoutingAgent(config RoutingConfig) (blades.Agent, error) {
 	for _, agent := range config.SubAgents {
 		targets[strings.TrimSpace(agent.Name())] = agent
 	}
+
+	var defaultAgent blades.Agent
+	if config.DefaultAgent != "" {
+		agent, ok := targets[config.DefaultAgent]
+		if !ok {
+			return nil, fmt.Errorf("default agent %s is not a sub agent of %s", config.DefaultAgent, config.Name)
+		}
+		defaultAgent = agent
+	}
+
+	for _, rule := range config.Rules {
+		if _, ok := targets[rule.Agent]; !ok {
+			return nil, fmt.Errorf("routing rule %s: agent %s is not a sub agent of %s", rule.Name, rule.Agent, config.Name)
+		}
+	}
+	metrics := config.Metrics
+	if metrics == nil {
+		metrics = middleware.NewRoutingMetrics()
+	}
+	rules, err := newRuleRouter(config.Rules, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	maxHops := config.MaxHops
+	if maxHops <= 0 {
+		maxHops = DefaultMaxHops
+	}
 	return &routingAgent{
-		Agent:   rootAgent,
-		targets: targets,
+		Agent:        rootAgent,
+		targets:      targets,
+		instruction:  instruction,
+		tools:        routingTools,
+		maxHops:      maxHops,
+		defaultAgent: defaultAgent,
+		rules:        rules,
 	}, nil
 }
 
 func (a *routingAgent) Run(ctx context.Context, invocation *blades.Invocation) blades.Generator[*blades.Message, error] {
 	return func(yield func(*blades.Message, error) bool) {
 		var (
-			targetAgent string
-			lastMessage *blades.Message
+			current blades.Agent = a.Agent
+			path                 = []string{a.Name()}
 		)
-		for message, err := range a.Agent.Run(ctx, invocation) {
-			if err != nil {
-				yield(nil, err)
-				return
-			}
-			// Check for handoff action
-			if target, ok := message.Actions[actionHandoffToAgent]; ok {
-				targetAgent, _ = target.(string)
-				lastMessage = message
-				break
+		if target := a.matchRule(invocation); target != nil {
+			current = target
+			path = append(path, target.Name())
+		}
+		a.recordPath(ctx, path)
+
+		for {
+			var (
+				targetAgent string
+				question    string
+				lastMessage *blades.Message
+			)
+			for message, err := range current.Run(ctx, a.hopInvocation(current, invocation)) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				// Check for handoff and clarification actions
+				if target, ok := message.Actions[actionHandoffToAgent]; ok {
+					targetAgent, _ = target.(string)
+					lastMessage = message
+					break
+				}
+				if q, ok := message.Actions[actionAskClarification]; ok {
+					question, _ = q.(string)
+					break
+				}
+				// Yield intermediate messages to preserve streaming
+				if !yield(message, nil) {
+					return
+				}
 			}
-			// Yield intermediate messages to preserve streaming
-			if !yield(message, nil) {
+
+			if question != "" {
+				yield(a.clarification(current.Name(), invocation, question), nil)
 				return
 			}
-			lastMessage = message
-		}
-		agent, ok := a.targets[targetAgent]
-		if !ok {
 			// No handoff occurred, the last message was already yielded in the loop
 			if targetAgent == "" {
 				return
 			}
-			yield(nil, fmt.Errorf("target agent not found: %s", targetAgent))
-			return
-		}
-		// If handoff message has text content, yield it before switching
-		if lastMessage != nil && lastMessage.Text() != "" {
-			if !yield(lastMessage, nil) {
+
+			next, ok := a.resolve(targetAgent)
+			if !ok {
+				yield(nil, fmt.Errorf("target agent not found: %s", targetAgent))
 				return
 			}
-		}
-		for message, err := range agent.Run(ctx, invocation) {
-			if !yield(message, err) {
+			if len(path) > a.maxHops {
+				yield(nil, fmt.Errorf("routing exceeded %d hops: %s -> %s", a.maxHops, strings.Join(path, " -> "), next.Name()))
 				return
 			}
+			// If handoff message has text content, yield it before switching
+			if lastMessage != nil && lastMessage.Text() != "" {
+				if !yield(lastMessage, nil) {
+					return
+				}
+			}
+
+			slog.Info("routing.handoff",
+				"from", current.Name(),
+				"to", next.Name(),
+				"hop", len(path),
+			)
+			path = append(path, next.Name())
+			a.recordPath(ctx, path)
+			current = next
 		}
 	}
 }
+
+// RoutingStats 返回规则路由的命中统计
+func (a *routingAgent) RoutingStats() middleware.RoutingStats {
+	return a.rules.metrics.Snapshot()
+}
+
+// matchRule 按规则匹配用户消息，命中时返回目标 agent
+func (a *routingAgent) matchRule(invocation *blades.Invocation) blades.Agent {
+	if len(a.rules.rules) == 0 || invocation.Message == nil {
+		return nil
+	}
+	rule := a.rules.route(invocation.Message.Text())
+	if rule == nil {
+		return nil
+	}
+	return a.targets[rule.Agent]
+}
+
+// hopInvocation 为每一跳构建独立的 invocation，避免指令与工具在多次运行间累积
+// 被路由的 agent 继承 orchestrator 的 handoff 指令与工具；orchestrator 自身运行时会自行注入。
+// 流程 agent 会把 invocation 传给每个成员，因此不注入，由流程完整运行后交还结果。
+func (a *routingAgent) hopInvocation(agent blades.Agent, invocation *blades.Invocation) *blades.Invocation {
+	inv := invocation.Clone()
+	if agent == a.Agent {
+		return inv
+	}
+	if _, ok := agent.(*flowAgent); ok {
+		return inv
+	}
+	inv.Instruction = blades.MergeParts(blades.SystemMessage(a.instruction), inv.Instruction)
+	inv.Tools = append(inv.Tools, a.tools...)
+	return inv
+}
+
+// resolve 返回 handoff 目标；目标为 orchestrator 自身时交还重新路由，不存在时回退到默认 agent
+func (a *routingAgent) resolve(name string) (blades.Agent, bool) {
+	if name == a.Name() {
+		return a.Agent, true
+	}
+	if agent, ok := a.targets[name]; ok {
+		return agent, true
+	}
+	if a.defaultAgent != nil {
+		slog.Warn("routing.target.unknown",
+			"target", name,
+			"fallback", a.defaultAgent.Name(),
+		)
+		return a.defaultAgent, true
+	}
+	return nil, false
+}
+
+// clarification 构建向用户提问的消息，用户的回答在下一轮请求中重新路由
+func (a *routingAgent) clarification(author string, invocation *blades.Invocation, question string) *blades.Message {
+	slog.Info("routing.clarification", "agent", author)
+	msg := blades.NewAssistantMessage(blades.StatusCompleted)
+	msg.Author = author
+	msg.InvocationID = invocation.ID
+	msg.Parts = []blades.Part{blades.TextPart{Text: question}}
+	msg.Metadata[MetadataClarification] = true
+	return msg
+}
+
+// recordPath 在会话状态中记录本次请求经过的 agent 路径
+func (a *routingAgent) recordPath(ctx context.Context, path []string) {
+	if s, ok := blades.FromSessionContext(ctx); ok && s != nil {
+		s.SetState(session.StateKeyRoutePath, slices.Clone(path))
+	}
+}
@@ -0,0 +1,380 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/internal/llm"
+	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/internal/session"
+)
+
+// scriptedModel 按顺序返回给定回合的 mock 模型
+func scriptedModel(turns ...llm.FixtureTurn) blades.ModelProvider {
+	return llm.NewMockProvider("mock", &llm.Fixture{Turns: turns})
+}
+
+func clarify(question string) llm.FixtureTurn {
+	args, _ := json.Marshal(map[string]string{"question": question})
+	return llm.FixtureTurn{ToolCalls: []llm.FixtureToolCall{{Name: actionAskClarification, Arguments: args}}}
+}
+
+func TestRoutingAgent_Run(t *testing.T) {
+	tests := []struct {
+		name         string
+		turns        map[string][]llm.FixtureTurn
+		maxHops      int
+		defaultAgent string
+		wantAuthor   string
+		wantText     string
+		wantPath     []string
+		wantErr      string
+	}{
+		{
+			name: "single handoff",
+			turns: map[string][]llm.FixtureTurn{
+				"orchestrator":  {{Handoff: "metrics_agent"}},
+				"metrics_agent": {{Text: "CPU 使用率 45%"}},
+			},
+			wantAuthor: "metrics_agent",
+			wantText:   "CPU 使用率 45%",
+			wantPath:   []string{"orchestrator", "metrics_agent"},
+		},
+		{
+			name: "routed agent hands onward",
+			turns: map[string][]llm.FixtureTurn{
+				"orchestrator":  {{Handoff: "metrics_agent"}},
+				"metrics_agent": {{Handoff: "report_agent"}},
+				"report_agent":  {{Text: "# 巡检报告"}},
+			},
+			wantAuthor: "report_agent",
+			wantText:   "# 巡检报告",
+			wantPath:   []string{"orchestrator", "metrics_agent", "report_agent"},
+		},
+		{
+			name: "routed agent hands back to router",
+			turns: map[string][]llm.FixtureTurn{
+				"orchestrator":  {{Handoff: "metrics_agent"}, {Handoff: "report_agent"}},
+				"metrics_agent": {{Handoff: "orchestrator"}},
+				"report_agent":  {{Text: "# 巡检报告"}},
+			},
+			wantAuthor: "report_agent",
+			wantText:   "# 巡检报告",
+			wantPath:   []string{"orchestrator", "metrics_agent", "orchestrator", "report_agent"},
+		},
+		{
+			name: "router asks for clarification",
+			turns: map[string][]llm.FixtureTurn{
+				"orchestrator": {clarify("需要查询指标还是生成报告？")},
+			},
+			wantAuthor: "orchestrator",
+			wantText:   "需要查询指标还是生成报告？",
+			wantPath:   []string{"orchestrator"},
+		},
+		{
+			name: "routed agent asks for clarification",
+			turns: map[string][]llm.FixtureTurn{
+				"orchestrator":  {{Handoff: "metrics_agent"}},
+				"metrics_agent": {clarify("要查询哪个集群？")},
+			},
+			wantAuthor: "metrics_agent",
+			wantText:   "要查询哪个集群？",
+			wantPath:   []string{"orchestrator", "metrics_agent"},
+		},
+		{
+			name: "unknown target falls back to default agent",
+			turns: map[string][]llm.FixtureTurn{
+				"orchestrator": {{Handoff: "capacity_agent"}},
+				"report_agent": {{Text: "# 巡检报告"}},
+			},
+			defaultAgent: "report_agent",
+			wantAuthor:   "report_agent",
+			wantText:     "# 巡检报告",
+			wantPath:     []string{"orchestrator", "report_agent"},
+		},
+		{
+			name: "unknown target without default agent",
+			turns: map[string][]llm.FixtureTurn{
+				"orchestrator": {{Handoff: "capacity_agent"}},
+			},
+			wantErr:  "target agent not found: capacity_agent",
+			wantPath: []string{"orchestrator"},
+		},
+		{
+			name: "max hops exceeded",
+			turns: map[string][]llm.FixtureTurn{
+				"orchestrator":  {{Handoff: "metrics_agent"}},
+				"metrics_agent": {{Handoff: "report_agent"}},
+			},
+			maxHops:  1,
+			wantErr:  "routing exceeded 1 hops: orchestrator -> metrics_agent -> report_agent",
+			wantPath: []string{"orchestrator", "metrics_agent"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subAgents []blades.Agent
+			for _, name := range []string{"metrics_agent", "report_agent"} {
+				agent, err := blades.NewAgent(name,
+					blades.WithDescription(name),
+					blades.WithModel(scriptedModel(tt.turns[name]...)),
+				)
+				require.NoError(t, err)
+				subAgents = append(subAgents, agent)
+			}
+
+			router, err := NewRoutingAgent(RoutingConfig{
+				Name:         "orchestrator",
+				Model:        scriptedModel(tt.turns["orchestrator"]...),
+				SubAgents:    subAgents,
+				MaxHops:      tt.maxHops,
+				DefaultAgent: tt.defaultAgent,
+			})
+			require.NoError(t, err)
+
+			sess := blades.NewSession()
+			msg, err := NewInspectionRunner(router).Run(context.Background(), blades.UserMessage("看看系统情况"), blades.WithSession(sess))
+			assert.Equal(t, tt.wantPath, sess.State()[session.StateKeyRoutePath])
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAuthor, msg.Author)
+			assert.Equal(t, tt.wantText, msg.Text())
+		})
+	}
+}
+
+func TestRoutingAgent_ClarificationMetadata(t *testing.T) {
+	router, err := NewRoutingAgent(RoutingConfig{
+		Name:  "orchestrator",
+		Model: scriptedModel(clarify("需要查询指标还是生成报告？")),
+	})
+	require.NoError(t, err)
+
+	msg, err := NewInspectionRunner(router).Run(context.Background(), blades.UserMessage("看看"))
+	require.NoError(t, err)
+	assert.Equal(t, true, msg.Metadata[MetadataClarification])
+}
+
+func TestRoutingAgent_HopInvocation(t *testing.T) {
+	report, err := blades.NewAgent("report_agent", blades.WithModel(scriptedModel()))
+	require.NoError(t, err)
+	analysis := newAnalysisFlow(map[string]blades.Agent{"report_agent": report}, nil)
+
+	router, err := NewRoutingAgent(RoutingConfig{
+		Name:      "orchestrator",
+		Model:     scriptedModel(),
+		SubAgents: []blades.Agent{report, analysis},
+	})
+	require.NoError(t, err)
+	ra := router.(*routingAgent)
+	require.NotEmpty(t, ra.tools)
+
+	// 直接路由目标注入 handoff 工具
+	inv := ra.hopInvocation(report, &blades.Invocation{})
+	assert.Len(t, inv.Tools, len(ra.tools))
+	assert.NotNil(t, inv.Instruction)
+
+	// 流程成员不注入，避免中途 handoff 打断流程
+	inv = ra.hopInvocation(analysis, &blades.Invocation{})
+	assert.Empty(t, inv.Tools)
+	assert.Nil(t, inv.Instruction)
+}
+
+func TestNewRoutingAgent_UnknownDefaultAgent(t *testing.T) {
+	_, err := NewRoutingAgent(RoutingConfig{
+		Name:         "orchestrator",
+		Model:        scriptedModel(),
+		DefaultAgent: "report_agent",
+	})
+	require.Error(t, err)
+	assert.Equal(t, "default agent report_agent is not a sub agent of orchestrator", err.Error())
+}
+
+func TestRoutingAgent_Rules(t *testing.T) {
+	rules := []RoutingRule{
+		{Name: "alerts", Agent: "service_agent", Keywords: []string{"告警", "Incident"}, Command: "/alerts"},
+		{Name: "report", Agent: "report_agent", Pattern: `^(生成|出).*报告`},
+	}
+	tests := []struct {
+		name       string
+		message    string
+		turns      map[string][]llm.FixtureTurn
+		wantAuthor string
+		wantPath   []string
+	}{
+		{
+			name:       "keyword match skips the router model",
+			message:    "列出最近的告警",
+			turns:      map[string][]llm.FixtureTurn{"service_agent": {{Text: "最近 1 小时无告警"}}},
+			wantAuthor: "service_agent",
+			wantPath:   []string{"orchestrator", "service_agent"},
+		},
+		{
+			name:       "keywords ignore case",
+			message:    "any open incidents?",
+			turns:      map[string][]llm.FixtureTurn{"service_agent": {{Text: "无 incident"}}},
+			wantAuthor: "service_agent",
+			wantPath:   []string{"orchestrator", "service_agent"},
+		},
+		{
+			name:       "slash command match",
+			message:    "/alerts prod",
+			turns:      map[string][]llm.FixtureTurn{"service_agent": {{Text: "prod 无告警"}}},
+			wantAuthor: "service_agent",
+			wantPath:   []string{"orchestrator", "service_agent"},
+		},
+		{
+			name:       "pattern match",
+			message:    "生成今天的巡检报告",
+			turns:      map[string][]llm.FixtureTurn{"report_agent": {{Text: "# 巡检报告"}}},
+			wantAuthor: "report_agent",
+			wantPath:   []string{"orchestrator", "report_agent"},
+		},
+		{
+			name:    "no rule falls through to the router model",
+			message: "CPU 使用率怎么样",
+			turns: map[string][]llm.FixtureTurn{
+				"orchestrator":  {{Handoff: "service_agent"}},
+				"service_agent": {{Text: "CPU 使用率 45%"}},
+			},
+			wantAuthor: "service_agent",
+			wantPath:   []string{"orchestrator", "service_agent"},
+		},
+		{
+			name:    "routed agent can still hand off",
+			message: "/alerts",
+			turns: map[string][]llm.FixtureTurn{
+				"service_agent": {{Handoff: "report_agent"}},
+				"report_agent":  {{Text: "# 告警报告"}},
+			},
+			wantAuthor: "report_agent",
+			wantPath:   []string{"orchestrator", "service_agent", "report_agent"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subAgents []blades.Agent
+			for _, name := range []string{"service_agent", "report_agent"} {
+				agent, err := blades.NewAgent(name,
+					blades.WithDescription(name),
+					blades.WithModel(scriptedModel(tt.turns[name]...)),
+				)
+				require.NoError(t, err)
+				subAgents = append(subAgents, agent)
+			}
+
+			router, err := NewRoutingAgent(RoutingConfig{
+				Name:      "orchestrator",
+				Model:     scriptedModel(tt.turns["orchestrator"]...),
+				SubAgents: subAgents,
+				Rules:     rules,
+			})
+			require.NoError(t, err)
+
+			sess := blades.NewSession()
+			msg, err := NewInspectionRunner(router).Run(context.Background(), blades.UserMessage(tt.message), blades.WithSession(sess))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAuthor, msg.Author)
+			assert.Equal(t, tt.wantPath, sess.State()[session.StateKeyRoutePath])
+		})
+	}
+}
+
+func TestRuleRouter_HitRate(t *testing.T) {
+	metrics := middleware.NewRoutingMetrics()
+	router, err := newRuleRouter([]RoutingRule{
+		{Name: "alerts", Agent: "service_agent", Command: "/alerts"},
+		{Name: "logs", Agent: "service_agent", Keywords: []string{"日志"}},
+	}, metrics)
+	require.NoError(t, err)
+
+	for _, text := range []string{"/alerts", "/ALERTS prod", "查一下错误日志", "CPU 怎么样"} {
+		router.route(text)
+	}
+	stats := metrics.Snapshot()
+	assert.Equal(t, middleware.RoutingStats{
+		Requests: 4,
+		Misses:   1,
+		Hits:     map[string]int64{"alerts": 2, "logs": 1},
+	}, stats)
+	assert.Equal(t, 0.75, stats.HitRate())
+	assert.Equal(t, 0.5, stats.RuleHitRate("alerts"))
+}
+
+// TestRoutingAgent_RoutingStats 验证重建 routing agent（如配置热加载）时共享的计数得以保留
+func TestRoutingAgent_RoutingStats(t *testing.T) {
+	metrics := middleware.NewRoutingMetrics()
+	rules := []RoutingRule{{Name: "report", Agent: "report_agent", Command: "/report"}}
+	newRouter := func() blades.Agent {
+		report, err := blades.NewAgent("report_agent", blades.WithModel(scriptedModel(llm.FixtureTurn{Text: "报告"})))
+		require.NoError(t, err)
+		router, err := NewRoutingAgent(RoutingConfig{
+			Name:      "orchestrator",
+			Model:     scriptedModel(),
+			SubAgents: []blades.Agent{report},
+			Rules:     rules,
+			Metrics:   metrics,
+		})
+		require.NoError(t, err)
+		return router
+	}
+
+	for range 2 {
+		_, err := NewInspectionRunner(newRouter()).Run(context.Background(), blades.UserMessage("/report"))
+		require.NoError(t, err)
+	}
+	stats := newRouter().(*routingAgent).RoutingStats()
+	assert.Equal(t, int64(2), stats.Requests)
+	assert.Equal(t, int64(2), stats.Hits["report"])
+	assert.Equal(t, 1.0, stats.RuleHitRate("report"))
+}
+
+func TestNewRoutingAgent_InvalidRules(t *testing.T) {
+	report, err := blades.NewAgent("report_agent", blades.WithModel(scriptedModel()))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		rule    RoutingRule
+		wantErr string
+	}{
+		{
+			name:    "unknown agent",
+			rule:    RoutingRule{Name: "alerts", Agent: "service_agent", Command: "/alerts"},
+			wantErr: "routing rule alerts: agent service_agent is not a sub agent of orchestrator",
+		},
+		{
+			name:    "invalid pattern",
+			rule:    RoutingRule{Name: "report", Agent: "report_agent", Pattern: "报告("},
+			wantErr: "routing rule report: invalid pattern",
+		},
+		{
+			name:    "no matcher",
+			rule:    RoutingRule{Name: "report", Agent: "report_agent", Keywords: []string{" "}},
+			wantErr: "routing rule report: one of keywords, pattern or command is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRoutingAgent(RoutingConfig{
+				Name:      "orchestrator",
+				Model:     scriptedModel(),
+				SubAgents: []blades.Agent{report},
+				Rules:     []RoutingRule{tt.rule},
+			})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/oneblade/internal/middleware"
+)
+
+// RoutingRule 在调用 orchestrator 模型之前按顺序匹配用户消息的确定性路由规则，
+// Keywords / Pattern / Command 任一匹配即命中，直接转交给 Agent
+type RoutingRule struct {
+	Name  string
+	Agent string
+	// Keywords 消息包含任一关键词即命中（忽略大小写）
+	Keywords []string
+	// Pattern 消息匹配的正则
+	Pattern string
+	// Command 斜杠命令，如 "/alerts"，消息的第一个词等于该命令即命中
+	Command string
+}
+
+type compiledRule struct {
+	RoutingRule
+	keywords []string
+	pattern  *regexp.Regexp
+}
+
+func (r *compiledRule) match(text string) bool {
+	if r.Command != "" {
+		if fields := strings.Fields(text); len(fields) > 0 && strings.EqualFold(fields[0], r.Command) {
+			return true
+		}
+	}
+	if r.pattern != nil && r.pattern.MatchString(text) {
+		return true
+	}
+	lower := strings.ToLower(text)
+	for _, keyword := range r.keywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleRouter 按顺序匹配路由规则，并将命中情况计入 metrics
+type ruleRouter struct {
+	rules   []*compiledRule
+	metrics *middleware.RoutingMetrics
+}
+
+func newRuleRouter(rules []RoutingRule, metrics *middleware.RoutingMetrics) (*ruleRouter, error) {
+	r := &ruleRouter{metrics: metrics}
+	for _, rule := range rules {
+		compiled := &compiledRule{RoutingRule: rule}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("routing rule %s: invalid pattern: %w", rule.Name, err)
+			}
+			compiled.pattern = pattern
+		}
+		for _, keyword := range rule.Keywords {
+			if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+				compiled.keywords = append(compiled.keywords, keyword)
+			}
+		}
+		if len(compiled.keywords) == 0 && compiled.pattern == nil && rule.Command == "" {
+			return nil, fmt.Errorf("routing rule %s: one of keywords, pattern or command is required", rule.Name)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+	return r, nil
+}
+
+// route 返回第一个命中的规则，未命中时返回 nil
+func (r *ruleRouter) route(text string) *compiledRule {
+	requests := r.metrics.Request()
+	text = strings.TrimSpace(text)
+	for _, rule := range r.rules {
+		if !rule.match(text) {
+			continue
+		}
+		hits := r.metrics.Hit(rule.Name)
+		slog.Info("routing.rule.hit",
+			"rule", rule.Name,
+			"agent", rule.Agent,
+			"hits", hits,
+			"requests", requests,
+		)
+		return rule
+	}
+	misses := r.metrics.Miss()
+	slog.Debug("routing.rule.miss",
+		"misses", misses,
+		"requests", requests,
+	)
+	return nil
+}
@@ -2,42 +2,33 @@ package agent
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/go-kratos/blades"
 	"github.com/go-kratos/blades/tools"
 
 	"github.com/oneblade/internal/consts"
 	"github.com/oneblade/internal/middleware"
+	"github.com/oneblade/internal/prompts"
 	"github.com/oneblade/service"
 )
 
 type ServiceAgent struct {
 	Model    blades.ModelProvider
 	Services []service.Service
+	// Prompts 提示词模板，为空时使用内置模板
+	Prompts *prompts.Set
 }
 
 func NewServiceAgent(cfg ServiceAgent) (blades.Agent, error) {
-	var serviceTools []tools.Tool
-	var serviceDescriptions []string
-
-	for _, s := range cfg.Services {
-		tool, err := s.AsTool()
-		if err != nil {
-			return nil, fmt.Errorf("create tool for %s: %v", s.Name(), err)
-		}
-		serviceTools = append(serviceTools, tool)
-
-		desc := fmt.Sprintf("%d. **%s** (%s) - %s", len(serviceTools), s.Name(), s.Type(), s.Description())
-		serviceDescriptions = append(serviceDescriptions, desc)
+	serviceTools, _, err := buildServiceTools(cfg.Services)
+	if err != nil {
+		return nil, err
 	}
 
-	instruction := fmt.Sprintf(consts.ServiceAgentInstruction, strings.Join(serviceDescriptions, "\n"))
-
 	return blades.NewAgent(
 		consts.AgentNameService,
 		blades.WithDescription(consts.ServiceAgentDescription),
-		blades.WithInstruction(instruction),
+		blades.WithInstructionProvider(promptInstruction(cfg.Prompts, prompts.ServiceAgent, cfg.Services)),
 		blades.WithModel(cfg.Model),
 		blades.WithTools(serviceTools...),
 		blades.WithMiddleware(
@@ -46,3 +37,21 @@ func NewServiceAgent(cfg ServiceAgent) (blades.Agent, error) {
 		),
 	)
 }
+
+// buildServiceTools 将每个 service 转换为工具，并生成供提示词使用的编号描述列表
+func buildServiceTools(services []service.Service) ([]tools.Tool, []string, error) {
+	var serviceTools []tools.Tool
+	var serviceDescriptions []string
+
+	for _, s := range services {
+		tool, err := s.AsTool()
+		if err != nil {
+			return nil, nil, fmt.Errorf("create tool for %s: %v", s.Name(), err)
+		}
+		serviceTools = append(serviceTools, tool)
+
+		desc := fmt.Sprintf("%d. **%s** (%s) - %s", len(serviceTools), s.Name(), s.Type(), s.Description())
+		serviceDescriptions = append(serviceDescriptions, desc)
+	}
+	return serviceTools, serviceDescriptions, nil
+}
@@ -0,0 +1,228 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-kratos/blades"
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"github.com/oneblade/internal/llm"
+)
+
+// 结构化输出在消息 metadata 中的 key，值分别为 *InspectionReport 与 *Forecast
+const (
+	MetadataInspectionReport = "inspection_report"
+	MetadataForecast         = "forecast"
+)
+
+// InspectionReport report_agent 的结构化巡检报告
+type InspectionReport struct {
+	Summary         string          `json:"summary" jsonschema:"执行摘要，一到三句话概括整体情况"`
+	HealthScore     int             `json:"health_score" jsonschema:"系统健康评分，0-100"`
+	Status          string          `json:"status" jsonschema:"整体状态"`
+	Findings        []ReportFinding `json:"findings" jsonschema:"关键指标、告警与日志中发现的问题，按严重程度从高到低排列"`
+	Risks           []string        `json:"risks" jsonschema:"风险评估"`
+	Recommendations []string        `json:"recommendations" jsonschema:"可操作的改进建议"`
+}
+
+// ReportFinding 巡检报告中的单项发现
+type ReportFinding struct {
+	Category string `json:"category" jsonschema:"来源：指标、告警或日志"`
+	Service  string `json:"service" jsonschema:"相关的服务或组件"`
+	Severity string `json:"severity" jsonschema:"严重程度"`
+	Title    string `json:"title" jsonschema:"一句话标题"`
+	Detail   string `json:"detail" jsonschema:"详情与依据"`
+}
+
+// Forecast prediction_agent 的结构化预测结果
+type Forecast struct {
+	Summary         string       `json:"summary" jsonschema:"预测结论摘要"`
+	Horizon         string       `json:"horizon" jsonschema:"预测的时间范围，如 24h、7d"`
+	Predictions     []Prediction `json:"predictions" jsonschema:"各维度的预测"`
+	Recommendations []string     `json:"recommendations" jsonschema:"容量规划与风险规避建议"`
+}
+
+// Prediction 单项预测
+type Prediction struct {
+	Target      string  `json:"target" jsonschema:"预测对象，如服务、指标或资源"`
+	Dimension   string  `json:"dimension" jsonschema:"预测维度"`
+	Trend       string  `json:"trend" jsonschema:"变化趋势"`
+	Risk        string  `json:"risk" jsonschema:"风险等级"`
+	Confidence  float64 `json:"confidence" jsonschema:"置信度，0-1"`
+	Description string  `json:"description" jsonschema:"预测依据与结论"`
+}
+
+var (
+	inspectionReportSchema = mustSchema[InspectionReport]("inspection_report", "巡检报告", func(s *jsonschema.Schema) {
+		s.Properties["health_score"].Minimum = float64Ptr(0)
+		s.Properties["health_score"].Maximum = float64Ptr(100)
+		s.Properties["status"].Enum = []any{"healthy", "warning", "critical"}
+		finding := s.Properties["findings"].Items
+		finding.Properties["category"].Enum = []any{"metric", "alert", "log"}
+		finding.Properties["severity"].Enum = []any{"info", "warning", "critical"}
+	})
+	forecastSchema = mustSchema[Forecast]("forecast", "系统健康预测", func(s *jsonschema.Schema) {
+		prediction := s.Properties["predictions"].Items
+		prediction.Properties["dimension"].Enum = []any{"resource", "alert", "availability", "capacity"}
+		prediction.Properties["trend"].Enum = []any{"up", "down", "stable"}
+		prediction.Properties["risk"].Enum = []any{"low", "medium", "high"}
+		prediction.Properties["confidence"].Minimum = float64Ptr(0)
+		prediction.Properties["confidence"].Maximum = float64Ptr(1)
+	})
+)
+
+// mustSchema 由 Go 类型生成 JSON Schema；类型固定，生成失败属于编程错误
+func mustSchema[T any](title, description string, refine func(*jsonschema.Schema)) *jsonschema.Schema {
+	s, err := jsonschema.For[T](nil)
+	if err != nil {
+		panic(err)
+	}
+	s.Title = title
+	s.Description = description
+	if refine != nil {
+		refine(s)
+	}
+	return s
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+// ReportFromMessage 返回 report_agent 消息中的结构化巡检报告
+func ReportFromMessage(msg *blades.Message) (*InspectionReport, bool) {
+	report, ok := msg.Metadata[MetadataInspectionReport].(*InspectionReport)
+	return report, ok
+}
+
+// ForecastFromMessage 返回 prediction_agent 消息中的结构化预测结果
+func ForecastFromMessage(msg *blades.Message) (*Forecast, bool) {
+	forecast, ok := msg.Metadata[MetadataForecast].(*Forecast)
+	return forecast, ok
+}
+
+// Render 将巡检报告渲染为 Markdown
+func (r *InspectionReport) Render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## 巡检报告\n\n**整体状态**：%s　**健康评分**：%d/100\n\n%s\n", statusLabel(r.Status), r.HealthScore, r.Summary)
+	if len(r.Findings) > 0 {
+		b.WriteString("\n### 关键发现\n\n")
+		for _, f := range r.Findings {
+			fmt.Fprintf(&b, "- [%s] %s", severityLabel(f.Severity), f.Title)
+			if f.Service != "" {
+				fmt.Fprintf(&b, "（%s）", f.Service)
+			}
+			if f.Detail != "" {
+				fmt.Fprintf(&b, "：%s", f.Detail)
+			}
+			b.WriteString("\n")
+		}
+	}
+	writeList(&b, "风险评估", r.Risks)
+	writeList(&b, "改进建议", r.Recommendations)
+	return b.String()
+}
+
+// Render 将预测结果渲染为 Markdown
+func (f *Forecast) Render() string {
+	var b strings.Builder
+	b.WriteString("## 健康预测\n\n")
+	if f.Horizon != "" {
+		fmt.Fprintf(&b, "**预测范围**：%s\n\n", f.Horizon)
+	}
+	b.WriteString(f.Summary + "\n")
+	if len(f.Predictions) > 0 {
+		b.WriteString("\n### 预测明细\n\n")
+		for _, p := range f.Predictions {
+			fmt.Fprintf(&b, "- %s：趋势%s，风险%s（置信度 %.0f%%）", p.Target, trendLabel(p.Trend), riskLabel(p.Risk), p.Confidence*100)
+			if p.Description != "" {
+				fmt.Fprintf(&b, "，%s", p.Description)
+			}
+			b.WriteString("\n")
+		}
+	}
+	writeList(&b, "建议", f.Recommendations)
+	return b.String()
+}
+
+func writeList(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(b, "- %s\n", item)
+	}
+}
+
+func statusLabel(s string) string {
+	return label(s, map[string]string{"healthy": "健康", "warning": "警告", "critical": "严重"})
+}
+
+func severityLabel(s string) string {
+	return label(s, map[string]string{"info": "提示", "warning": "警告", "critical": "严重"})
+}
+
+func trendLabel(s string) string {
+	return label(s, map[string]string{"up": "上升", "down": "下降", "stable": "平稳"})
+}
+
+func riskLabel(s string) string {
+	return label(s, map[string]string{"low": "低", "medium": "中", "high": "高"})
+}
+
+func label(s string, labels map[string]string) string {
+	if l, ok := labels[s]; ok {
+		return l
+	}
+	return s
+}
+
+// structuredOutput 将模型返回的结构化 JSON 解析为 T，写入消息 metadata（key 为 metadataKey），
+// 并把消息文本替换为渲染后的 Markdown，使会话历史与下游 agent 看到的仍是可读文本。
+//
+// schema 校验与修复重试由 llm 层完成，这里解析失败时保留原文本。
+func structuredOutput[T any, PT interface {
+	*T
+	Render() string
+}](metadataKey string) blades.Middleware {
+	return func(next blades.Handler) blades.Handler {
+		return blades.HandleFunc(func(ctx context.Context, inv *blades.Invocation) blades.Generator[*blades.Message, error] {
+			return func(yield func(*blades.Message, error) bool) {
+				for msg, err := range next.Handle(ctx, inv) {
+					if err == nil && msg != nil && msg.Role == blades.RoleAssistant && msg.Status == blades.StatusCompleted {
+						renderStructured[T, PT](msg, metadataKey)
+					}
+					if !yield(msg, err) {
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+func renderStructured[T any, PT interface {
+	*T
+	Render() string
+}](msg *blades.Message, metadataKey string) {
+	data, ok := llm.StructuredOutput(msg)
+	if !ok {
+		slog.Warn("agent.structured.missing", "agent", msg.Author, "metadata_key", metadataKey)
+		return
+	}
+	v := PT(new(T))
+	if err := json.Unmarshal(data, v); err != nil {
+		slog.Warn("agent.structured.decode_failed", "agent", msg.Author, "metadata_key", metadataKey, "error", err)
+		return
+	}
+	if msg.Metadata == nil {
+		msg.Metadata = make(map[string]any)
+	}
+	msg.Metadata[metadataKey] = v
+	msg.Parts = []blades.Part{blades.TextPart{Text: v.Render()}}
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/internal/llm"
+)
+
+// structuredModel 以非原生 JSON 模式的 provider 包装 mock 模型，覆盖提示词约束与修复重试
+func structuredModel(t *testing.T, fixture string) blades.ModelProvider {
+	t.Helper()
+	return llm.NewStructuredProvider(mockModel(t, fixture), "mock")
+}
+
+func TestNewReportAgent_StructuredOutput(t *testing.T) {
+	reportAgent, err := NewReportAgent(ReportAgentConfig{
+		Model:            structuredModel(t, "structured_report.json"),
+		StructuredOutput: true,
+	})
+	require.NoError(t, err)
+
+	msg, err := blades.NewRunner(reportAgent).Run(context.Background(), blades.UserMessage("生成巡检报告"))
+	require.NoError(t, err)
+
+	report, ok := ReportFromMessage(msg)
+	require.True(t, ok)
+	assert.Equal(t, 85, report.HealthScore)
+	assert.Equal(t, "warning", report.Status)
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, "prometheus", report.Findings[0].Service)
+	assert.Equal(t, []string{"清理过期数据或扩容"}, report.Recommendations)
+
+	// 消息文本为渲染后的报告而非 JSON
+	assert.Equal(t, report.Render(), msg.Text())
+	assert.Contains(t, msg.Text(), "**整体状态**：警告")
+	assert.Contains(t, msg.Text(), "- [警告] 磁盘使用率 85%（prometheus）")
+}
+
+func TestNewPredictionAgent_StructuredOutput(t *testing.T) {
+	predictionAgent, err := NewPredictionAgent(PredictionAgentConfig{
+		Model:            structuredModel(t, "structured_forecast.json"),
+		StructuredOutput: true,
+	})
+	require.NoError(t, err)
+
+	msg, err := blades.NewRunner(predictionAgent).Run(context.Background(), blades.UserMessage("预测未来一周"))
+	require.NoError(t, err)
+
+	forecast, ok := ForecastFromMessage(msg)
+	require.True(t, ok)
+	assert.Equal(t, "7d", forecast.Horizon)
+	require.Len(t, forecast.Predictions, 1)
+	assert.InDelta(t, 0.8, forecast.Predictions[0].Confidence, 1e-9)
+	assert.Contains(t, msg.Text(), "- node-1 内存：趋势上升，风险中（置信度 80%）")
+}
+
+func TestNewReportAgent_Unstructured(t *testing.T) {
+	reportAgent, err := NewReportAgent(ReportAgentConfig{Model: mockModel(t, "handoff_report.json")})
+	require.NoError(t, err)
+
+	msg, err := blades.NewRunner(reportAgent).Run(context.Background(), blades.UserMessage("生成巡检报告"))
+	require.NoError(t, err)
+
+	_, ok := ReportFromMessage(msg)
+	assert.False(t, ok)
+	assert.Contains(t, msg.Text(), "所有服务运行正常")
+}
+
+func TestStructuredSchemas(t *testing.T) {
+	tests := []struct {
+		name    string
+		resolve func() error
+	}{
+		{name: "inspection report", resolve: func() error { _, err := inspectionReportSchema.Resolve(nil); return err }},
+		{name: "forecast", resolve: func() error { _, err := forecastSchema.Resolve(nil); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.resolve())
+		})
+	}
+	assert.Equal(t, "inspection_report", inspectionReportSchema.Title)
+	assert.Equal(t, []string{"summary", "health_score", "status", "findings", "risks", "recommendations"}, inspectionReportSchema.Required)
+}
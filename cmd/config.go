@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/oneblade/config"
+	"github.com/oneblade/internal/app"
+	"github.com/oneblade/service"
+)
+
+// runConfigCommand 执行 config 子命令
+//
+// 用法：
+//
+//	oneblade [--config path] [--profile name] config print [--resolved]
+//	oneblade [--config path] [--profile name] config validate
+//	oneblade config schema
+func runConfigCommand(args []string, configPath, profile string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: config <print|validate|schema> [flags]")
+	}
+
+	fs := flag.NewFlagSet("config "+args[0], flag.ContinueOnError)
+	fs.StringVar(&configPath, "config", configPath, "配置文件路径")
+	fs.StringVar(&profile, "profile", profile, "环境 profile，如 prod 会合并 config.prod.toml")
+
+	switch args[0] {
+	case "print":
+		resolved := fs.Bool("resolved", false, "输出合并后的最终配置（敏感信息已脱敏）")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return printConfig(stdout, configPath, profile, *resolved)
+	case "validate":
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return validateConfig(stdout, configPath, profile)
+	case "schema":
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return printSchema(stdout)
+	default:
+		return fmt.Errorf("unknown config command %q", args[0])
+	}
+}
+
+// printConfig 输出配置来源文件，resolved 时输出合并后的 TOML
+func printConfig(w io.Writer, configPath, profile string, resolved bool) error {
+	loader := config.NewLoader(configPath, config.WithProfile(profile))
+	res, err := loader.Resolve()
+	if err != nil {
+		return err
+	}
+	if resolved {
+		return res.WriteMasked(w)
+	}
+	for _, src := range res.Sources {
+		if _, err := fmt.Fprintln(w, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateConfig 执行完整的配置校验，包括延迟解析的 [services.<name>.options]
+func validateConfig(w io.Writer, configPath, profile string) error {
+	application, err := app.NewApplication(configPath, config.WithProfile(profile))
+	if err != nil {
+		return fmt.Errorf("create application: %w", err)
+	}
+	cfg, err := application.Validate()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "config ok: %d agent(s), %d service(s)\n", len(cfg.Agents), len(cfg.Services))
+	return err
+}
+
+// printSchema 输出 Config 与已注册 service options 的 JSON Schema
+func printSchema(w io.Writer) error {
+	optionsTypes := service.OptionsTypes()
+	serviceOptions := make(map[string]reflect.Type, len(optionsTypes))
+	for serviceType, t := range optionsTypes {
+		serviceOptions[string(serviceType)] = t
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(config.Schema(serviceOptions))
+}
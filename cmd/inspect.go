@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/oneblade/config"
+	"github.com/oneblade/internal/app"
+)
+
+// runInspectCommand 执行 inspect 子命令，按巡检清单执行一次巡检并输出报告
+//
+// 用法：
+//
+//	oneblade [--config path] [--profile name] inspect --playbook <name|path>
+func runInspectCommand(ctx context.Context, args []string, configPath, profile string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.StringVar(&configPath, "config", configPath, "配置文件路径")
+	fs.StringVar(&profile, "profile", profile, "环境 profile，如 prod 会合并 config.prod.toml")
+	name := fs.String("playbook", "", "巡检清单名称（[playbooks] dir 下的 <name>.toml）或文件路径")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("usage: inspect --playbook <name|path>")
+	}
+
+	application, err := app.NewApplication(configPath, config.WithProfile(profile))
+	if err != nil {
+		return fmt.Errorf("create application: %w", err)
+	}
+	if err := application.Initialize(ctx); err != nil {
+		return fmt.Errorf("initialize application: %w", err)
+	}
+	defer func() {
+		if err := application.ShutdownWithTimeout(5 * time.Second); err != nil {
+			slog.Warn("[inspect] shutdown failed", "error", err)
+		}
+	}()
+
+	msg, err := application.RunPlaybook(ctx, *name)
+	if err != nil {
+		return fmt.Errorf("run playbook %s: %w", *name, err)
+	}
+	_, err = fmt.Fprintln(stdout, msg.Text())
+	return err
+}
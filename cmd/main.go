@@ -9,26 +9,44 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/oneblade/config"
 	"github.com/oneblade/internal/app"
 	"github.com/oneblade/internal/repl"
 )
 
 func main() {
 	configPath := flag.String("config", "./config.toml", "配置文件路径")
+	profile := flag.String("profile", "", "环境 profile，如 prod 会合并 config.prod.toml")
 	flag.Parse()
 
+	if args := flag.Args(); len(args) > 0 && args[0] == "config" {
+		if err := runConfigCommand(args[1:], *configPath, *profile, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	if err := run(ctx, *configPath); err != nil {
+	if args := flag.Args(); len(args) > 0 && args[0] == "inspect" {
+		if err := runInspectCommand(ctx, args[1:], *configPath, *profile, os.Stdout); err != nil {
+			slog.Error("inspect failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(ctx, *configPath, *profile); err != nil {
 		slog.Error("application error", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context, configPath string) error {
+func run(ctx context.Context, configPath, profile string) error {
 	// Build application
-	application, err := app.NewApplication(configPath)
+	application, err := app.NewApplication(configPath, config.WithProfile(profile))
 	if err != nil {
 		return fmt.Errorf("create application: %w", err)
 	}
@@ -40,7 +58,14 @@ func run(ctx context.Context, configPath string) error {
 	if err := application.Initialize(ctx); err != nil {
 		return fmt.Errorf("initialize application: %w", err)
 	}
-	slog.Info("[main] application initialized", "config_path", configPath)
+	slog.Info("[main] application initialized", "config_path", configPath, "profile", profile)
+
+	// Hot reload config.toml on change or SIGHUP
+	go func() {
+		if err := application.WatchConfig(ctx); err != nil {
+			slog.Error("[main] config watch stopped", "error", err)
+		}
+	}()
 
 	// Create and run REPL
 	r, err := repl.NewREPL(ctx, repl.WithApplication(application))
@@ -13,20 +13,105 @@ import (
 // - 本项目采用“严格 per-agent 配置”策略：不做继承/合并。
 // - required 字段缺失应在启动时直接报错；可选字段缺失由运行时填充默认值。
 type AgentLLMConfig struct {
-	Provider    string   `toml:"provider" validate:"required,oneof=openai gemini anthropic"`
+	Provider    string   `toml:"provider" validate:"required,oneof=openai gemini anthropic azure_openai vertex bedrock ollama openai_compatible mock"`
 	Model       string   `toml:"model" validate:"required"`
 	APIKey      string   `toml:"api_key"`
 	BaseURL     string   `toml:"base_url"`
 	Timeout     string   `toml:"timeout"`
 	MaxTokens   *int     `toml:"max_tokens" validate:"omitempty,gt=0"`
 	Temperature *float64 `toml:"temperature" validate:"omitempty,gte=0,lte=2"`
+	// 以下采样参数均为可选，未配置时使用模型默认值；provider 不支持的参数会在构建模型时报错
+	// TopP nucleus sampling 概率阈值（openai / anthropic / gemini / ollama）
+	TopP *float64 `toml:"top_p" validate:"omitempty,gt=0,lte=1"`
+	// TopK 仅从概率最高的 K 个 token 中采样（anthropic / gemini）
+	TopK *int `toml:"top_k" validate:"omitempty,gt=0"`
+	// StopSequences 生成遇到任一序列即停止
+	StopSequences []string `toml:"stop_sequences" validate:"omitempty,max=16,dive,required"`
+	// Seed 随机种子，尽力保证相同输入得到相同输出（openai / gemini / ollama）
+	Seed *int64 `toml:"seed" validate:"omitempty,gt=0"`
+	// PresencePenalty / FrequencyPenalty 重复惩罚，取值 (0, 2]（openai / gemini / ollama）
+	PresencePenalty  *float64 `toml:"presence_penalty" validate:"omitempty,gt=0,lte=2"`
+	FrequencyPenalty *float64 `toml:"frequency_penalty" validate:"omitempty,gt=0,lte=2"`
+	// ThinkingBudget 扩展思考的 token 预算（anthropic：>= 1024 且小于 max_tokens；gemini：0 关闭，-1 由模型自行决定）
+	ThinkingBudget *int `toml:"thinking_budget" validate:"omitempty,gte=-1"`
+	// ReasoningEffort 推理强度（openai 推理模型 / ollama / openai_compatible）
+	ReasoningEffort string `toml:"reasoning_effort" validate:"omitempty,oneof=minimal low medium high"`
+	// 云厂商托管端点（azure_openai / vertex / bedrock）相关配置
+	// Deployment Azure OpenAI 部署名，默认与 model 相同；base_url 为资源端点（如 https://xxx.openai.azure.com）
+	Deployment string `toml:"deployment"`
+	// APIVersion Azure OpenAI API 版本，默认 "2024-10-21"
+	APIVersion string `toml:"api_version"`
+	// Project Vertex AI 项目 ID，未配置时读取 GOOGLE_CLOUD_PROJECT
+	Project string `toml:"project"`
+	// Location Vertex AI 区域，未配置时读取 GOOGLE_CLOUD_LOCATION，默认 "us-central1"
+	Location string `toml:"location"`
+	// CredentialsFile Vertex AI 服务账号密钥文件，未配置时使用 ADC（Application Default Credentials）
+	CredentialsFile string `toml:"credentials_file"`
+	// Region AWS Bedrock 区域，未配置时使用 AWS 默认配置链（AWS_REGION、~/.aws/config）
+	Region string `toml:"region"`
+	// MaxRetries 超时、429、5xx 等临时错误的最大重试次数（指数退避 + 随机抖动），默认 2，0 表示不重试
+	MaxRetries *int `toml:"max_retries" validate:"omitempty,gte=0,lte=10"`
+	// RequestsPerMinute 每分钟请求数上限，0 表示不限制
+	// 相同 provider + base_url + api_key 的 agent 共享同一额度。
+	RequestsPerMinute int `toml:"requests_per_minute" validate:"omitempty,gt=0"`
+	// TokensPerMinute 每分钟 token 上限，0 表示不限制
+	// 请求前按字符数估算输入 token，响应后按实际用量校正；共享规则同 RequestsPerMinute。
+	TokensPerMinute int `toml:"tokens_per_minute" validate:"omitempty,gt=0"`
+	// Fallbacks 主模型出现超时、429、5xx 等临时错误时按顺序尝试的备用模型
+	// 每个备用模型同样采用严格配置（不继承主模型的字段），且不支持再嵌套 fallbacks。
+	// 配置了 fallbacks 时只有最后一个模型按 max_retries 重试，其余模型第一次失败即切换。
+	Fallbacks []AgentLLMConfig `toml:"fallbacks" validate:"omitempty,dive"`
+	// Fixture mock provider 的脚本文件（.json）或录制文件（.jsonl），仅 provider = "mock" 时使用
+	Fixture string `toml:"fixture" validate:"required_if=Provider mock"`
+	// Record 非空时把每次请求/响应追加写入该 JSONL 文件，可作为 mock 的 fixture 回放
+	// 录制内容包含完整的提示词与工具结果，请按日志同等级别保管。
+	Record string `toml:"record"`
+	// Cache 响应缓存，默认关闭
+	Cache LLMCacheConfig `toml:"cache"`
+}
+
+// LLMCacheConfig 模型响应缓存配置
+//
+// 缓存 key 由模型、生成参数、instruction、消息与工具 schema 计算；错误响应不缓存。
+// temperature > 0 时输出不确定，默认自动跳过缓存，需要时通过 Force 强制开启。
+type LLMCacheConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Backend 缓存后端：file（默认，进程重启后仍有效）或 memory
+	Backend string `toml:"backend" validate:"omitempty,oneof=file memory"`
+	// Dir file 后端的缓存目录，默认为用户缓存目录下的 oneblade/llm
+	Dir string `toml:"dir"`
+	// TTL 缓存有效期，默认 "1h"
+	TTL string `toml:"ttl"`
+	// Force temperature > 0 时仍然缓存
+	Force bool `toml:"force"`
 }
 
 // AgentConfig Agent 配置
 // 包含 enabled 开关和 LLM 配置
+//
+// LLM 可以内联配置，也可以通过 Model 引用 [models.<name>] 中的模型配置（二者只能选其一）；
+// 引用在 Load 时展开到 LLM，因此其余代码只需读取 LLM。
 type AgentConfig struct {
 	Enabled bool           `toml:"enabled"`
+	Model   string         `toml:"model"`
 	LLM     AgentLLMConfig `toml:"llm"`
+	// StructuredOutput 要求模型按 JSON Schema 输出结构化结果（仅 report_agent / prediction_agent 支持）
+	// 支持原生 JSON 模式的 provider 直接使用，其余 provider 通过提示词约束并在校验失败时要求模型修正。
+	StructuredOutput bool `toml:"structured_output"`
+
+	// 以下字段用于声明自定义 agent（名称不是内置 agent 时生效），内置 agent 不支持
+	// Description 供 orchestrator 路由时判断是否交给该 agent，自定义 agent 必填
+	Description string `toml:"description"`
+	// Instruction 系统提示词，与 InstructionFile 二选一，自定义 agent 必填其一
+	Instruction string `toml:"instruction"`
+	// InstructionFile 从文件读取系统提示词，相对路径基于声明它的配置文件所在目录，在 Load 时读入 Instruction
+	InstructionFile string `toml:"instruction_file"`
+	// Services 允许调用的 service（[services.<name>] 的名称），每个 service 作为一个工具提供
+	Services []string `toml:"services" validate:"omitempty,dive,required"`
+	// Tools 允许调用的内置工具名称，如 Memory、SaveContext、LoadContext、Time、ConvertUnit、ReadFile
+	Tools []string `toml:"tools" validate:"omitempty,dive,required"`
+	// Middleware 启用的中间件，未配置时默认 ["logging", "history"]
+	Middleware []string `toml:"middleware" validate:"omitempty,dive,oneof=logging history"`
 }
 
 func (c *Config) GetAgentConfig(agentName string) (*AgentConfig, error) {
@@ -58,6 +143,8 @@ type ConversationConfig struct {
 	SummaryMaxOutputTokens int `toml:"summary_max_output_tokens" validate:"omitempty,gt=0"`
 	// SummaryModelAgent 使用哪个已注册的 agent 模型进行摘要（通过 ModelRegistry 获取）
 	SummaryModelAgent string `toml:"summary_model_agent" validate:"omitempty"`
+	// SummaryModel 使用哪个 [models.<name>] 模型进行摘要，配置后优先于 SummaryModelAgent
+	SummaryModel string `toml:"summary_model" validate:"omitempty"`
 }
 
 // ConversationConfig 默认值常量
@@ -71,12 +158,94 @@ const (
 
 // Config 根配置结构
 type Config struct {
-	Server       ServerConfig             `toml:"server" validate:"required"`
-	Data         DataConfig               `toml:"data"`
-	Log          LogConfig                `toml:"log"`
-	Conversation ConversationConfig       `toml:"conversation"`
-	Agents       map[string]AgentConfig   `toml:"agents" validate:"required,dive"`
-	Services     map[string]ServiceConfig `toml:"services" validate:"required,dive"`
+	Server       ServerConfig       `toml:"server" validate:"required"`
+	Data         DataConfig         `toml:"data"`
+	Log          LogConfig          `toml:"log"`
+	Conversation ConversationConfig `toml:"conversation"`
+	Prompts      PromptsConfig      `toml:"prompts"`
+	Playbooks    PlaybooksConfig    `toml:"playbooks"`
+	Routing      RoutingConfig      `toml:"routing"`
+	Tools        ToolsConfig        `toml:"tools"`
+	// Investigation investigation_agent 的事件调查配置
+	Investigation InvestigationConfig `toml:"investigation"`
+	// Postmortem postmortem_agent 创建复盘 issue 的配置
+	Postmortem PostmortemConfig `toml:"postmortem"`
+	// Models 可被多个 agent 共享的命名模型配置，agent 通过 model = "<name>" 引用
+	Models   map[string]AgentLLMConfig `toml:"models" validate:"omitempty,dive"`
+	Agents   map[string]AgentConfig    `toml:"agents" validate:"required,dive"`
+	Services map[string]ServiceConfig  `toml:"services" validate:"required,dive"`
+}
+
+// PromptsConfig 提示词模板配置
+type PromptsConfig struct {
+	// Locale 内置 agent 提示词的语言：zh（默认）或 en
+	Locale string `toml:"locale" validate:"omitempty,oneof=zh en"`
+	// Dir 模板覆盖目录，按 <dir>/<locale>/<name>.tmpl 放置需要覆盖的模板，相对路径基于声明它的配置文件所在目录
+	Dir string `toml:"dir"`
+}
+
+// PlaybooksConfig 巡检清单配置
+type PlaybooksConfig struct {
+	// Dir 巡检清单目录，inspect --playbook <name> 读取 <dir>/<name>.toml，默认为主配置文件目录下的 playbooks，相对路径基于声明它的配置文件所在目录
+	Dir string `toml:"dir"`
+}
+
+// DefaultPlaybooksDir 默认的巡检清单目录
+const DefaultPlaybooksDir = "playbooks"
+
+// InvestigationConfig 事件调查配置
+type InvestigationConfig struct {
+	// MaxIterations 调查阶段最多执行的查询次数，默认 8
+	MaxIterations int `toml:"max_iterations" validate:"omitempty,gte=1,lte=50"`
+	// Window 以事件触发时间为中心前后各查询的时长，默认 30m
+	Window time.Duration `toml:"window" validate:"omitempty,gte=0"`
+}
+
+// PostmortemConfig 事件复盘配置
+type PostmortemConfig struct {
+	// Project 复盘 issue 所在的 Jira 项目，默认使用 jira service 的默认项目
+	Project string `toml:"project"`
+	// IssueType 复盘 issue 的类型，默认 Task
+	IssueType string `toml:"issue_type"`
+	// SubtaskType 改进项子任务的类型，默认 Sub-task
+	SubtaskType string `toml:"subtask_type"`
+}
+
+// ToolsConfig 内置本地工具配置
+type ToolsConfig struct {
+	Files FilesToolConfig `toml:"files"`
+}
+
+// FilesToolConfig 只读文件工具 ReadFile 的配置
+type FilesToolConfig struct {
+	// Dirs 允许读取的目录，相对路径基于声明它的配置文件所在目录；为空时不提供 ReadFile 工具
+	Dirs []string `toml:"dirs" validate:"omitempty,dive,required"`
+	// MaxBytes 单次读取的最大字节数，超出部分被截断，默认 256KiB
+	MaxBytes int64 `toml:"max_bytes" validate:"omitempty,gte=1"`
+}
+
+// RoutingConfig orchestrator 路由配置
+type RoutingConfig struct {
+	// MaxHops 单次请求允许的最大 handoff 次数（包括子 agent 之间的继续转交与交还 orchestrator），默认 3
+	MaxHops int `toml:"max_hops" validate:"omitempty,gte=1,lte=10"`
+	// DefaultAgent handoff 目标不存在时回退到的子 agent，为空时直接报错
+	DefaultAgent string `toml:"default_agent"`
+	// Rules 在 LLM 路由之前按顺序匹配的规则，命中时直接转交给对应 agent，未命中再由 orchestrator 模型路由
+	Rules []RoutingRule `toml:"rules" validate:"omitempty,dive"`
+}
+
+// RoutingRule 确定性路由规则，keywords / pattern / command 至少配置一项，任一匹配即命中
+type RoutingRule struct {
+	// Name 规则名称，用于日志中的命中统计
+	Name string `toml:"name" validate:"required"`
+	// Agent 命中后转交的子 agent
+	Agent string `toml:"agent" validate:"required"`
+	// Keywords 用户消息包含任一关键词即命中（忽略大小写）
+	Keywords []string `toml:"keywords"`
+	// Pattern 用户消息匹配该正则即命中
+	Pattern string `toml:"pattern"`
+	// Command 斜杠命令，如 "/alerts"，用户消息以该命令开头即命中
+	Command string `toml:"command"`
 }
 
 // LogConfig 日志配置
@@ -116,4 +285,6 @@ type ServiceConfig struct {
 	Description string         `toml:"description"`
 	Enabled     bool           `toml:"enabled"`
 	Options     toml.Primitive `toml:"options"`
+	// CollectTimeout 分析流程采集阶段中该 service 的采集超时，默认 60s
+	CollectTimeout time.Duration `toml:"collect_timeout" validate:"omitempty,gt=0"`
 }
@@ -0,0 +1,278 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// includeKey 顶层 include 指令，值为相对当前文件的路径数组
+const includeKey = "include"
+
+// maskedValue 打印配置时替换敏感值
+const maskedValue = "******"
+
+// sensitiveKeyPattern 匹配需要脱敏的配置项名称
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)(api_?key|password|passwd|secret|token|credential)`)
+
+// LoaderOption Loader 可选项
+type LoaderOption func(*Loader)
+
+// WithProfile 指定环境 profile，加载时在基础配置之上合并 <name>.<profile>.toml
+func WithProfile(profile string) LoaderOption {
+	return func(l *Loader) {
+		l.profile = profile
+	}
+}
+
+// ProfilePath 返回基础配置文件对应的 profile 覆盖文件路径
+// 例如 config.toml + prod => config.prod.toml
+func ProfilePath(base, profile string) string {
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "." + profile + ext
+}
+
+// Resolved 合并后的配置文档
+type Resolved struct {
+	// Sources 参与合并的文件（绝对路径），按合并顺序排列，后者覆盖前者
+	Sources []string
+	// Document 合并且展开占位符后的原始文档，不包含 include 指令
+	Document map[string]any
+
+	secrets secretSet
+}
+
+// Resolve 解析 include 与 profile 并合并为单个文档，不做结构校验
+//
+// 合并规则：
+// - include 的文件先于当前文件合并，当前文件中的值覆盖被 include 的值。
+// - profile 覆盖文件（及其 include）最后合并。
+// - table 递归合并，数组与标量整体替换。
+func (l *Loader) Resolve() (*Resolved, error) {
+	r := &Resolved{Document: make(map[string]any), secrets: make(secretSet)}
+	if err := r.mergeFile(l.configPath, nil); err != nil {
+		return nil, err
+	}
+	if l.profile != "" {
+		overlay := ProfilePath(l.configPath, l.profile)
+		if _, err := os.Stat(overlay); err != nil {
+			return nil, fmt.Errorf("load profile %q: %w", l.profile, err)
+		}
+		if err := r.mergeFile(overlay, nil); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// mergeFile 加载单个文件及其 include 并合并到 r.Document
+// stack 为当前 include 链，用于检测循环引用。
+func (r *Resolved) mergeFile(path string, stack []string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("load config file %s: %w", path, err)
+	}
+	for _, p := range stack {
+		if p == absPath {
+			return fmt.Errorf("include cycle: %s", strings.Join(append(stack, absPath), " -> "))
+		}
+	}
+	stack = append(stack, absPath)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("load config file %s: %w", path, err)
+	}
+	expanded, err := expandEnv(string(content), r.secrets)
+	if err != nil {
+		return fmt.Errorf("load config file %s: %w", path, err)
+	}
+
+	// 每个文件先单独按 Config 解码一次，类型错误与未知配置项按所在文件与行号报告，
+	// 合并后重新编码的文档行号与用户文件无关。
+	var typed Config
+	meta, err := toml.Decode(expanded, &typed)
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	if unknown := unknownKeys(&meta); len(unknown) > 0 {
+		return fmt.Errorf("parse config file %s: unknown keys: %v", path, unknown)
+	}
+
+	var doc map[string]any
+	if _, err := toml.Decode(expanded, &doc); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	includes, err := popIncludes(doc)
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	resolveRelativePaths(doc, filepath.Dir(absPath))
+	for _, inc := range includes {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(absPath), inc)
+		}
+		if err := r.mergeFile(inc, stack); err != nil {
+			return err
+		}
+	}
+
+	mergeTables(r.Document, doc)
+	r.Sources = append(r.Sources, absPath)
+	return nil
+}
+
+// popIncludes 取出并删除 include 指令
+func popIncludes(doc map[string]any) ([]string, error) {
+	v, ok := doc[includeKey]
+	if !ok {
+		return nil, nil
+	}
+	delete(doc, includeKey)
+
+	items, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array of strings", includeKey)
+	}
+	includes := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok || s == "" {
+			return nil, fmt.Errorf("%s must be an array of strings", includeKey)
+		}
+		includes = append(includes, s)
+	}
+	return includes, nil
+}
+
+// resolveRelativePaths 将文件中的相对路径配置项改写为基于该文件所在目录的绝对路径
+// 合并后无法再区分配置项来自哪个文件，因此在合并前解析，使被 include 的文件可以放在其他目录。
+func resolveRelativePaths(doc map[string]any, dir string) {
+	resolve := func(v any) any {
+		if path, ok := v.(string); ok && path != "" && !filepath.IsAbs(path) {
+			return filepath.Join(dir, path)
+		}
+		return v
+	}
+	for _, name := range []string{"prompts", "playbooks"} {
+		if table, ok := doc[name].(map[string]any); ok {
+			if v, ok := table["dir"]; ok {
+				table["dir"] = resolve(v)
+			}
+		}
+	}
+	if tools, ok := doc["tools"].(map[string]any); ok {
+		if files, ok := tools["files"].(map[string]any); ok {
+			if dirs, ok := files["dirs"].([]any); ok {
+				for i, d := range dirs {
+					dirs[i] = resolve(d)
+				}
+			}
+		}
+	}
+	if agents, ok := doc["agents"].(map[string]any); ok {
+		for _, v := range agents {
+			if agent, ok := v.(map[string]any); ok {
+				if file, ok := agent["instruction_file"]; ok {
+					agent["instruction_file"] = resolve(file)
+				}
+			}
+		}
+	}
+}
+
+// mergeTables 将 src 递归合并到 dst
+func mergeTables(dst, src map[string]any) {
+	for k, v := range src {
+		srcTable, srcOK := v.(map[string]any)
+		dstTable, dstOK := dst[k].(map[string]any)
+		if srcOK && dstOK {
+			mergeTables(dstTable, srcTable)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// encode 将合并后的文档重新编码为 TOML
+func (r *Resolved) encode() (string, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(r.Document); err != nil {
+		return "", fmt.Errorf("encode merged config: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// WriteMasked 输出合并后的 TOML，敏感配置项与密钥引用解析出的值均被替换为 ******
+func (r *Resolved) WriteMasked(w io.Writer) error {
+	for _, src := range r.Sources {
+		if _, err := fmt.Fprintf(w, "# source: %s\n", src); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return err
+	}
+	masked, _ := r.mask("", r.Document).(map[string]any)
+	enc := toml.NewEncoder(w)
+	enc.Indent = ""
+	return enc.Encode(masked)
+}
+
+// mask 返回 v 的脱敏副本
+func (r *Resolved) mask(key string, v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = r.mask(k, item)
+		}
+		return out
+	case []map[string]any:
+		out := make([]map[string]any, len(val))
+		for i, item := range val {
+			out[i], _ = r.mask(key, item).(map[string]any)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = r.mask(key, item)
+		}
+		return out
+	case string:
+		if val != "" && (sensitiveKeyPattern.MatchString(key) || r.secrets.leaks(val)) {
+			return maskedValue
+		}
+		return val
+	default:
+		return val
+	}
+}
+
+// secretSet 记录由密钥引用解析出的值，用于打印配置时脱敏
+type secretSet map[string]struct{}
+
+// add 记录一个密钥值，nil 时忽略
+func (s secretSet) add(v string) {
+	if s != nil {
+		s[v] = struct{}{}
+	}
+}
+
+// leaks 判断 v 是否包含任一密钥值（如拼接在 DSN 中）
+func (s secretSet) leaks(v string) bool {
+	for secret := range s {
+		if strings.Contains(v, secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFiles 在同一临时目录下写入多个配置文件，返回目录路径
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+const layeredBaseConfig = `include = ["agents.toml"]
+
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = true
+[services.prometheus.options]
+address = "http://prometheus.staging:9090"
+timeout = "30s"
+`
+
+// TestLoader_Load_Include 测试 include 文件的合并
+func TestLoader_Load_Include(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.toml": layeredBaseConfig,
+		"agents.toml": testAgentConfig,
+	})
+
+	loader := NewLoader(filepath.Join(dir, "config.toml"))
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+
+	assert.Contains(t, cfg.Agents, "orchestrator")
+	assert.Contains(t, cfg.Services, "prometheus")
+	assert.Equal(t, []string{
+		filepath.Join(dir, "agents.toml"),
+		filepath.Join(dir, "config.toml"),
+	}, loader.Sources())
+}
+
+// TestLoader_Load_IncludeRelativePaths 测试被 include 文件中的相对路径基于该文件所在目录
+func TestLoader_Load_IncludeRelativePaths(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.toml": `include = ["team/agents.toml"]
+
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = false
+
+[tools.files]
+dirs = ["runbooks"]
+`,
+		"team/agents.toml": `
+[prompts]
+dir = "prompts"
+
+[playbooks]
+dir = "/etc/oneblade/playbooks"
+
+[agents.capacity_agent]
+enabled = true
+description = "容量规划"
+instruction_file = "capacity.md"
+[agents.capacity_agent.llm]
+provider = "openai"
+model = "gpt-4o"
+`,
+		"team/capacity.md": "你是容量规划专家。\n",
+	})
+
+	cfg, err := NewLoader(filepath.Join(dir, "config.toml")).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "你是容量规划专家。\n", cfg.Agents["capacity_agent"].Instruction)
+	assert.Equal(t, filepath.Join(dir, "team", "prompts"), cfg.Prompts.Dir)
+	assert.Equal(t, "/etc/oneblade/playbooks", cfg.Playbooks.Dir, "绝对路径保持不变")
+	assert.Equal(t, []string{filepath.Join(dir, "runbooks")}, cfg.Tools.Files.Dirs)
+}
+
+// TestLoader_Load_Profile 测试 profile 覆盖文件的合并
+func TestLoader_Load_Profile(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.toml": layeredBaseConfig,
+		"agents.toml": testAgentConfig,
+		"config.prod.toml": `
+[services.prometheus.options]
+address = "http://prometheus.prod:9090"
+
+[services.pagerduty]
+type = "pagerduty"
+enabled = true
+`,
+	})
+	configPath := filepath.Join(dir, "config.toml")
+
+	t.Run("未指定 profile 时只加载基础配置", func(t *testing.T) {
+		loader := NewLoader(configPath)
+		_, err := loader.Load()
+		require.NoError(t, err)
+
+		var opts struct {
+			Address string `toml:"address"`
+			Timeout string `toml:"timeout"`
+		}
+		primitive, meta, err := loader.GetServiceOptions("prometheus")
+		require.NoError(t, err)
+		require.NoError(t, meta.PrimitiveDecode(primitive, &opts))
+		assert.Equal(t, "http://prometheus.staging:9090", opts.Address)
+	})
+
+	t.Run("profile 覆盖同名字段并保留其余字段", func(t *testing.T) {
+		loader := NewLoader(configPath, WithProfile("prod"))
+		cfg, err := loader.Load()
+		require.NoError(t, err)
+		assert.Equal(t, "prod", loader.Profile())
+		assert.Contains(t, cfg.Services, "pagerduty")
+
+		var opts struct {
+			Address string `toml:"address"`
+			Timeout string `toml:"timeout"`
+		}
+		primitive, meta, err := loader.GetServiceOptions("prometheus")
+		require.NoError(t, err)
+		require.NoError(t, meta.PrimitiveDecode(primitive, &opts))
+		assert.Equal(t, "http://prometheus.prod:9090", opts.Address)
+		assert.Equal(t, "30s", opts.Timeout)
+		assert.Equal(t, filepath.Join(dir, "config.prod.toml"), loader.Sources()[2])
+	})
+
+	t.Run("profile 文件不存在", func(t *testing.T) {
+		loader := NewLoader(configPath, WithProfile("dev"))
+		_, err := loader.Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `load profile "dev"`)
+	})
+}
+
+// TestLoader_Resolve_Errors 测试 include 的错误处理
+func TestLoader_Resolve_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "循环 include",
+			files: map[string]string{
+				"config.toml": `include = ["a.toml"]`,
+				"a.toml":      `include = ["config.toml"]`,
+			},
+			wantErr: "include cycle",
+		},
+		{
+			name: "include 文件不存在",
+			files: map[string]string{
+				"config.toml": `include = ["missing.toml"]`,
+			},
+			wantErr: "missing.toml",
+		},
+		{
+			name: "include 类型错误",
+			files: map[string]string{
+				"config.toml": `include = "agents.toml"`,
+			},
+			wantErr: "include must be an array of strings",
+		},
+		{
+			name: "被 include 文件中的类型错误指向该文件的行号",
+			files: map[string]string{
+				"config.toml": "include = [\"team/agents.toml\"]\n\n[server]\naddr = \"localhost:8080\"\n",
+				"team/agents.toml": `
+[agents.orchestrator]
+enabled = "yes"
+`,
+			},
+			wantErr: filepath.Join("team", "agents.toml") + `: toml: line 3 (last key "agents.orchestrator.enabled"): incompatible types`,
+		},
+		{
+			name: "被 include 文件中的未知配置项指向该文件",
+			files: map[string]string{
+				"config.toml": `include = ["agents.toml"]`,
+				"agents.toml": `
+[agents.orchestrator]
+enabeld = true
+`,
+			},
+			wantErr: "agents.toml: unknown keys: [agents.orchestrator.enabeld]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, tt.files)
+			_, err := NewLoader(filepath.Join(dir, "config.toml")).Resolve()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+// TestResolved_WriteMasked 测试打印合并后的配置时脱敏
+func TestResolved_WriteMasked(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "os_pass")
+	require.NoError(t, os.WriteFile(secretFile, []byte("hunter2"), 0600))
+
+	dir := writeConfigFiles(t, map[string]string{
+		"config.toml": layeredBaseConfig + fmt.Sprintf(`
+[services.opensearch]
+type = "opensearch"
+enabled = true
+[services.opensearch.options]
+username = "admin"
+dsn = "https://admin:${file:%s}@opensearch:9200"
+`, secretFile),
+		"agents.toml": testAgentConfig,
+	})
+
+	res, err := NewLoader(filepath.Join(dir, "config.toml")).Resolve()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, res.WriteMasked(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, "# source: "+filepath.Join(dir, "agents.toml"))
+	assert.Contains(t, out, `address = "http://prometheus.staging:9090"`)
+	assert.Contains(t, out, `username = "admin"`)
+	assert.NotContains(t, out, "test-api-key")
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "include")
+	assert.Contains(t, out, maskedValue)
+}
@@ -1,10 +1,17 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/BurntSushi/toml"
 	"github.com/go-playground/validator/v10"
@@ -13,36 +20,52 @@ import (
 
 // Loader 配置加载器
 type Loader struct {
-	configPath  string
+	configPath string
+	profile    string
+	opts       []LoaderOption
+	validator  *validator.Validate
+
+	mu          sync.RWMutex
 	config      *Config
+	raw         map[string]any
+	sources     []string
 	serviceMeta map[string]*toml.MetaData
-	validator   *validator.Validate
 }
 
 // NewLoader 创建配置加载器
-func NewLoader(configPath string) *Loader {
+func NewLoader(configPath string, opts ...LoaderOption) *Loader {
 	loader := &Loader{
 		configPath:  configPath,
+		opts:        opts,
 		validator:   validator.New(),
 		serviceMeta: make(map[string]*toml.MetaData),
 	}
+	for _, opt := range opts {
+		opt(loader)
+	}
 	return loader
 }
 
 // extractServiceMeta 提取每个 service 的元数据
-func (l *Loader) extractServiceMeta(meta *toml.MetaData, cfg *Config) {
+func (l *Loader) extractServiceMeta(meta *toml.MetaData, cfg *Config) map[string]*toml.MetaData {
 	// 为每个 service 保存元数据副本
+	serviceMeta := make(map[string]*toml.MetaData, len(cfg.Services))
 	for name := range cfg.Services {
-		l.serviceMeta[name] = meta
+		serviceMeta[name] = meta
 	}
+	return serviceMeta
 }
 
 // Load 加载并解析配置
 func (l *Loader) Load() (*Config, error) {
-	// 加载并展开环境变量
-	content, err := l.loadAndExpand(l.configPath)
+	// 加载、展开占位符并合并 include / profile
+	resolved, err := l.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	content, err := resolved.encode()
 	if err != nil {
-		return nil, fmt.Errorf("load config file %s: %w", l.configPath, err)
+		return nil, err
 	}
 
 	// 解析配置
@@ -59,6 +82,10 @@ func (l *Loader) Load() (*Config, error) {
 
 	applyDefaults(&cfg)
 
+	if err := resolveModelRefs(&cfg); err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
+
 	// 验证配置结构
 	if err := l.validate(&cfg); err != nil {
 		return nil, fmt.Errorf("validate config: %w", err)
@@ -68,14 +95,35 @@ func (l *Loader) Load() (*Config, error) {
 		return nil, fmt.Errorf("validate config: %w", err)
 	}
 
+	if err := validateRouting(cfg.Routing); err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
+
+	if err := l.resolveInstructionFiles(&cfg); err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
+	if cfg.Prompts.Dir != "" {
+		cfg.Prompts.Dir = l.resolvePath(cfg.Prompts.Dir)
+	}
+	cfg.Playbooks.Dir = l.resolvePath(cfg.Playbooks.Dir)
+	for i, dir := range cfg.Tools.Files.Dirs {
+		cfg.Tools.Files.Dirs[i] = l.resolvePath(dir)
+	}
+
 	// 筛选 enabled agents 和 services
 	l.filterEnabledAgents(&cfg)
 	l.filterEnabledServices(&cfg)
 
 	// 提取 service 元数据
-	l.extractServiceMeta(&meta, &cfg)
+	serviceMeta := l.extractServiceMeta(&meta, &cfg)
 
+	l.mu.Lock()
 	l.config = &cfg
+	// 保留原始文档，用于热加载时比较 [services.<name>.options] 等延迟解析的部分
+	l.raw = resolved.Document
+	l.sources = resolved.Sources
+	l.serviceMeta = serviceMeta
+	l.mu.Unlock()
 	return &cfg, nil
 }
 
@@ -110,6 +158,68 @@ func applyDefaults(cfg *Config) {
 			"default", consts.AgentNameOrchestrator)
 		cfg.Conversation.SummaryModelAgent = consts.AgentNameOrchestrator
 	}
+	if cfg.Playbooks.Dir == "" {
+		cfg.Playbooks.Dir = DefaultPlaybooksDir
+	}
+}
+
+// resolveModelRefs 将 agent 的 model 引用展开为对应 [models.<name>] 的 LLM 配置
+func resolveModelRefs(cfg *Config) error {
+	var errs []error
+	for name, agent := range cfg.Agents {
+		if agent.Model == "" {
+			continue
+		}
+		profile, ok := cfg.Models[agent.Model]
+		if !ok {
+			errs = append(errs, fmt.Errorf("agents.%s.model: model %q not found in [models]", name, agent.Model))
+			continue
+		}
+		if !reflect.ValueOf(agent.LLM).IsZero() {
+			errs = append(errs, fmt.Errorf("agents.%s: model and llm are mutually exclusive", name))
+			continue
+		}
+		agent.LLM = profile
+		cfg.Agents[name] = agent
+	}
+	if ref := cfg.Conversation.SummaryModel; ref != "" {
+		if _, ok := cfg.Models[ref]; !ok {
+			errs = append(errs, fmt.Errorf("conversation.summary_model: model %q not found in [models]", ref))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveInstructionFiles 将 enabled agent 的 instruction_file 读入 Instruction
+// 相对路径基于声明它的配置文件所在目录；自定义 agent 的其余约束由应用层校验。
+func (l *Loader) resolveInstructionFiles(cfg *Config) error {
+	var errs []error
+	for name, agent := range cfg.Agents {
+		if !agent.Enabled || agent.InstructionFile == "" {
+			continue
+		}
+		if agent.Instruction != "" {
+			errs = append(errs, fmt.Errorf("agents.%s: instruction and instruction_file are mutually exclusive", name))
+			continue
+		}
+		data, err := os.ReadFile(l.resolvePath(agent.InstructionFile))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("agents.%s.instruction_file: %w", name, err))
+			continue
+		}
+		agent.Instruction = string(data)
+		cfg.Agents[name] = agent
+	}
+	return errors.Join(errs...)
+}
+
+// resolvePath 将相对路径解析为基于主配置文件所在目录的路径
+// 配置文件中的相对路径已在合并时按所在文件解析，这里处理的是默认值。
+func (l *Loader) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(l.configPath), path)
 }
 
 func validateConversation(cfg ConversationConfig) error {
@@ -119,60 +229,168 @@ func validateConversation(cfg ConversationConfig) error {
 	return nil
 }
 
-// checkUnknownKeys checks for undecoded keys in the config
-func (l *Loader) checkUnknownKeys(meta *toml.MetaData) error {
-	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
-		var unknown []toml.Key
-		for _, k := range undecoded {
-			// Ignore [services.<name>.options] as they are delayed parsed
-			if len(k) >= 3 && k[0] == "services" && k[2] == "options" {
-				continue
+// validateRouting 校验路由规则：名称唯一、至少配置一种匹配方式、正则可编译、命令以 / 开头
+func validateRouting(cfg RoutingConfig) error {
+	seen := make(map[string]struct{}, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		if _, ok := seen[rule.Name]; ok {
+			return fmt.Errorf("routing.rules: duplicate rule name %s", rule.Name)
+		}
+		seen[rule.Name] = struct{}{}
+
+		if len(rule.Keywords) == 0 && rule.Pattern == "" && rule.Command == "" {
+			return fmt.Errorf("routing.rules %s: one of keywords, pattern or command is required", rule.Name)
+		}
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return fmt.Errorf("routing.rules %s: invalid pattern: %w", rule.Name, err)
 			}
-			unknown = append(unknown, k)
 		}
-		if len(unknown) > 0 {
-			return fmt.Errorf("parse config file %s: unknown keys: %v", l.configPath, unknown)
+		if rule.Command != "" && (!strings.HasPrefix(rule.Command, "/") || strings.ContainsAny(rule.Command, " \t")) {
+			return fmt.Errorf("routing.rules %s: command must start with / and contain no spaces", rule.Name)
 		}
 	}
 	return nil
 }
 
-// loadAndExpand 读取文件并展开环境变量
-func (l *Loader) loadAndExpand(path string) (string, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
+// checkUnknownKeys checks for undecoded keys in the config
+func (l *Loader) checkUnknownKeys(meta *toml.MetaData) error {
+	if unknown := unknownKeys(meta); len(unknown) > 0 {
+		return fmt.Errorf("parse config file %s: unknown keys: %v", l.configPath, unknown)
 	}
+	return nil
+}
 
-	expanded := expandEnv(string(content))
-	return expanded, nil
+// unknownKeys 返回未解码到 Config 的配置项
+func unknownKeys(meta *toml.MetaData) []toml.Key {
+	var unknown []toml.Key
+	for _, k := range meta.Undecoded() {
+		// Ignore [services.<name>.options] as they are delayed parsed
+		if len(k) >= 3 && k[0] == "services" && k[2] == "options" {
+			continue
+		}
+		// include 指令在合并时处理
+		if len(k) == 1 && k[0] == includeKey {
+			continue
+		}
+		unknown = append(unknown, k)
+	}
+	return unknown
 }
 
-// expandEnv 展开环境变量占位符
-// 支持 ${VAR} 和 ${VAR:default} 语法
-func expandEnv(s string) string {
-	// 匹配 ${VAR} 或 ${VAR:default}
-	re := regexp.MustCompile(`\$\{([^}:]+)(?::([^}]*))?\}`)
+// placeholderPattern 匹配 ${VAR}、${VAR:default} 与 ${scheme:ref}
+var placeholderPattern = regexp.MustCompile(`\$\{([^}:]+)(?::([^}]*))?\}`)
+
+// expandEnv 展开环境变量占位符与密钥引用
+// 支持 ${VAR} 和 ${VAR:default} 语法；
+// 名称为已注册 scheme（file、exec、keyring 等）时按密钥引用解析，如 ${file:/run/secrets/pd_key}。
+// 环境变量缺失时使用默认值（可以为空），密钥引用解析失败则返回包含该引用的错误。
+// 密钥值会按 TOML basic string 转义，因此密钥引用需写在双引号字符串中；
+// secrets 非 nil 时记录解析出的密钥值，供打印配置时脱敏。
+// 注释不做展开，避免注释中的示例引用被解析；字符串（包括多行字符串）中的 # 不视为注释。
+func expandEnv(s string, secrets secretSet) (string, error) {
+	var errs []error
+	expand := func(text string) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+			// 提取变量名和默认值
+			groups := placeholderPattern.FindStringSubmatch(match)
+			if len(groups) < 2 {
+				return match
+			}
 
-	return re.ReplaceAllStringFunc(s, func(match string) string {
-		// 提取变量名和默认值
-		groups := re.FindStringSubmatch(match)
-		if len(groups) < 2 {
-			return match
-		}
+			varName := groups[1]
+			defaultVal := ""
+			if len(groups) >= 3 {
+				defaultVal = groups[2]
+			}
 
-		varName := groups[1]
-		defaultVal := ""
-		if len(groups) >= 3 {
-			defaultVal = groups[2]
+			// 密钥引用：错误信息只包含引用本身，不包含任何解析结果
+			if isSecretScheme(varName) {
+				val, err := resolveSecret(context.Background(), varName, defaultVal)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("resolve secret reference %s: %w", match, err))
+					return match
+				}
+				secrets.add(val)
+				return escapeBasicString(val)
+			}
+
+			// 查找环境变量
+			if val := os.Getenv(varName); val != "" {
+				return val
+			}
+			return defaultVal
+		})
+	}
+
+	var b strings.Builder
+	pos := 0
+	for _, c := range commentRanges(s) {
+		b.WriteString(expand(s[pos:c[0]]))
+		b.WriteString(s[c[0]:c[1]])
+		pos = c[1]
+	}
+	b.WriteString(expand(s[pos:]))
+	if len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
+	return b.String(), nil
+}
+
+// commentRanges 返回 TOML 文本中注释的 [start, end) 区间
+// 按 TOML 的字符串语法跳过 basic / literal 字符串及其多行形式，其中的 # 不是注释。
+func commentRanges(s string) [][2]int {
+	var ranges [][2]int
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '#':
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			ranges = append(ranges, [2]int{i, i + end})
+			i += end
+		case strings.HasPrefix(s[i:], `"""`):
+			i = skipString(s, i+3, `"""`, true)
+		case strings.HasPrefix(s[i:], "'''"):
+			i = skipString(s, i+3, "'''", false)
+		case s[i] == '"':
+			i = skipString(s, i+1, `"`, true)
+		case s[i] == '\'':
+			i = skipString(s, i+1, "'", false)
 		}
+	}
+	return ranges
+}
 
-		// 查找环境变量
-		if val := os.Getenv(varName); val != "" {
-			return val
+// skipString 从字符串内容的起始位置 i 开始查找结束引号，返回结束引号最后一个字符的位置
+// 单行字符串遇到换行即结束；escapes 为 true 时跳过反斜杠转义的字符。
+func skipString(s string, i int, quote string, escapes bool) int {
+	multiline := len(quote) == 3
+	for ; i < len(s); i++ {
+		switch {
+		case escapes && s[i] == '\\':
+			i++
+		case !multiline && s[i] == '\n':
+			return i
+		case strings.HasPrefix(s[i:], quote):
+			// 多行字符串结尾可以紧跟最多两个引号，如 """a"""""
+			end := i + len(quote)
+			for multiline && end < len(s) && s[end] == quote[0] && end-i < len(quote)+2 {
+				end++
+			}
+			return end - 1
 		}
-		return defaultVal
-	})
+	}
+	return len(s)
+}
+
+// basicStringEscaper 转义 TOML basic string 中的特殊字符
+var basicStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// escapeBasicString 使任意密钥值（如多行 PEM）可以安全地嵌入双引号字符串
+func escapeBasicString(s string) string {
+	return basicStringEscaper.Replace(s)
 }
 
 // validate 验证配置
@@ -204,6 +422,8 @@ func (l *Loader) filterEnabledServices(cfg *Config) {
 
 // Get 获取当前配置
 func (l *Loader) Get() (*Config, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	if l.config == nil {
 		return nil, fmt.Errorf("config not loaded")
 	}
@@ -215,8 +435,23 @@ func (l *Loader) ConfigPath() string {
 	return l.configPath
 }
 
+// Profile 获取当前 profile，未指定时为空
+func (l *Loader) Profile() string {
+	return l.profile
+}
+
+// Sources 获取最近一次加载参与合并的文件，按合并顺序排列
+func (l *Loader) Sources() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return slices.Clone(l.sources)
+}
+
 // GetServiceOptions 获取指定 service 的原始配置数据
 func (l *Loader) GetServiceOptions(serviceName string) (toml.Primitive, *toml.MetaData, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	cfg := l.config
 	if cfg == nil {
 		return toml.Primitive{}, nil, fmt.Errorf("config not loaded")
@@ -97,9 +97,27 @@ addr = "localhost:6379"
 read_timeout = "5s"
 write_timeout = "5s"
 
+[[routing.rules]]
+name = "alerts"
+agent = "service_agent"
+keywords = ["告警", "alert"]
+command = "/alerts"
+
+[tools.files]
+dirs = ["runbooks", "/srv/docs"]
+
+[investigation]
+max_iterations = 12
+window = "1h"
+
+[postmortem]
+project = "OPS"
+subtask_type = "Sub-task"
+
 [services.prometheus]
 type = "prometheus"
 enabled = true
+collect_timeout = "30s"
 
 [services.pagerduty]
 type = "pagerduty"
@@ -115,6 +133,16 @@ enabled = false
 	assert.Equal(t, "localhost:8080", cfg.Server.Addr)
 	assert.True(t, cfg.Services["prometheus"].Enabled)
 	assert.False(t, cfg.Services["pagerduty"].Enabled)
+	assert.Equal(t, 30*time.Second, cfg.Services["prometheus"].CollectTimeout)
+	assert.Equal(t, []RoutingRule{{
+		Name:     "alerts",
+		Agent:    "service_agent",
+		Keywords: []string{"告警", "alert"},
+		Command:  "/alerts",
+	}}, cfg.Routing.Rules)
+	assert.Equal(t, []string{filepath.Join(filepath.Dir(configPath), "runbooks"), "/srv/docs"}, cfg.Tools.Files.Dirs)
+	assert.Equal(t, InvestigationConfig{MaxIterations: 12, Window: time.Hour}, cfg.Investigation)
+	assert.Equal(t, PostmortemConfig{Project: "OPS", SubtaskType: "Sub-task"}, cfg.Postmortem)
 }
 
 // TestLoader_Load_FileNotFound 测试文件不存在的情况
@@ -316,7 +344,8 @@ func Test_expandEnv(t *testing.T) {
 			cleanup := setupEnvVars(t, tt.envVars)
 			defer cleanup()
 
-			result := expandEnv(tt.input)
+			result, err := expandEnv(tt.input, nil)
+			require.NoError(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -546,6 +575,100 @@ enabled = true
 		assert.True(t, containsAny(err.Error(), []string{"retain_recent_messages", "retain"}), err.Error())
 	})
 
+	routingRuleTests := []struct {
+		name    string
+		rule    string
+		wantErr string
+	}{
+		{
+			name:    "路由规则缺少匹配方式",
+			rule:    `name = "alerts"` + "\n" + `agent = "service_agent"`,
+			wantErr: "routing.rules alerts: one of keywords, pattern or command is required",
+		},
+		{
+			name:    "路由规则正则无效",
+			rule:    `name = "alerts"` + "\n" + `agent = "service_agent"` + "\n" + `pattern = "告警("`,
+			wantErr: "routing.rules alerts: invalid pattern",
+		},
+		{
+			name:    "路由规则命令格式错误",
+			rule:    `name = "alerts"` + "\n" + `agent = "service_agent"` + "\n" + `command = "alerts"`,
+			wantErr: "routing.rules alerts: command must start with /",
+		},
+		{
+			name:    "路由规则缺少 agent",
+			rule:    `name = "alerts"` + "\n" + `command = "/alerts"`,
+			wantErr: "Agent",
+		},
+	}
+	for _, tt := range routingRuleTests {
+		t.Run(tt.name, func(t *testing.T) {
+			configContent := testAgentConfig + `
+[server]
+addr = "localhost:8080"
+
+[[routing.rules]]
+` + tt.rule + `
+
+[services.prometheus]
+type = "prometheus"
+enabled = true
+`
+			configPath := createTempConfig(t, configContent)
+
+			_, err := NewLoader(configPath).Load()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("路由规则名称重复", func(t *testing.T) {
+		configContent := testAgentConfig + `
+[server]
+addr = "localhost:8080"
+
+[[routing.rules]]
+name = "alerts"
+agent = "service_agent"
+command = "/alerts"
+
+[[routing.rules]]
+name = "alerts"
+agent = "service_agent"
+keywords = ["告警"]
+
+[services.prometheus]
+type = "prometheus"
+enabled = true
+`
+		configPath := createTempConfig(t, configContent)
+
+		_, err := NewLoader(configPath).Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "routing.rules: duplicate rule name alerts")
+	})
+
+	t.Run("routing.max_hops 超出范围", func(t *testing.T) {
+		configContent := testAgentConfig + `
+[server]
+addr = "localhost:8080"
+
+[routing]
+max_hops = 20
+
+[services.prometheus]
+type = "prometheus"
+enabled = true
+`
+		configPath := createTempConfig(t, configContent)
+
+		loader := NewLoader(configPath)
+
+		_, err := loader.Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "MaxHops")
+	})
+
 	t.Run("无效的 hostname_port 格式", func(t *testing.T) {
 		configContent := testAgentConfig + `
 [server]
@@ -695,3 +818,160 @@ model = "gpt-4"
 		assert.Equal(t, 3, len(cfg.Agents))
 	})
 }
+
+// TestLoader_Load_ModelProfiles 测试 [models.<name>] 引用展开
+func TestLoader_Load_ModelProfiles(t *testing.T) {
+	baseConfig := `
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = false
+
+[models.fast]
+provider = "openai"
+model = "gpt-4o-mini"
+api_key = "test-api-key"
+`
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+		check   func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "agent 引用模型配置",
+			content: `
+[conversation]
+summary_model = "fast"
+
+[agents.orchestrator]
+enabled = true
+model = "fast"
+
+[agents.service_agent]
+enabled = true
+[agents.service_agent.llm]
+provider = "openai"
+model = "gpt-4o"
+`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, cfg.Models["fast"], cfg.Agents["orchestrator"].LLM)
+				assert.Equal(t, "gpt-4o", cfg.Agents["service_agent"].LLM.Model)
+				assert.Equal(t, "fast", cfg.Conversation.SummaryModel)
+			},
+		},
+		{
+			name: "引用不存在的模型配置",
+			content: `
+[agents.orchestrator]
+enabled = true
+model = "slow"
+`,
+			wantErr: `agents.orchestrator.model: model "slow" not found in [models]`,
+		},
+		{
+			name: "model 与 llm 同时配置",
+			content: `
+[agents.orchestrator]
+enabled = true
+model = "fast"
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4o"
+`,
+			wantErr: "agents.orchestrator: model and llm are mutually exclusive",
+		},
+		{
+			name: "summary_model 引用不存在的模型配置",
+			content: `
+[conversation]
+summary_model = "slow"
+
+[agents.orchestrator]
+enabled = true
+model = "fast"
+`,
+			wantErr: `conversation.summary_model: model "slow" not found in [models]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewLoader(createTempConfig(t, baseConfig+tt.content)).Load()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestLoader_Load_InstructionFile(t *testing.T) {
+	baseConfig := `
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = false
+
+[agents.capacity_agent]
+enabled = true
+description = "容量规划"
+[agents.capacity_agent.llm]
+provider = "openai"
+model = "gpt-4o"
+`
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+		check   func(t *testing.T, cfg *Config)
+	}{
+		{
+			name:    "相对路径基于配置文件目录",
+			content: `instruction_file = "prompts/capacity.md"`,
+			check: func(t *testing.T, cfg *Config) {
+				assert.Equal(t, "你是容量规划专家。\n", cfg.Agents["capacity_agent"].Instruction)
+			},
+		},
+		{
+			name: "instruction 与 instruction_file 同时配置",
+			content: `instruction = "inline"
+instruction_file = "prompts/capacity.md"`,
+			wantErr: "agents.capacity_agent: instruction and instruction_file are mutually exclusive",
+		},
+		{
+			name:    "instruction_file 不存在",
+			content: `instruction_file = "prompts/missing.md"`,
+			wantErr: "agents.capacity_agent.instruction_file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 自定义字段追加在 [agents.capacity_agent] 表中，需插入到 llm 子表之前
+			content := strings.Replace(baseConfig, "[agents.capacity_agent.llm]", tt.content+"\n[agents.capacity_agent.llm]", 1)
+			configPath := createTempConfig(t, content)
+			dir := filepath.Join(filepath.Dir(configPath), "prompts")
+			require.NoError(t, os.MkdirAll(dir, 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "capacity.md"), []byte("你是容量规划专家。\n"), 0o644))
+
+			cfg, err := NewLoader(configPath).Load()
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// schemaDraft 导出的 JSON Schema 版本
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern Go time.Duration 字符串格式，如 30s、1m30s
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+var (
+	durationType  = reflect.TypeFor[time.Duration]()
+	primitiveType = reflect.TypeFor[toml.Primitive]()
+)
+
+// Schema 生成 Config 的 JSON Schema，供编辑器（如 Even Better TOML / taplo）补全与校验
+//
+// serviceOptions 为 service type 到 Options 结构体类型的映射，
+// 会以 $defs 的形式导出，并按 [services.<name>].type 约束对应的 options table。
+//
+// 说明：
+// - 字段名取 toml 标签，约束取 validate 标签（required、oneof、gt/gte/lt/lte、min/max、url、dive）。
+// - 与 Loader 的严格模式一致，未知字段不被允许；[services.<name>.options] 未注册类型时不做约束。
+func Schema(serviceOptions map[string]reflect.Type) map[string]any {
+	root := schemaFor(reflect.TypeFor[Config](), nil)
+	root["$schema"] = schemaDraft
+	root["title"] = "oneblade config"
+	properties := root["properties"].(map[string]any)
+	properties[includeKey] = map[string]any{
+		"type":  "array",
+		"items": map[string]any{"type": "string"},
+	}
+
+	if len(serviceOptions) == 0 {
+		return root
+	}
+
+	types := make([]string, 0, len(serviceOptions))
+	for serviceType := range serviceOptions {
+		types = append(types, serviceType)
+	}
+	sort.Strings(types)
+
+	defs := make(map[string]any, len(types))
+	conditions := make([]any, 0, len(types))
+	for _, serviceType := range types {
+		name := serviceType + "_options"
+		defs[name] = schemaFor(serviceOptions[serviceType], nil)
+		conditions = append(conditions, map[string]any{
+			"if": map[string]any{
+				"properties": map[string]any{"type": map[string]any{"const": serviceType}},
+				"required":   []string{"type"},
+			},
+			"then": map[string]any{
+				"properties": map[string]any{"options": map[string]any{"$ref": "#/$defs/" + name}},
+			},
+		})
+	}
+	root["$defs"] = defs
+
+	services := properties["services"].(map[string]any)
+	serviceSchema := services["additionalProperties"].(map[string]any)
+	serviceSchema["allOf"] = conditions
+	return root
+}
+
+// schemaFor 根据 Go 类型生成 JSON Schema
+// visiting 为当前路径上的结构体类型，递归类型（如 AgentLLMConfig.Fallbacks）再次出现时只约束为 object。
+func schemaFor(t reflect.Type, visiting []reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		return map[string]any{"type": "string", "pattern": durationPattern}
+	case t == primitiveType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if slices.Contains(visiting, t) {
+			return map[string]any{"type": "object"}
+		}
+		return structSchema(t, append(visiting, t))
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), visiting)}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), visiting)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema 生成结构体的 object schema
+func structSchema(t reflect.Type, visiting []reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := schemaFor(field.Type, visiting)
+		if applyValidateTag(schema, field.Tag.Get("validate")) {
+			required = append(required, name)
+		}
+		properties[name] = schema
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// applyValidateTag 将 validate 标签转换为 schema 约束，返回字段是否必填
+// dive 之后的规则作用于数组元素或 map 的值。
+func applyValidateTag(schema map[string]any, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	rules := strings.Split(tag, ",")
+	if i := slices.Index(rules, "dive"); i >= 0 {
+		elemTag := strings.Join(rules[i+1:], ",")
+		rules = rules[:i]
+		if items, ok := schema["items"].(map[string]any); ok {
+			applyValidateTag(items, elemTag)
+		} else if values, ok := schema["additionalProperties"].(map[string]any); ok {
+			applyValidateTag(values, elemTag)
+		}
+	}
+
+	required := false
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+			switch schema["type"] {
+			case "array":
+				schema["minItems"] = 1
+			case "object":
+				schema["minProperties"] = 1
+			case "string":
+				schema["minLength"] = 1
+			}
+		case "oneof":
+			enum := make([]any, 0)
+			for _, v := range strings.Fields(param) {
+				enum = append(enum, v)
+			}
+			schema["enum"] = enum
+		case "url":
+			schema["format"] = "uri"
+		case "gt":
+			setNumber(schema, "exclusiveMinimum", param)
+		case "gte":
+			setNumber(schema, "minimum", param)
+		case "lt":
+			setNumber(schema, "exclusiveMaximum", param)
+		case "lte":
+			setNumber(schema, "maximum", param)
+		case "min", "max":
+			setLength(schema, name, param)
+		}
+	}
+	return required
+}
+
+// setNumber 设置数值约束
+func setNumber(schema map[string]any, key, param string) {
+	if v, err := strconv.ParseFloat(param, 64); err == nil {
+		schema[key] = v
+	}
+}
+
+// setLength 按字段类型将 min/max 转换为长度或数值约束
+func setLength(schema map[string]any, rule, param string) {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+	keys := map[string][2]string{
+		"array":  {"minItems", "maxItems"},
+		"object": {"minProperties", "maxProperties"},
+		"string": {"minLength", "maxLength"},
+	}
+	typ, _ := schema["type"].(string)
+	pair, ok := keys[typ]
+	if !ok {
+		pair = [2]string{"minimum", "maximum"}
+	}
+	if rule == "min" {
+		schema[pair[0]] = n
+	} else {
+		schema[pair[1]] = n
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaTestOptions struct {
+	Address   string        `toml:"address" validate:"required,url"`
+	Hosts     []string      `toml:"hosts" validate:"omitempty,dive,url"`
+	Timeout   time.Duration `toml:"timeout"`
+	Retries   *int          `toml:"retries" validate:"omitempty,gte=0,lte=5"`
+	Mode      string        `toml:"mode" validate:"omitempty,oneof=fast slow"`
+	unexposed string
+}
+
+// TestSchema 测试 JSON Schema 的生成
+func TestSchema(t *testing.T) {
+	schema := Schema(map[string]reflect.Type{"prometheus": reflect.TypeFor[schemaTestOptions]()})
+
+	assert.Equal(t, schemaDraft, schema["$schema"])
+	assert.ElementsMatch(t, []string{"server", "agents", "services"}, schema["required"])
+
+	properties := schema["properties"].(map[string]any)
+	assert.Contains(t, properties, includeKey)
+
+	t.Run("validate 标签转换为约束", func(t *testing.T) {
+		llm := properties["agents"].(map[string]any)["additionalProperties"].(map[string]any)["properties"].(map[string]any)["llm"].(map[string]any)
+		llmProps := llm["properties"].(map[string]any)
+		assert.Equal(t, []any{"openai", "gemini", "anthropic", "azure_openai", "vertex", "bedrock", "ollama", "openai_compatible", "mock"}, llmProps["provider"].(map[string]any)["enum"])
+		assert.Equal(t, 2.0, llmProps["temperature"].(map[string]any)["maximum"])
+		assert.Equal(t, "number", llmProps["temperature"].(map[string]any)["type"])
+		assert.Equal(t, 0.0, llmProps["max_tokens"].(map[string]any)["exclusiveMinimum"])
+		assert.ElementsMatch(t, []string{"provider", "model"}, llm["required"])
+	})
+
+	t.Run("service options 按 type 约束", func(t *testing.T) {
+		defs := schema["$defs"].(map[string]any)
+		opts := defs["prometheus_options"].(map[string]any)
+		optProps := opts["properties"].(map[string]any)
+		assert.Equal(t, []string{"address"}, opts["required"])
+		assert.Equal(t, "uri", optProps["address"].(map[string]any)["format"])
+		assert.Equal(t, "uri", optProps["hosts"].(map[string]any)["items"].(map[string]any)["format"])
+		assert.Equal(t, durationPattern, optProps["timeout"].(map[string]any)["pattern"])
+		assert.Equal(t, "integer", optProps["retries"].(map[string]any)["type"])
+		assert.Equal(t, []any{"fast", "slow"}, optProps["mode"].(map[string]any)["enum"])
+		assert.NotContains(t, optProps, "unexposed")
+
+		service := properties["services"].(map[string]any)["additionalProperties"].(map[string]any)
+		allOf, ok := service["allOf"].([]any)
+		require.True(t, ok)
+		require.Len(t, allOf, 1)
+		then := allOf[0].(map[string]any)["then"].(map[string]any)
+		assert.Equal(t, "#/$defs/prometheus_options", then["properties"].(map[string]any)["options"].(map[string]any)["$ref"])
+	})
+}
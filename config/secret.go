@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 内置的密钥引用 scheme
+const (
+	SecretSchemeFile    = "file"
+	SecretSchemeExec    = "exec"
+	SecretSchemeKeyring = "keyring"
+)
+
+// execSecretTimeout ${exec:...} 命令的最长执行时间
+const execSecretTimeout = 10 * time.Second
+
+// SecretProvider 解析 ${<scheme>:<ref>} 形式的密钥引用
+//
+// 说明：
+// - 在配置加载时（TOML 解析之前）解析，解析结果不会写入日志。
+// - 解析失败必须返回错误，不允许静默返回空字符串。
+type SecretProvider interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretProviderFunc 函数形式的 SecretProvider
+type SecretProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve 实现 SecretProvider
+func (f SecretProviderFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// Keyring vault/keyring 类密钥后端的最小接口
+// 通过 NewKeyringProvider 适配为 SecretProvider 后注册即可使用 ${keyring:<service>/<key>}。
+type Keyring interface {
+	Get(ctx context.Context, service, key string) (string, error)
+}
+
+// NewKeyringProvider 将 Keyring 适配为 SecretProvider，引用格式为 <service>/<key>
+func NewKeyringProvider(k Keyring) SecretProvider {
+	return SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		service, key, ok := strings.Cut(ref, "/")
+		if !ok || service == "" || key == "" {
+			return "", fmt.Errorf("invalid keyring reference, want <service>/<key>")
+		}
+		return k.Get(ctx, service, key)
+	})
+}
+
+var secretProviders = struct {
+	mu        sync.RWMutex
+	providers map[string]SecretProvider
+}{
+	providers: map[string]SecretProvider{
+		SecretSchemeFile: SecretProviderFunc(resolveFileSecret),
+		SecretSchemeExec: SecretProviderFunc(resolveExecSecret),
+	},
+}
+
+// RegisterSecretProvider 注册密钥引用 scheme，已存在时覆盖
+//
+// 注意：scheme 会与 ${VAR:default} 语法中的变量名冲突，
+// 注册后形如 ${<scheme>:...} 的占位符不再被当作环境变量处理。
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	secretProviders.mu.Lock()
+	defer secretProviders.mu.Unlock()
+	secretProviders.providers[scheme] = provider
+}
+
+// getSecretProvider 获取 scheme 对应的 SecretProvider
+func getSecretProvider(scheme string) (SecretProvider, bool) {
+	secretProviders.mu.RLock()
+	defer secretProviders.mu.RUnlock()
+	provider, ok := secretProviders.providers[scheme]
+	return provider, ok
+}
+
+// isSecretScheme 判断占位符名称是否是密钥引用 scheme
+// keyring 即使没有注册后端也视为密钥引用，以便给出明确的错误而不是替换为空字符串。
+func isSecretScheme(name string) bool {
+	if name == SecretSchemeKeyring {
+		return true
+	}
+	_, ok := getSecretProvider(name)
+	return ok
+}
+
+// resolveSecret 解析单个密钥引用
+func resolveSecret(ctx context.Context, scheme, ref string) (string, error) {
+	if strings.TrimSpace(ref) == "" {
+		return "", fmt.Errorf("empty reference")
+	}
+	provider, ok := getSecretProvider(scheme)
+	if !ok {
+		return "", fmt.Errorf("no secret provider registered for scheme %q", scheme)
+	}
+	value, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		return "", fmt.Errorf("resolved to an empty value")
+	}
+	return value, nil
+}
+
+// resolveFileSecret 读取文件内容作为密钥，去掉首尾空白（如 Docker/K8s secret 末尾换行）
+func resolveFileSecret(_ context.Context, path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// execStderrLimit 命令失败时错误信息中保留的 stderr 最大字节数
+const execStderrLimit = 512
+
+// resolveExecSecret 执行命令并以 stdout 作为密钥
+// 命令失败时错误信息附带 stderr（截断到 execStderrLimit）以便排查；stdout 不会出现在错误信息中。
+func resolveExecSecret(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, execSecretTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", fmt.Errorf("run command: %w", err)
+		}
+		if len(msg) > execStderrLimit {
+			msg = msg[:execStderrLimit] + "..."
+		}
+		return "", fmt.Errorf("run command: %w: %s", err, msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
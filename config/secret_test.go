@@ -0,0 +1,164 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubKeyring map[string]string
+
+func (k stubKeyring) Get(ctx context.Context, service, key string) (string, error) {
+	v, ok := k[service+"/"+key]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s not found", service, key)
+	}
+	return v, nil
+}
+
+// Test_expandEnv_SecretReferences 测试密钥引用的解析
+func Test_expandEnv_SecretReferences(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "pd_key")
+	require.NoError(t, os.WriteFile(secretFile, []byte("pd-secret\n"), 0600))
+
+	t.Run("file 引用去掉末尾换行", func(t *testing.T) {
+		got, err := expandEnv(fmt.Sprintf(`api_key = "${file:%s}"`, secretFile), nil)
+		require.NoError(t, err)
+		assert.Equal(t, `api_key = "pd-secret"`, got)
+	})
+
+	t.Run("file 引用不存在时返回包含引用的错误", func(t *testing.T) {
+		_, err := expandEnv(`api_key = "${file:/nonexistent/pd_key}"`, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "${file:/nonexistent/pd_key}")
+	})
+
+	t.Run("exec 引用", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("requires sh")
+		}
+		got, err := expandEnv(`token = "${exec:echo from-exec}"`, nil)
+		require.NoError(t, err)
+		assert.Equal(t, `token = "from-exec"`, got)
+	})
+
+	t.Run("exec 命令失败时附带 stderr", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("requires sh")
+		}
+		_, err := expandEnv(`token = "${exec:echo out-$((6*7)); echo vault: permission denied >&2; exit 3}"`, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exit status 3: vault: permission denied")
+		assert.NotContains(t, err.Error(), "out-42", "stdout 不出现在错误信息中")
+	})
+
+	t.Run("exec 输出为空视为错误", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("requires sh")
+		}
+		_, err := expandEnv(`token = "${exec:true}"`, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "empty value")
+	})
+
+	t.Run("未注册的 keyring 后端", func(t *testing.T) {
+		_, err := expandEnv(`password = "${keyring:opensearch/admin}"`, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "${keyring:opensearch/admin}")
+		assert.Contains(t, err.Error(), "no secret provider registered")
+	})
+
+	t.Run("多个错误全部报告", func(t *testing.T) {
+		_, err := expandEnv(`a = "${file:/missing/a}"
+b = "${file:/missing/b}"`, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "/missing/a")
+		assert.Contains(t, err.Error(), "/missing/b")
+	})
+
+	t.Run("整行注释中的引用不解析", func(t *testing.T) {
+		got, err := expandEnv("# api_key = \"${file:/nonexistent/pd_key}\"\nname = \"x\"", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "# api_key = \"${file:/nonexistent/pd_key}\"\nname = \"x\"", got)
+	})
+
+	t.Run("行尾注释中的引用不解析", func(t *testing.T) {
+		got, err := expandEnv("name = \"x\" # ${file:/nonexistent/pd_key}\nurl = \"http://h/#${PORT_UNSET:9090}\"", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "name = \"x\" # ${file:/nonexistent/pd_key}\nurl = \"http://h/#9090\"", got)
+	})
+
+	t.Run("多行字符串中以 # 开头的行照常展开", func(t *testing.T) {
+		t.Setenv("TEAM_CHANNEL", "oncall")
+		input := "instruction = \"\"\"\n# 升级渠道\n#${TEAM_CHANNEL}\n\"\"\"\nnote = '''\n# ${TEAM_CHANNEL}\n'''\n# ${file:/nonexistent/pd_key}\n"
+		got, err := expandEnv(input, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "instruction = \"\"\"\n# 升级渠道\n#oncall\n\"\"\"\nnote = '''\n# oncall\n'''\n# ${file:/nonexistent/pd_key}\n", got)
+	})
+
+	t.Run("密钥值按 basic string 转义", func(t *testing.T) {
+		pem := filepath.Join(t.TempDir(), "key.pem")
+		require.NoError(t, os.WriteFile(pem, []byte("line1\nline\"2\""), 0600))
+		got, err := expandEnv(fmt.Sprintf(`key = "${file:%s}"`, pem), nil)
+		require.NoError(t, err)
+		assert.Equal(t, `key = "line1\nline\"2\""`, got)
+	})
+}
+
+// TestRegisterSecretProvider_Keyring 测试通过 Keyring 接口注册自定义后端
+func TestRegisterSecretProvider_Keyring(t *testing.T) {
+	RegisterSecretProvider(SecretSchemeKeyring, NewKeyringProvider(stubKeyring{"opensearch/admin": "s3cr3t"}))
+	t.Cleanup(func() {
+		secretProviders.mu.Lock()
+		delete(secretProviders.providers, SecretSchemeKeyring)
+		secretProviders.mu.Unlock()
+	})
+
+	got, err := expandEnv(`password = "${keyring:opensearch/admin}"`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `password = "s3cr3t"`, got)
+
+	_, err = expandEnv(`password = "${keyring:opensearch}"`, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "<service>/<key>")
+}
+
+// TestLoader_Load_SecretReference 测试 Load 时解析密钥引用
+func TestLoader_Load_SecretReference(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "pd_key")
+	require.NoError(t, os.WriteFile(secretFile, []byte("pd-secret"), 0600))
+
+	configContent := testAgentConfig + fmt.Sprintf(`
+[server]
+addr = "localhost:8080"
+
+[services.pagerduty]
+type = "pagerduty"
+enabled = true
+description = "${file:%s}"
+`, secretFile)
+	loader := NewLoader(createTempConfig(t, configContent))
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "pd-secret", cfg.Services["pagerduty"].Description)
+
+	loader = NewLoader(createTempConfig(t, testAgentConfig+`
+[server]
+addr = "localhost:8080"
+
+[services.pagerduty]
+type = "pagerduty"
+enabled = true
+description = "${file:/nonexistent/pd_key}"
+`))
+	_, err = loader.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "load config file")
+	assert.Contains(t, err.Error(), "${file:/nonexistent/pd_key}")
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce 合并编辑器保存时产生的多次写事件
+const reloadDebounce = 300 * time.Millisecond
+
+// Diff 描述两次加载之间的配置差异
+//
+// 说明：
+// - 只统计 enabled 的 agents / services（disabled 的在 Load 阶段已被过滤）。
+// - ServicesChanged 同时比较 [services.<name>.options] 原始内容。
+// - RestartRequired 列出无法热加载、需要重启才能生效的配置段。
+type Diff struct {
+	ServicesAdded        []string
+	ServicesRemoved      []string
+	ServicesChanged      []string
+	AgentsAdded          []string
+	AgentsRemoved        []string
+	AgentsChanged        []string
+	ConversationChanged  bool
+	LogChanged           bool
+	RoutingChanged       bool
+	ToolsChanged         bool
+	InvestigationChanged bool
+	PostmortemChanged    bool
+	RestartRequired      []string
+}
+
+// Empty 判断是否没有任何差异
+func (d Diff) Empty() bool {
+	return len(d.ServicesAdded) == 0 && len(d.ServicesRemoved) == 0 && len(d.ServicesChanged) == 0 &&
+		len(d.AgentsAdded) == 0 && len(d.AgentsRemoved) == 0 && len(d.AgentsChanged) == 0 &&
+		!d.ConversationChanged && !d.LogChanged && !d.RoutingChanged && !d.ToolsChanged && !d.InvestigationChanged && !d.PostmortemChanged &&
+		len(d.RestartRequired) == 0
+}
+
+// ReloadFunc 在新配置通过加载与校验后被调用
+// 返回错误时新配置被丢弃，旧配置保持生效。
+type ReloadFunc func(ctx context.Context, next *Loader, diff Diff) error
+
+// Reload 重新加载配置文件
+//
+// 新配置先在独立的 Loader 中完成 Load 与校验，再交给 onReload 应用；
+// 只有两步都成功时才替换当前配置，否则保留旧配置并返回错误。
+func (l *Loader) Reload(ctx context.Context, onReload ReloadFunc) error {
+	next := NewLoader(l.configPath, l.opts...)
+	if _, err := next.Load(); err != nil {
+		return fmt.Errorf("reload config: %w", err)
+	}
+
+	diff := l.diff(next)
+	if diff.Empty() {
+		slog.Info("config.reload.unchanged", "path", l.configPath)
+		return nil
+	}
+	if len(diff.RestartRequired) > 0 {
+		slog.Warn("config.reload.restart_required", "sections", diff.RestartRequired)
+	}
+
+	if onReload != nil {
+		if err := onReload(ctx, next, diff); err != nil {
+			return fmt.Errorf("apply config: %w", err)
+		}
+	}
+
+	l.commit(next)
+	slog.Info("config.reload.complete",
+		"path", l.configPath,
+		"services_added", diff.ServicesAdded,
+		"services_removed", diff.ServicesRemoved,
+		"services_changed", diff.ServicesChanged,
+		"agents_added", diff.AgentsAdded,
+		"agents_removed", diff.AgentsRemoved,
+		"agents_changed", diff.AgentsChanged,
+	)
+	return nil
+}
+
+// Watch 监听配置文件变化与 SIGHUP 信号并触发 Reload，阻塞直到 ctx 结束
+//
+// 监听的是配置文件所在目录而不是文件本身，以兼容编辑器“写临时文件再 rename”的保存方式。
+// include 与 profile 覆盖文件同样会被监听，reload 后新增的 include 会自动加入监听。
+// 单次 reload 失败只记录日志，不会中断监听。
+func (l *Loader) Watch(ctx context.Context, onReload ReloadFunc) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create config watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	watched, err := l.watchSources(watcher)
+	if err != nil {
+		return err
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	slog.Info("config.watch.start", "path", l.configPath, "sources", l.Sources())
+
+	var (
+		timer  *time.Timer
+		timerC <-chan time.Time
+	)
+	reload := func(reason string) {
+		slog.Info("config.reload.start", "path", l.configPath, "reason", reason)
+		if err := l.Reload(ctx, onReload); err != nil {
+			slog.Error("config.reload.rejected", "path", l.configPath, "error", err)
+			return
+		}
+		if next, err := l.watchSources(watcher); err != nil {
+			slog.Warn("config.watch.error", "error", err)
+		} else {
+			watched = next
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return nil
+		case <-hup:
+			reload("sighup")
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if _, ok := watched[filepath.Clean(event.Name)]; !ok {
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			if timer == nil {
+				timer = time.NewTimer(reloadDebounce)
+			} else {
+				timer.Reset(reloadDebounce)
+			}
+			timerC = timer.C
+		case <-timerC:
+			timerC = nil
+			reload("file_changed")
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("config.watch.error", "error", err)
+		}
+	}
+}
+
+// watchSources 监听所有配置来源文件所在目录，返回需要关注的文件集合
+func (l *Loader) watchSources(watcher *fsnotify.Watcher) (map[string]struct{}, error) {
+	sources := l.Sources()
+	if len(sources) == 0 {
+		absPath, err := filepath.Abs(l.configPath)
+		if err != nil {
+			return nil, fmt.Errorf("resolve config path %s: %w", l.configPath, err)
+		}
+		sources = []string{absPath}
+	}
+	if l.profile != "" {
+		// profile 覆盖文件缺失时 Load 会失败，这里仍然监听以便文件创建后能触发 reload
+		absOverlay, err := filepath.Abs(ProfilePath(l.configPath, l.profile))
+		if err == nil {
+			sources = append(sources, absOverlay)
+		}
+	}
+
+	watched := make(map[string]struct{}, len(sources))
+	dirs := make(map[string]struct{})
+	for _, src := range sources {
+		watched[src] = struct{}{}
+		dirs[filepath.Dir(src)] = struct{}{}
+	}
+	existing := watcher.WatchList()
+	for dir := range dirs {
+		if slices.Contains(existing, dir) {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			return nil, fmt.Errorf("watch config dir %s: %w", dir, err)
+		}
+	}
+	return watched, nil
+}
+
+// commit 用 next 的内容替换当前配置
+func (l *Loader) commit(next *Loader) {
+	next.mu.RLock()
+	cfg, raw, sources, serviceMeta := next.config, next.raw, next.sources, next.serviceMeta
+	next.mu.RUnlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = cfg
+	l.raw = raw
+	l.sources = sources
+	l.serviceMeta = serviceMeta
+}
+
+// diff 比较当前配置与 next 的差异
+func (l *Loader) diff(next *Loader) Diff {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	next.mu.RLock()
+	defer next.mu.RUnlock()
+
+	var d Diff
+	if l.config == nil || next.config == nil {
+		return d
+	}
+	oldCfg, newCfg := l.config, next.config
+
+	oldRawServices, _ := l.raw["services"].(map[string]any)
+	newRawServices, _ := next.raw["services"].(map[string]any)
+	d.ServicesAdded, d.ServicesRemoved, d.ServicesChanged = diffKeys(oldCfg.Services, newCfg.Services, func(name string) bool {
+		return !reflect.DeepEqual(oldRawServices[name], newRawServices[name])
+	})
+	d.AgentsAdded, d.AgentsRemoved, d.AgentsChanged = diffKeys(oldCfg.Agents, newCfg.Agents, func(name string) bool {
+		return !reflect.DeepEqual(oldCfg.Agents[name], newCfg.Agents[name])
+	})
+
+	d.ConversationChanged = oldCfg.Conversation != newCfg.Conversation
+	d.LogChanged = oldCfg.Log != newCfg.Log
+	d.RoutingChanged = !reflect.DeepEqual(oldCfg.Routing, newCfg.Routing)
+	d.ToolsChanged = !reflect.DeepEqual(oldCfg.Tools, newCfg.Tools)
+	d.InvestigationChanged = oldCfg.Investigation != newCfg.Investigation
+	d.PostmortemChanged = oldCfg.Postmortem != newCfg.Postmortem
+	if oldCfg.Server != newCfg.Server {
+		d.RestartRequired = append(d.RestartRequired, "server")
+	}
+	if oldCfg.Data != newCfg.Data {
+		d.RestartRequired = append(d.RestartRequired, "data")
+	}
+	return d
+}
+
+// diffKeys 按 key 比较两个 map，返回新增、删除与变更的 key（均已排序）
+func diffKeys[V any](prev, next map[string]V, changed func(name string) bool) (added, removed, modified []string) {
+	for name := range next {
+		if _, ok := prev[name]; !ok {
+			added = append(added, name)
+		} else if changed(name) {
+			modified = append(modified, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(modified)
+	return added, removed, modified
+}
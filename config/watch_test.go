@@ -0,0 +1,192 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reloadBaseConfig = testAgentConfig + `
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = true
+[services.prometheus.options]
+address = "http://localhost:9090"
+
+[services.pagerduty]
+type = "pagerduty"
+enabled = true
+[services.pagerduty.options]
+api_key = "key"
+`
+
+// TestLoader_Reload_Diff 验证 Reload 能正确计算差异并替换当前配置
+func TestLoader_Reload_Diff(t *testing.T) {
+	configPath := createTempConfig(t, reloadBaseConfig)
+	loader := NewLoader(configPath)
+	_, err := loader.Load()
+	require.NoError(t, err)
+
+	updated := testAgentConfig + `
+[agents.report_agent]
+enabled = true
+[agents.report_agent.llm]
+provider = "openai"
+model = "gpt-4o"
+api_key = "test-api-key"
+
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = true
+[services.prometheus.options]
+address = "http://prometheus:9090"
+
+[services.opensearch]
+type = "opensearch"
+enabled = true
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(updated), 0644))
+
+	var got Diff
+	err = loader.Reload(context.Background(), func(ctx context.Context, next *Loader, diff Diff) error {
+		got = diff
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"opensearch"}, got.ServicesAdded)
+	assert.Equal(t, []string{"pagerduty"}, got.ServicesRemoved)
+	assert.Equal(t, []string{"prometheus"}, got.ServicesChanged)
+	assert.Equal(t, []string{"report_agent"}, got.AgentsAdded)
+	assert.Empty(t, got.AgentsChanged)
+	assert.Empty(t, got.RestartRequired)
+
+	cfg, err := loader.Get()
+	require.NoError(t, err)
+	assert.Contains(t, cfg.Services, "opensearch")
+	assert.NotContains(t, cfg.Services, "pagerduty")
+}
+
+// TestLoader_Reload_KeepsOldConfig 验证新配置无效或应用失败时保留旧配置
+func TestLoader_Reload_KeepsOldConfig(t *testing.T) {
+	t.Run("新配置校验失败", func(t *testing.T) {
+		configPath := createTempConfig(t, reloadBaseConfig)
+		loader := NewLoader(configPath)
+		_, err := loader.Load()
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(configPath, []byte(testAgentConfig+`
+[server]
+addr = "invalid-address"
+`), 0644))
+
+		called := false
+		err = loader.Reload(context.Background(), func(ctx context.Context, next *Loader, diff Diff) error {
+			called = true
+			return nil
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "reload config")
+		assert.False(t, called)
+
+		cfg, err := loader.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "localhost:8080", cfg.Server.Addr)
+	})
+
+	t.Run("应用新配置失败", func(t *testing.T) {
+		configPath := createTempConfig(t, reloadBaseConfig)
+		loader := NewLoader(configPath)
+		_, err := loader.Load()
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(configPath, []byte(testAgentConfig+`
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = true
+[services.prometheus.options]
+address = "http://localhost:9090"
+`), 0644))
+
+		applyErr := errors.New("boom")
+		err = loader.Reload(context.Background(), func(ctx context.Context, next *Loader, diff Diff) error {
+			return applyErr
+		})
+		require.ErrorIs(t, err, applyErr)
+
+		cfg, err := loader.Get()
+		require.NoError(t, err)
+		assert.Contains(t, cfg.Services, "pagerduty")
+	})
+}
+
+// TestLoader_Reload_Unchanged 验证配置没有变化时不调用 onReload
+func TestLoader_Reload_Unchanged(t *testing.T) {
+	configPath := createTempConfig(t, reloadBaseConfig)
+	loader := NewLoader(configPath)
+	_, err := loader.Load()
+	require.NoError(t, err)
+
+	err = loader.Reload(context.Background(), func(ctx context.Context, next *Loader, diff Diff) error {
+		t.Fatalf("onReload should not be called for unchanged config")
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// TestLoader_Watch 验证文件写入会触发热加载
+func TestLoader_Watch(t *testing.T) {
+	configPath := createTempConfig(t, reloadBaseConfig)
+	loader := NewLoader(configPath)
+	_, err := loader.Load()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloaded := make(chan Diff, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- loader.Watch(ctx, func(ctx context.Context, next *Loader, diff Diff) error {
+			reloaded <- diff
+			return nil
+		})
+	}()
+
+	// watcher 就绪时间不确定，按大于 debounce 的间隔重复写入直到触发
+	updated := reloadBaseConfig + `
+[services.opensearch]
+type = "opensearch"
+enabled = true
+`
+	deadline := time.After(5 * time.Second)
+	ticker := time.NewTicker(2 * reloadDebounce)
+	defer ticker.Stop()
+	for {
+		select {
+		case diff := <-reloaded:
+			assert.Equal(t, []string{"opensearch"}, diff.ServicesAdded)
+			cancel()
+			require.NoError(t, <-done)
+			return
+		case <-ticker.C:
+			require.NoError(t, os.WriteFile(configPath, []byte(updated), 0644))
+		case <-deadline:
+			t.Fatal("timed out waiting for config reload")
+		}
+	}
+}
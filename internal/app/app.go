@@ -5,7 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"os"
+	"os/user"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kratos/blades"
@@ -17,9 +22,13 @@ import (
 	"github.com/oneblade/internal/consts"
 	"github.com/oneblade/internal/llm"
 	"github.com/oneblade/internal/logger"
+	"github.com/oneblade/internal/middleware"
 	"github.com/oneblade/internal/persistence"
+	"github.com/oneblade/internal/playbook"
+	"github.com/oneblade/internal/prompts"
 	"github.com/oneblade/internal/session"
 	"github.com/oneblade/internal/summary"
+	"github.com/oneblade/internal/toolbox"
 	"github.com/oneblade/service"
 
 	_ "github.com/oneblade/service/jira"
@@ -29,21 +38,30 @@ import (
 )
 
 type Application struct {
-	cfg          *config.Loader
-	agents       map[string]*config.AgentConfig
-	registry     *service.Registry
-	modelReg     *llm.ModelRegistry
+	cfg      *config.Loader
+	agents   map[string]*config.AgentConfig
+	registry *service.Registry
+	modelReg *llm.ModelRegistry
+	// llmFactory 在整个进程生命周期内复用，使相同 API key 的限流额度在 agents 与热加载之间共享
+	llmFactory  *llm.Factory
+	memoryStore memory.MemoryStore
+	// routingMetrics 在热加载重建 orchestrator 时复用，规则命中计数不会清零
+	routingMetrics *middleware.RoutingMetrics
+
+	// mu 保护 orchestrator/runner/agents，配置热加载时会整体替换
+	mu           sync.RWMutex
 	orchestrator blades.Agent
 	runner       *blades.Runner
-	memoryStore  memory.MemoryStore
 }
 
-func NewApplication(configPath string) (*Application, error) {
-	loader := config.NewLoader(configPath)
+func NewApplication(configPath string, opts ...config.LoaderOption) (*Application, error) {
+	loader := config.NewLoader(configPath, opts...)
 
 	return &Application{
-		cfg:      loader,
-		modelReg: llm.NewModelRegistry(),
+		cfg:            loader,
+		modelReg:       llm.NewModelRegistry(),
+		llmFactory:     llm.NewFactory(),
+		routingMetrics: middleware.NewRoutingMetrics(),
 	}, nil
 }
 
@@ -56,22 +74,21 @@ func (a *Application) Initialize(ctx context.Context) error {
 	if err := a.validateRules(cfg); err != nil {
 		return fmt.Errorf("validate app rules: %w", err)
 	}
+	// 启动时即校验全部 service 的 options，避免缺少必填项的 service 在首次调用时才报错
+	if err := service.ValidateOptions(a.cfg); err != nil {
+		return fmt.Errorf("validate service options: %w", err)
+	}
 
 	logger.Initialize(cfg)
 
 	// Cache enabled agents
-	a.agents = make(map[string]*config.AgentConfig)
-	for name, acfg := range cfg.Agents {
-		if acfg.Enabled {
-			a.agents[name] = &acfg
-		}
-	}
+	a.agents = enabledAgents(cfg)
 
 	if err := a.initServices(); err != nil {
 		return err
 	}
 
-	if err := a.initModels(ctx); err != nil {
+	if err := a.initModels(ctx, cfg); err != nil {
 		return err
 	}
 
@@ -79,13 +96,39 @@ func (a *Application) Initialize(ctx context.Context) error {
 		return err
 	}
 
-	if err := a.initOrchestrator(); err != nil {
+	if err := a.initOrchestrator(cfg); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// enabledAgents 返回 enabled 的 agent 配置，每个 agent 持有独立的配置指针
+func enabledAgents(cfg *config.Config) map[string]*config.AgentConfig {
+	agents := make(map[string]*config.AgentConfig)
+	for name, acfg := range cfg.Agents {
+		if acfg.Enabled {
+			agents[name] = &acfg
+		}
+	}
+	return agents
+}
+
+// Validate 加载配置并执行启动时的全部校验（包括各 service 的 options），不初始化服务与模型
+func (a *Application) Validate() (*config.Config, error) {
+	cfg, err := a.cfg.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	if err := a.validateRules(cfg); err != nil {
+		return nil, fmt.Errorf("validate app rules: %w", err)
+	}
+	if err := service.ValidateOptions(a.cfg); err != nil {
+		return nil, fmt.Errorf("validate service options: %w", err)
+	}
+	return cfg, nil
+}
+
 func (a *Application) validateRules(cfg *config.Config) error {
 	orchestrator, ok := cfg.Agents[consts.AgentNameOrchestrator]
 	if !ok {
@@ -95,17 +138,104 @@ func (a *Application) validateRules(cfg *config.Config) error {
 		return fmt.Errorf("orchestrator agent %s must be enabled", consts.AgentNameOrchestrator)
 	}
 
+	// analysis_agent 等由其他 agent 组装，没有独立的配置项
+	for _, name := range consts.ReservedAgents {
+		if _, ok := cfg.Agents[name]; ok {
+			return fmt.Errorf("agent %s is reserved and cannot be configured in [agents]", name)
+		}
+	}
+
+	// 自定义 agent 同样可以作为子 Agent 被路由
 	enabledSubAgents := 0
-	for _, name := range consts.RequiredSubAgents {
-		if agent, ok := cfg.Agents[name]; ok && agent.Enabled {
+	for name, agent := range cfg.Agents {
+		if !agent.Enabled {
+			continue
+		}
+		if slices.Contains(consts.RequiredSubAgents, name) || !slices.Contains(consts.BuiltinAgents, name) {
 			enabledSubAgents++
 		}
 	}
 	if enabledSubAgents == 0 {
 		agentNames := strings.Join(consts.RequiredSubAgents, ", ")
-		return fmt.Errorf("at least one sub agent (%s) must be enabled", agentNames)
+		return fmt.Errorf("at least one sub agent (%s) or custom agent must be enabled", agentNames)
+	}
+
+	// 默认 agent 与路由规则的目标必须是可被路由的子 agent；analysis_agent 始终存在
+	routable := func(name string) bool {
+		if name == consts.AgentNameAnalysis {
+			return true
+		}
+		agent, ok := cfg.Agents[name]
+		return ok && agent.Enabled && name != consts.AgentNameOrchestrator
+	}
+	if name := cfg.Routing.DefaultAgent; name != "" && !routable(name) {
+		return fmt.Errorf("routing.default_agent %s must be an enabled sub agent", name)
+	}
+	for _, rule := range cfg.Routing.Rules {
+		if !routable(rule.Agent) {
+			return fmt.Errorf("routing.rules %s: agent %s must be an enabled sub agent", rule.Name, rule.Agent)
+		}
+	}
+
+	// investigation_agent 通过 PagerDuty 获取事件详情
+	if agent, ok := cfg.Agents[consts.AgentNameInvestigation]; ok && agent.Enabled {
+		hasPagerDuty := false
+		for _, s := range cfg.Services {
+			hasPagerDuty = hasPagerDuty || (s.Enabled && s.Type == string(service.PagerDuty))
+		}
+		if !hasPagerDuty {
+			return fmt.Errorf("agent %s requires an enabled pagerduty service", consts.AgentNameInvestigation)
+		}
+	}
+
+	// postmortem_agent 从 PagerDuty 获取事件日志，并在 Jira 创建复盘 issue
+	if agent, ok := cfg.Agents[consts.AgentNamePostmortem]; ok && agent.Enabled {
+		enabled := make(map[string]bool)
+		for _, s := range cfg.Services {
+			enabled[s.Type] = enabled[s.Type] || s.Enabled
+		}
+		for _, typ := range []service.ServiceType{service.PagerDuty, service.Jira} {
+			if !enabled[string(typ)] {
+				return fmt.Errorf("agent %s requires an enabled %s service", consts.AgentNamePostmortem, typ)
+			}
+		}
 	}
 
+	for name, agent := range cfg.Agents {
+		if agent.StructuredOutput && name != consts.AgentNameReport && name != consts.AgentNamePrediction {
+			return fmt.Errorf("agent %s does not support structured_output", name)
+		}
+		if !agent.Enabled {
+			continue
+		}
+		if err := validateCustomAgent(cfg, name, agent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateCustomAgent 校验自定义 agent 的声明；内置 agent 不允许配置自定义 agent 专属字段
+func validateCustomAgent(cfg *config.Config, name string, agent config.AgentConfig) error {
+	if slices.Contains(consts.BuiltinAgents, name) {
+		if agent.Description != "" || agent.Instruction != "" || agent.InstructionFile != "" ||
+			len(agent.Services) > 0 || len(agent.Tools) > 0 || len(agent.Middleware) > 0 {
+			return fmt.Errorf("agent %s is built-in: description, instruction, services, tools and middleware are only supported for custom agents", name)
+		}
+		return nil
+	}
+	if agent.Description == "" {
+		return fmt.Errorf("custom agent %s requires a description", name)
+	}
+	if strings.TrimSpace(agent.Instruction) == "" {
+		return fmt.Errorf("custom agent %s requires an instruction or instruction_file", name)
+	}
+	for _, svc := range agent.Services {
+		if s, ok := cfg.Services[svc]; !ok || !s.Enabled {
+			return fmt.Errorf("custom agent %s: service %s not found or not enabled", name, svc)
+		}
+	}
 	return nil
 }
 
@@ -132,23 +262,20 @@ func (a *Application) initServices() error {
 	return nil
 }
 
-func (a *Application) initModels(ctx context.Context) error {
+func (a *Application) initModels(ctx context.Context, cfg *config.Config) error {
 	slog.Info("app.init.models.start")
-	factory := llm.NewFactory()
-
-	for name, agentCfg := range a.agents {
-		m, err := factory.Build(ctx, agentCfg.LLM)
-		if err != nil {
-			return fmt.Errorf("build model for %s: %w", name, err)
-		}
-		a.modelReg.Register(name, m)
-		slog.Info("app.init.models.register",
-			"agent", name,
-			"provider", agentCfg.LLM.Provider,
-			"model", agentCfg.LLM.Model,
-		)
-	}
-	slog.Info("app.init.models.complete", "count", len(a.agents))
+	targets := modelTargets(cfg, a.agents)
+	staged, err := a.buildModels(ctx, targets, slices.Collect(maps.Keys(targets)))
+	if err != nil {
+		return err
+	}
+	if err := a.modelReg.Swap(staged.models, staged.keys, nil); err != nil {
+		return err
+	}
+	slog.Info("app.init.models.complete",
+		"count", len(staged.models),
+		"clients", len(staged.built),
+	)
 	return nil
 }
 
@@ -158,7 +285,8 @@ func (a *Application) initMemoryStore() error {
 	return nil
 }
 
-func (a *Application) initTools() ([]toolkit.Tool, error) {
+// initTools 构建 general_agent 与自定义 agent 可用的本地工具；配置了 [tools.files] dirs 时才提供 ReadFile
+func (a *Application) initTools(cfg *config.Config) ([]toolkit.Tool, error) {
 	slog.Info("app.init.tools.start")
 	memoryTool, err := memory.NewMemoryTool(a.memoryStore)
 	if err != nil {
@@ -172,7 +300,22 @@ func (a *Application) initTools() ([]toolkit.Tool, error) {
 	if err != nil {
 		return nil, fmt.Errorf("create LoadContext tool: %w", err)
 	}
-	tools := []toolkit.Tool{memoryTool, saveTool, loadTool}
+	timeTool, err := toolbox.NewTimeTool()
+	if err != nil {
+		return nil, fmt.Errorf("create Time tool: %w", err)
+	}
+	unitTool, err := toolbox.NewConvertUnitTool()
+	if err != nil {
+		return nil, fmt.Errorf("create ConvertUnit tool: %w", err)
+	}
+	tools := []toolkit.Tool{memoryTool, saveTool, loadTool, timeTool, unitTool}
+	if files := cfg.Tools.Files; len(files.Dirs) > 0 {
+		readTool, err := toolbox.NewReadFileTool(files.Dirs, files.MaxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("create ReadFile tool: %w", err)
+		}
+		tools = append(tools, readTool)
+	}
 	toolNames := make([]string, 0, len(tools))
 	for _, t := range tools {
 		toolNames = append(toolNames, t.Name())
@@ -184,35 +327,104 @@ func (a *Application) initTools() ([]toolkit.Tool, error) {
 	return tools, nil
 }
 
-func (a *Application) initOrchestrator() error {
+func (a *Application) initOrchestrator(cfg *config.Config) error {
 	slog.Info("app.init.orchestrator.start")
-	enabledAgents := make([]string, 0, len(a.agents))
-	for name := range a.agents {
+	orchestrator, enabled, err := a.buildOrchestrator(cfg, a.modelReg, a.registry.All(), a.agents)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.orchestrator = orchestrator
+	a.runner = agent.NewInspectionRunner(orchestrator)
+	a.mu.Unlock()
+	slog.Info("app.init.orchestrator.complete",
+		"enabled_agents", enabled,
+	)
+	return nil
+}
+
+// buildOrchestrator 基于给定的模型、服务与 agent 配置构建 orchestrator，不修改 Application 状态
+// cfg 提供提示词与采集超时等配置；提示词模板随 orchestrator 一起重新加载（启动与配置热加载时）。
+func (a *Application) buildOrchestrator(cfg *config.Config, modelReg *llm.ModelRegistry, services []service.Service, agents map[string]*config.AgentConfig) (blades.Agent, []string, error) {
+	enabledAgents := make([]string, 0, len(agents))
+	var structuredAgents []string
+	customAgents := make(map[string]agent.CustomAgentSpec)
+	for name, acfg := range agents {
 		enabledAgents = append(enabledAgents, name)
+		if acfg.StructuredOutput {
+			structuredAgents = append(structuredAgents, name)
+		}
+		if !slices.Contains(consts.BuiltinAgents, name) {
+			customAgents[name] = agent.CustomAgentSpec{
+				Description: acfg.Description,
+				Instruction: acfg.Instruction,
+				Services:    acfg.Services,
+				Tools:       acfg.Tools,
+				Middleware:  acfg.Middleware,
+			}
+		}
 	}
 
-	baseTools, err := a.initTools()
+	baseTools, err := a.initTools(cfg)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	promptSet, err := prompts.Load(prompts.Options{Locale: cfg.Prompts.Locale, Dir: cfg.Prompts.Dir})
+	if err != nil {
+		return nil, nil, fmt.Errorf("load prompts: %w", err)
+	}
+	slog.Info("app.init.prompts.complete",
+		"locale", promptSet.Locale(),
+		"version", promptSet.Version(),
+		"dir", cfg.Prompts.Dir,
+	)
+
+	collectTimeouts := make(map[string]time.Duration)
+	for name, scfg := range cfg.Services {
+		if scfg.CollectTimeout > 0 {
+			collectTimeouts[name] = scfg.CollectTimeout
+		}
+	}
+
+	routingRules := make([]agent.RoutingRule, 0, len(cfg.Routing.Rules))
+	for _, rule := range cfg.Routing.Rules {
+		routingRules = append(routingRules, agent.RoutingRule{
+			Name:     rule.Name,
+			Agent:    rule.Agent,
+			Keywords: rule.Keywords,
+			Pattern:  rule.Pattern,
+			Command:  rule.Command,
+		})
 	}
 
 	orchestrator, err := agent.NewOrchestratorAgent(agent.OrchestratorConfig{
-		ModelRegistry:          a.modelReg,
-		Services:               a.registry.All(),
-		EnabledAgents:          enabledAgents,
-		Tools:                  baseTools,
-		ConversationMaxMessage: 50,
+		ModelRegistry:              modelReg,
+		Services:                   services,
+		EnabledAgents:              enabledAgents,
+		Tools:                      baseTools,
+		ConversationMaxMessage:     50,
+		StructuredOutputAgents:     structuredAgents,
+		CustomAgents:               customAgents,
+		Prompts:                    promptSet,
+		CollectTimeouts:            collectTimeouts,
+		MaxHops:                    cfg.Routing.MaxHops,
+		DefaultAgent:               cfg.Routing.DefaultAgent,
+		RoutingRules:               routingRules,
+		RoutingMetrics:             a.routingMetrics,
+		InvestigationMaxIterations: cfg.Investigation.MaxIterations,
+		InvestigationWindow:        cfg.Investigation.Window,
+		Postmortem: agent.PostmortemConfig{
+			Project:     cfg.Postmortem.Project,
+			IssueType:   cfg.Postmortem.IssueType,
+			SubtaskType: cfg.Postmortem.SubtaskType,
+		},
 	})
 	if err != nil {
-		return fmt.Errorf("create orchestrator failed: %w", err)
+		return nil, nil, fmt.Errorf("create orchestrator failed: %w", err)
 	}
-	a.orchestrator = orchestrator
-
-	a.runner = agent.NewInspectionRunner(a.orchestrator)
-	slog.Info("app.init.orchestrator.complete",
-		"enabled_agents", enabledAgents,
-	)
-	return nil
+	return orchestrator, enabledAgents, nil
 }
 
 func (a *Application) Shutdown(ctx context.Context) error {
@@ -234,10 +446,95 @@ func (a *Application) Shutdown(ctx context.Context) error {
 }
 
 func (a *Application) Run(ctx context.Context, input *blades.Message, opts ...blades.RunOption) (*blades.Message, error) {
-	if a.runner == nil {
+	if a.registry == nil {
+		return nil, fmt.Errorf("application not initialized")
+	}
+	// 先登记调用再取 runner，重载替换的 service 与模型会等本次调用结束后再关闭
+	release := a.acquire()
+	defer release()
+
+	a.mu.RLock()
+	runner := a.runner
+	a.mu.RUnlock()
+
+	if runner == nil {
+		return nil, fmt.Errorf("application not initialized")
+	}
+	return runner.Run(ctx, input, opts...)
+}
+
+// acquire 在 service 与模型注册表中登记一次调用，返回的 release 在调用结束时执行
+func (a *Application) acquire() (release func()) {
+	releaseServices := a.registry.Acquire()
+	releaseModels := a.modelReg.Acquire()
+	return func() {
+		releaseModels()
+		releaseServices()
+	}
+}
+
+// RunPlaybook 执行巡检清单，并将检查结果交给 report_agent 生成报告
+// name 为 [playbooks] dir 下的清单名称或清单文件路径；未启用 report_agent 时只返回检查结果。
+func (a *Application) RunPlaybook(ctx context.Context, name string) (*blades.Message, error) {
+	cfg, err := a.cfg.Get()
+	if err != nil {
+		return nil, fmt.Errorf("get config: %w", err)
+	}
+	if a.registry == nil {
 		return nil, fmt.Errorf("application not initialized")
 	}
-	return a.runner.Run(ctx, input, opts...)
+	release := a.acquire()
+	defer release()
+
+	p, err := playbook.Load(playbook.Path(cfg.Playbooks.Dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	reportCfg, reportEnabled := a.agents[consts.AgentNameReport]
+	a.mu.RUnlock()
+
+	services := a.registry.All()
+	var report blades.Agent
+	if reportEnabled {
+		model, err := a.modelReg.Get(consts.AgentNameReport)
+		if err != nil {
+			return nil, err
+		}
+		promptSet, err := prompts.Load(prompts.Options{Locale: cfg.Prompts.Locale, Dir: cfg.Prompts.Dir})
+		if err != nil {
+			return nil, fmt.Errorf("load prompts: %w", err)
+		}
+		report, err = agent.NewReportAgent(agent.ReportAgentConfig{
+			Model:            model,
+			Services:         services,
+			Prompts:          promptSet,
+			StructuredOutput: reportCfg.StructuredOutput,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		slog.Warn("playbook.report.disabled", "agent", consts.AgentNameReport)
+	}
+
+	playbookAgent, err := agent.NewPlaybookAgent(agent.PlaybookAgentConfig{
+		Playbook: p,
+		Services: services,
+		Report:   report,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := a.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("playbook.start", "playbook", p.Name, "checks", len(p.Checks))
+	runner := agent.NewInspectionRunner(playbookAgent)
+	return runner.Run(ctx, blades.UserMessage("执行巡检清单 "+p.Name), blades.WithSession(sess))
 }
 
 func (a *Application) NewSession() (blades.Session, error) {
@@ -246,7 +543,7 @@ func (a *Application) NewSession() (blades.Session, error) {
 		return nil, fmt.Errorf("get config: %w", err)
 	}
 
-	modelName := cfg.Conversation.SummaryModelAgent
+	modelName := summaryModelName(cfg)
 	model, err := a.modelReg.Get(modelName)
 	if err != nil {
 		return nil, fmt.Errorf("get model %s: %w", modelName, err)
@@ -260,10 +557,30 @@ func (a *Application) NewSession() (blades.Session, error) {
 		return nil, fmt.Errorf("create summarizer: %w", err)
 	}
 
-	return session.NewManagedSession(session.ManagedSessionConfig{
+	managed, err := session.NewManagedSession(session.ManagedSessionConfig{
 		Conversation: cfg.Conversation,
 		Summarizer:   s,
 	})
+	if err != nil {
+		return nil, err
+	}
+	if name := currentUser(); name != "" {
+		managed.SetState(session.StateKeyUser, name)
+	}
+	return managed, nil
+}
+
+// currentUser 返回运行进程的系统用户名，作为会话用户注入提示词
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// RoutingStats 返回进程启动以来规则路由的命中统计，热加载后继续累计
+func (a *Application) RoutingStats() middleware.RoutingStats {
+	return a.routingMetrics.Snapshot()
 }
 
 func (a *Application) MemoryStore() memory.MemoryStore {
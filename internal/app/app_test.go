@@ -2,12 +2,18 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/go-kratos/blades"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/internal/session"
 )
 
 // createTempConfig 创建临时配置文件
@@ -169,6 +175,243 @@ model = "gpt-4"
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "at least one sub agent")
 	})
+
+	t.Run("不支持结构化输出的 agent", func(t *testing.T) {
+		configContent := baseConfig + `
+[agents.orchestrator]
+enabled = true
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4"
+
+[agents.service_agent]
+enabled = true
+structured_output = true
+[agents.service_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`
+		configPath := createTempConfig(t, configContent)
+		app, _ := NewApplication(configPath)
+		err := app.Initialize(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "agent service_agent does not support structured_output")
+	})
+
+	t.Run("默认 agent 未开启", func(t *testing.T) {
+		configContent := baseConfig + `
+[routing]
+default_agent = "report_agent"
+
+[agents.orchestrator]
+enabled = true
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4"
+
+[agents.service_agent]
+enabled = true
+[agents.service_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`
+		configPath := createTempConfig(t, configContent)
+		app, _ := NewApplication(configPath)
+		err := app.Initialize(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "routing.default_agent report_agent must be an enabled sub agent")
+	})
+
+	t.Run("investigation_agent 缺少 pagerduty", func(t *testing.T) {
+		configContent := baseConfig + `
+[agents.orchestrator]
+enabled = true
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4"
+
+[agents.investigation_agent]
+enabled = true
+[agents.investigation_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`
+		configPath := createTempConfig(t, configContent)
+		app, _ := NewApplication(configPath)
+		err := app.Initialize(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "agent investigation_agent requires an enabled pagerduty service")
+	})
+
+	t.Run("postmortem_agent 缺少 jira", func(t *testing.T) {
+		configContent := baseConfig + `
+[services.pagerduty]
+type = "pagerduty"
+enabled = true
+[services.pagerduty.options]
+api_key = "test"
+
+[agents.orchestrator]
+enabled = true
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4"
+
+[agents.postmortem_agent]
+enabled = true
+[agents.postmortem_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`
+		configPath := createTempConfig(t, configContent)
+		app, _ := NewApplication(configPath)
+		err := app.Initialize(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "agent postmortem_agent requires an enabled jira service")
+	})
+
+	t.Run("路由规则目标 agent 未开启", func(t *testing.T) {
+		configContent := baseConfig + `
+[[routing.rules]]
+name = "alerts"
+agent = "report_agent"
+command = "/alerts"
+
+[agents.orchestrator]
+enabled = true
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4"
+
+[agents.service_agent]
+enabled = true
+[agents.service_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`
+		configPath := createTempConfig(t, configContent)
+		app, _ := NewApplication(configPath)
+		err := app.Initialize(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "routing.rules alerts: agent report_agent must be an enabled sub agent")
+	})
+
+	t.Run("service options 缺少必填字段", func(t *testing.T) {
+		// 部分 service 可用时同样拒绝启动
+		configContent := `
+[server]
+addr = "localhost:8080"
+[services.prometheus]
+type = "prometheus"
+enabled = true
+[services.prometheus.options]
+address = "http://localhost:9090"
+[services.pagerduty]
+type = "pagerduty"
+enabled = true
+
+[agents.orchestrator]
+enabled = true
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4"
+
+[agents.service_agent]
+enabled = true
+[agents.service_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`
+		configPath := createTempConfig(t, configContent)
+		app, _ := NewApplication(configPath)
+		err := app.Initialize(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "services.pagerduty.options.api_key")
+	})
+
+	customAgentTests := []struct {
+		name    string
+		agent   string
+		wantErr string
+	}{
+		{
+			name: "自定义 agent 缺少 description",
+			agent: `
+[agents.capacity_agent]
+enabled = true
+instruction = "你是容量规划专家。"
+[agents.capacity_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`,
+			wantErr: "custom agent capacity_agent requires a description",
+		},
+		{
+			name: "自定义 agent 缺少 instruction",
+			agent: `
+[agents.capacity_agent]
+enabled = true
+description = "容量规划"
+[agents.capacity_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`,
+			wantErr: "custom agent capacity_agent requires an instruction or instruction_file",
+		},
+		{
+			name: "自定义 agent 引用未开启的 service",
+			agent: `
+[agents.capacity_agent]
+enabled = true
+description = "容量规划"
+instruction = "你是容量规划专家。"
+services = ["prometheus"]
+[agents.capacity_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`,
+			wantErr: "custom agent capacity_agent: service prometheus not found or not enabled",
+		},
+		{
+			name: "内置 agent 配置自定义字段",
+			agent: `
+[agents.report_agent]
+enabled = true
+instruction = "override"
+[agents.report_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`,
+			wantErr: "agent report_agent is built-in",
+		},
+		{
+			name: "配置保留的内置 agent",
+			agent: `
+[agents.analysis_agent]
+enabled = true
+[agents.analysis_agent.llm]
+provider = "openai"
+model = "gpt-4"
+`,
+			wantErr: "agent analysis_agent is reserved and cannot be configured in [agents]",
+		},
+	}
+	for _, tt := range customAgentTests {
+		t.Run(tt.name, func(t *testing.T) {
+			configContent := baseConfig + `
+[agents.orchestrator]
+enabled = true
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4"
+` + tt.agent
+			configPath := createTempConfig(t, configContent)
+			app, _ := NewApplication(configPath)
+			err := app.Initialize(context.Background())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
 }
 
 // TestApplication_Run_NotInitialized 测试未初始化直接运行
@@ -188,3 +431,451 @@ func TestApplication_Shutdown(t *testing.T) {
 	err := app.Shutdown(context.Background())
 	assert.NoError(t, err)
 }
+
+// TestApplication_ReloadConfig 验证热加载会替换模型与 runner，且无效配置会被拒绝
+func TestApplication_ReloadConfig(t *testing.T) {
+	baseConfig := `
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = true
+[services.prometheus.options]
+address = "http://localhost:9090"
+
+[agents.orchestrator]
+enabled = true
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4"
+api_key = "key-orchestrator"
+`
+	serviceAgent := `
+[agents.service_agent]
+enabled = true
+[agents.service_agent.llm]
+provider = "openai"
+model = "%s"
+api_key = "key-service-agent"
+`
+	configPath := createTempConfig(t, baseConfig+fmt.Sprintf(serviceAgent, "gpt-4"))
+
+	app, err := NewApplication(configPath)
+	require.NoError(t, err)
+	require.NoError(t, app.Initialize(context.Background()))
+	oldRunner := app.runner
+
+	t.Run("模型变更后替换 runner", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(configPath, []byte(baseConfig+fmt.Sprintf(serviceAgent, "gpt-4o")), 0644))
+		require.NoError(t, app.ReloadConfig(context.Background()))
+
+		assert.NotSame(t, oldRunner, app.runner)
+		assert.Equal(t, "gpt-4o", app.agents["service_agent"].LLM.Model)
+		m, err := app.modelReg.Get("service_agent")
+		require.NoError(t, err)
+		assert.Equal(t, "gpt-4o", m.Name())
+	})
+
+	t.Run("无效配置被拒绝并保留旧配置", func(t *testing.T) {
+		currentRunner := app.runner
+		// 关闭所有子 agent，违反应用规则
+		require.NoError(t, os.WriteFile(configPath, []byte(baseConfig), 0644))
+		err := app.ReloadConfig(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "at least one sub agent")
+
+		assert.Same(t, currentRunner, app.runner)
+		cfg, err := app.cfg.Get()
+		require.NoError(t, err)
+		assert.Contains(t, cfg.Agents, "service_agent")
+	})
+
+	t.Run("service options 无效时拒绝热加载", func(t *testing.T) {
+		currentRunner := app.runner
+		pagerduty := `
+[services.pagerduty]
+type = "pagerduty"
+enabled = true
+`
+		require.NoError(t, os.WriteFile(configPath, []byte(baseConfig+pagerduty+fmt.Sprintf(serviceAgent, "gpt-4o")), 0644))
+		err := app.ReloadConfig(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "services.pagerduty.options.api_key")
+
+		assert.Same(t, currentRunner, app.runner)
+		cfg, err := app.cfg.Get()
+		require.NoError(t, err)
+		assert.NotContains(t, cfg.Services, "pagerduty")
+	})
+}
+
+// TestApplication_Validate 验证 Validate 会校验延迟解析的 service options
+func TestApplication_Validate(t *testing.T) {
+	agents := `
+[server]
+addr = "localhost:8080"
+
+[agents.orchestrator]
+enabled = true
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4"
+api_key = "key-orchestrator"
+
+[agents.service_agent]
+enabled = true
+[agents.service_agent.llm]
+provider = "openai"
+model = "gpt-4"
+api_key = "key-service-agent"
+`
+	tests := []struct {
+		name     string
+		services string
+		wantErr  []string
+	}{
+		{
+			name: "配置正确",
+			services: `
+[services.prometheus]
+type = "prometheus"
+enabled = true
+[services.prometheus.options]
+address = "http://localhost:9090"
+`,
+		},
+		{
+			name: "options 缺少必填字段",
+			services: `
+[services.prometheus]
+type = "prometheus"
+enabled = true
+[services.prometheus.options]
+address = "not-a-url"
+
+[services.pagerduty]
+type = "pagerduty"
+enabled = true
+`,
+			wantErr: []string{"services.prometheus.options.address", "services.pagerduty.options.api_key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, err := NewApplication(createTempConfig(t, agents+tt.services))
+			require.NoError(t, err)
+
+			cfg, err := app.Validate()
+			if len(tt.wantErr) == 0 {
+				require.NoError(t, err)
+				assert.Contains(t, cfg.Services, "prometheus")
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+// TestApplication_Initialize_ModelProfiles 验证 [models.<name>] 引用与相同配置的模型实例共享
+func TestApplication_Initialize_ModelProfiles(t *testing.T) {
+	configContent := `
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = true
+[services.prometheus.options]
+address = "http://localhost:9090"
+
+[models.fast]
+provider = "openai"
+model = "gpt-4o-mini"
+api_key = "key-shared"
+
+[conversation]
+summary_model = "fast"
+
+[agents.orchestrator]
+enabled = true
+model = "fast"
+
+[agents.service_agent]
+enabled = true
+model = "fast"
+
+# 内联配置与 profile 完全相同时同样共享实例
+[agents.report_agent]
+enabled = true
+[agents.report_agent.llm]
+provider = "openai"
+model = "gpt-4o-mini"
+api_key = "key-shared"
+
+[agents.prediction_agent]
+enabled = true
+[agents.prediction_agent.llm]
+provider = "openai"
+model = "gpt-4o"
+api_key = "key-shared"
+`
+	app, err := NewApplication(createTempConfig(t, configContent))
+	require.NoError(t, err)
+	require.NoError(t, app.Initialize(context.Background()))
+
+	orchestrator, err := app.modelReg.Get("orchestrator")
+	require.NoError(t, err)
+	serviceAgent, err := app.modelReg.Get("service_agent")
+	require.NoError(t, err)
+	reportAgent, err := app.modelReg.Get("report_agent")
+	require.NoError(t, err)
+	predictionAgent, err := app.modelReg.Get("prediction_agent")
+	require.NoError(t, err)
+	summaryModel, err := app.modelReg.Get("models.fast")
+	require.NoError(t, err)
+
+	assert.Same(t, orchestrator, serviceAgent)
+	assert.Same(t, orchestrator, reportAgent)
+	assert.Same(t, orchestrator, summaryModel)
+	assert.NotSame(t, orchestrator, predictionAgent)
+	assert.Equal(t, "gpt-4o-mini", orchestrator.Name())
+
+	_, err = app.NewSession()
+	require.NoError(t, err)
+}
+
+func TestApplication_Initialize_CustomAgent(t *testing.T) {
+	configContent := `
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = true
+[services.prometheus.options]
+address = "http://localhost:9090"
+
+[models.fast]
+provider = "openai"
+model = "gpt-4o-mini"
+api_key = "key-shared"
+
+[agents.orchestrator]
+enabled = true
+model = "fast"
+
+# 只开启自定义 agent 也满足子 agent 要求
+[agents.capacity_agent]
+enabled = true
+model = "fast"
+description = "容量规划与扩容评估"
+instruction_file = "prompts/capacity.md"
+services = ["prometheus"]
+tools = ["Memory"]
+middleware = ["logging"]
+`
+	configPath := createTempConfig(t, configContent)
+	dir := filepath.Join(filepath.Dir(configPath), "prompts")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "capacity.md"), []byte("你是容量规划专家。"), 0o644))
+
+	app, err := NewApplication(configPath)
+	require.NoError(t, err)
+	require.NoError(t, app.Initialize(context.Background()))
+
+	assert.Equal(t, "你是容量规划专家。", app.agents["capacity_agent"].Instruction)
+	_, err = app.modelReg.Get("capacity_agent")
+	require.NoError(t, err)
+}
+
+func TestApplication_Initialize_Prompts(t *testing.T) {
+	baseConfig := `
+[server]
+addr = "localhost:8080"
+
+[services.prometheus]
+type = "prometheus"
+enabled = false
+
+[agents.orchestrator]
+enabled = true
+[agents.orchestrator.llm]
+provider = "openai"
+model = "gpt-4"
+api_key = "test-key"
+
+[agents.report_agent]
+enabled = true
+[agents.report_agent.llm]
+provider = "openai"
+model = "gpt-4"
+api_key = "test-key"
+`
+
+	t.Run("英文模板与覆盖目录", func(t *testing.T) {
+		configPath := createTempConfig(t, baseConfig+`
+[prompts]
+locale = "en"
+dir = "prompts"
+`)
+		dir := filepath.Join(filepath.Dir(configPath), "prompts", "en")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "report_agent.tmpl"), []byte("You write on-call reports."), 0o644))
+
+		app, err := NewApplication(configPath)
+		require.NoError(t, err)
+		require.NoError(t, app.Initialize(context.Background()))
+
+		sess, err := app.NewSession()
+		require.NoError(t, err)
+		if name := currentUser(); name != "" {
+			assert.Equal(t, name, sess.State()[session.StateKeyUser])
+		}
+	})
+
+	t.Run("覆盖目录不存在", func(t *testing.T) {
+		configPath := createTempConfig(t, baseConfig+`
+[prompts]
+dir = "missing"
+`)
+		app, err := NewApplication(configPath)
+		require.NoError(t, err)
+		err = app.Initialize(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "load prompts")
+	})
+
+	t.Run("不支持的语言", func(t *testing.T) {
+		configPath := createTempConfig(t, baseConfig+`
+[prompts]
+locale = "fr"
+`)
+		app, err := NewApplication(configPath)
+		require.NoError(t, err)
+		err = app.Initialize(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Prompts.Locale")
+	})
+}
+
+func TestApplication_RunPlaybook(t *testing.T) {
+	// 模拟 prometheus 即时查询：5xx 错误率 3%
+	prom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1760000000,"0.03"]}]}}`)
+	}))
+	defer prom.Close()
+
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "report.json")
+	require.NoError(t, os.WriteFile(fixture, []byte(`{"turns": [
+  {"match": {"instruction": "巡检报告撰写专家", "last_message": "执行巡检清单 daily-core"}, "text": "# 巡检报告\n\n5xx 错误率超过警告阈值。"}
+]}`), 0o644))
+
+	configPath := createTempConfig(t, fmt.Sprintf(`
+[server]
+addr = "localhost:8080"
+
+[services.prom]
+type = "prometheus"
+enabled = true
+[services.prom.options]
+address = %q
+
+[models.mock]
+provider = "mock"
+model = "mock"
+fixture = %q
+
+[agents.orchestrator]
+enabled = true
+model = "mock"
+
+[agents.report_agent]
+enabled = true
+model = "mock"
+`, prom.URL, fixture))
+	playbooks := filepath.Join(filepath.Dir(configPath), "playbooks")
+	require.NoError(t, os.MkdirAll(playbooks, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(playbooks, "daily-core.toml"), []byte(`
+[[checks]]
+name = "5xx 错误率"
+service = "prom"
+operation = "query_instant"
+warn = 0.01
+fail = 0.05
+[checks.params]
+promql = "error_ratio"
+`), 0o644))
+
+	app, err := NewApplication(configPath)
+	require.NoError(t, err)
+	require.NoError(t, app.Initialize(context.Background()))
+
+	msg, err := app.RunPlaybook(context.Background(), "daily-core")
+	require.NoError(t, err)
+	assert.Equal(t, "report_agent", msg.Author)
+	assert.Contains(t, msg.Text(), "5xx 错误率超过警告阈值")
+
+	t.Run("清单不存在", func(t *testing.T) {
+		_, err := app.RunPlaybook(context.Background(), "weekly")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "read playbook")
+	})
+}
+
+func TestApplication_Run_GeneralAgent(t *testing.T) {
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "general.json")
+	require.NoError(t, os.WriteFile(fixture, []byte(`{"turns": [
+  {"tool_calls": [{"name": "ReadFile", "arguments": {"path": "disk.md"}}]},
+  {"match": {"last_message": "磁盘使用率超过 90%"}, "text": "按 runbook：先清理 /var/log 下的旧日志。"}
+]}`), 0o644))
+
+	configPath := createTempConfig(t, fmt.Sprintf(`
+[server]
+addr = "localhost:8080"
+
+[[routing.rules]]
+name = "runbook"
+agent = "general_agent"
+command = "/runbook"
+
+[tools.files]
+dirs = ["runbooks"]
+
+[services.prometheus]
+type = "prometheus"
+enabled = false
+
+[models.mock]
+provider = "mock"
+model = "mock"
+fixture = %q
+
+[agents.orchestrator]
+enabled = true
+model = "mock"
+
+[agents.general_agent]
+enabled = true
+model = "mock"
+`, fixture))
+	runbooks := filepath.Join(filepath.Dir(configPath), "runbooks")
+	require.NoError(t, os.MkdirAll(runbooks, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(runbooks, "disk.md"), []byte("磁盘使用率超过 90%：清理 /var/log 下的旧日志"), 0o644))
+
+	app, err := NewApplication(configPath)
+	require.NoError(t, err)
+	require.NoError(t, app.Initialize(context.Background()))
+
+	msg, err := app.Run(context.Background(), blades.UserMessage("/runbook 磁盘满了怎么办"))
+	require.NoError(t, err)
+	assert.Equal(t, "general_agent", msg.Author)
+	assert.Contains(t, msg.Text(), "/var/log")
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-kratos/blades"
+
+	"github.com/oneblade/config"
+	"github.com/oneblade/internal/llm"
+)
+
+// stagedModels 是一次构建得到的模型，尚未注册到 ModelRegistry
+type stagedModels struct {
+	// models 名称（agent 或 models.<profile>）到模型的映射，可能包含复用的运行中实例
+	models map[string]blades.ModelProvider
+	// keys 每个名称对应的 llm.ModelKey
+	keys map[string]string
+	// built 本次新建的模型；回滚时只关闭这些，复用的实例仍在使用中
+	built map[string]blades.ModelProvider
+}
+
+// modelTargets 返回需要注册模型的名称及其 LLM 配置：
+// 所有 enabled agents，以及 conversation.summary_model 引用的模型配置。
+func modelTargets(cfg *config.Config, agents map[string]*config.AgentConfig) map[string]config.AgentLLMConfig {
+	targets := make(map[string]config.AgentLLMConfig, len(agents)+1)
+	for name, agentCfg := range agents {
+		targets[name] = agentCfg.LLM
+	}
+	if ref := cfg.Conversation.SummaryModel; ref != "" {
+		targets[llm.ProfileName(ref)] = cfg.Models[ref]
+	}
+	return targets
+}
+
+// summaryModelName 返回会话摘要使用的模型在 ModelRegistry 中的名称
+func summaryModelName(cfg *config.Config) string {
+	if ref := cfg.Conversation.SummaryModel; ref != "" {
+		return llm.ProfileName(ref)
+	}
+	return cfg.Conversation.SummaryModelAgent
+}
+
+// buildModels 为 targets 中指定名称的条目构建模型
+//
+// LLM 配置完全相同的条目共享同一个模型实例（同一个 HTTP client 与限流额度）；
+// 运行中的 ModelRegistry 已有相同配置的实例时直接复用，不再新建。
+// 任意一个模型构建失败时，本次新建的模型会被关闭并返回错误。
+func (a *Application) buildModels(ctx context.Context, targets map[string]config.AgentLLMConfig, names []string) (*stagedModels, error) {
+	staged := &stagedModels{
+		models: make(map[string]blades.ModelProvider, len(names)),
+		keys:   make(map[string]string, len(names)),
+		built:  make(map[string]blades.ModelProvider),
+	}
+	byKey := make(map[string]blades.ModelProvider)
+
+	for _, name := range names {
+		llmCfg, ok := targets[name]
+		if !ok {
+			continue
+		}
+		key := llm.ModelKey(llmCfg)
+
+		m, shared := byKey[key]
+		if !shared {
+			m, shared = a.modelReg.Lookup(key)
+		}
+		if !shared {
+			var err error
+			m, err = a.llmFactory.Build(ctx, llmCfg)
+			if err != nil {
+				closeStaged(nil, staged.built)
+				return nil, fmt.Errorf("build model for %s: %w", name, err)
+			}
+			staged.built[name] = m
+		}
+		byKey[key] = m
+		staged.models[name] = m
+		staged.keys[name] = key
+
+		slog.Info("app.models.register",
+			"name", name,
+			"provider", llmCfg.Provider,
+			"model", llmCfg.Model,
+			"fallbacks", len(llmCfg.Fallbacks),
+			"shared", shared,
+		)
+	}
+	return staged, nil
+}
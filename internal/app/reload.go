@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/go-kratos/blades"
+
+	"github.com/oneblade/agent"
+	"github.com/oneblade/config"
+	"github.com/oneblade/internal/llm"
+	"github.com/oneblade/internal/logger"
+	"github.com/oneblade/service"
+)
+
+// WatchConfig 监听配置文件变化（以及 SIGHUP）并热加载，阻塞直到 ctx 结束
+func (a *Application) WatchConfig(ctx context.Context) error {
+	return a.cfg.Watch(ctx, a.applyConfig)
+}
+
+// ReloadConfig 立即重新加载一次配置
+func (a *Application) ReloadConfig(ctx context.Context) error {
+	return a.cfg.Reload(ctx, a.applyConfig)
+}
+
+// applyConfig 将新配置应用到运行中的应用
+//
+// 流程分为两段：
+//  1. 暂存：构建变更的 services 与 models，并基于暂存结果重新构建 orchestrator；
+//     任一步失败都会关闭已构建的资源并返回错误，运行中的实例不受影响。
+//  2. 提交：原子替换 service.Registry、llm.ModelRegistry 与 runner。
+//
+// 会话由调用方持有，与 runner 无关，因此替换 runner 不会中断已有会话；
+// conversation 配置只对之后新建的会话生效。
+func (a *Application) applyConfig(ctx context.Context, next *config.Loader, diff config.Diff) error {
+	cfg, err := next.Get()
+	if err != nil {
+		return err
+	}
+	if err := a.validateRules(cfg); err != nil {
+		return fmt.Errorf("validate app rules: %w", err)
+	}
+	if err := service.ValidateOptions(next); err != nil {
+		return fmt.Errorf("validate service options: %w", err)
+	}
+
+	agents := enabledAgents(cfg)
+
+	// 1. 暂存 services
+	services, err := a.registry.Build(next, slices.Concat(diff.ServicesAdded, diff.ServicesChanged))
+	if err != nil {
+		return fmt.Errorf("build services: %w", err)
+	}
+
+	// 2. 暂存 models
+	// 只重建变更的 agents；model profile 的变更已展开到引用它的 agents 中。
+	// 摘要模型不属于任何 agent，每次都重新暂存，配置未变时会直接复用运行中的实例。
+	targets := modelTargets(cfg, agents)
+	names := slices.Concat(diff.AgentsAdded, diff.AgentsChanged)
+	if ref := cfg.Conversation.SummaryModel; ref != "" {
+		names = append(names, llm.ProfileName(ref))
+	}
+	staged, err := a.buildModels(ctx, targets, names)
+	if err != nil {
+		closeStaged(services, nil)
+		return err
+	}
+	stagedModels := a.modelReg.Clone()
+	for name, m := range staged.models {
+		stagedModels.RegisterKeyed(name, staged.keys[name], m)
+	}
+	var removedModels []string
+	for _, name := range a.modelReg.Names() {
+		if _, ok := targets[name]; !ok {
+			removedModels = append(removedModels, name)
+		}
+	}
+
+	// 3. 基于暂存结果构建 orchestrator
+	orchestrator, enabled, err := a.buildOrchestrator(cfg, stagedModels, a.registry.Merged(services, diff.ServicesRemoved), agents)
+	if err != nil {
+		closeStaged(services, staged.built)
+		return err
+	}
+
+	// 4. 提交
+	if err := a.registry.Swap(services, diff.ServicesRemoved); err != nil {
+		slog.Warn("app.reload.services.close_stale_failed", "error", err)
+	}
+	if err := a.modelReg.Swap(staged.models, staged.keys, removedModels); err != nil {
+		slog.Warn("app.reload.models.close_stale_failed", "error", err)
+	}
+
+	a.mu.Lock()
+	a.agents = agents
+	a.orchestrator = orchestrator
+	a.runner = agent.NewInspectionRunner(orchestrator)
+	a.mu.Unlock()
+
+	if diff.LogChanged {
+		logger.Initialize(cfg)
+	}
+
+	slog.Info("app.reload.complete",
+		"enabled_agents", enabled,
+		"services_swapped", len(services),
+		"models_swapped", len(staged.models),
+		"models_built", len(staged.built),
+	)
+	return nil
+}
+
+// closeStaged 关闭暂存但未提交的资源
+func closeStaged(services map[string]service.Service, models map[string]blades.ModelProvider) {
+	for name, s := range services {
+		if err := s.Close(); err != nil {
+			slog.Warn("app.reload.close_staged_service_failed", "service", name, "error", err)
+		}
+	}
+	for name, m := range models {
+		if closer, ok := m.(interface{ Close() error }); ok {
+			if err := closer.Close(); err != nil {
+				slog.Warn("app.reload.close_staged_model_failed", "agent", name, "error", err)
+			}
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package consts
 
 const (
-	AgentNameOrchestrator = "orchestrator"
-	AgentNameService      = "service_agent"
-	AgentNamePrediction   = "prediction_agent"
-	AgentNameReport       = "report_agent"
-	AgentNameAnalysis     = "analysis_agent"
-	AgentNameGeneral      = "general_agent"
+	AgentNameOrchestrator  = "orchestrator"
+	AgentNameService       = "service_agent"
+	AgentNamePrediction    = "prediction_agent"
+	AgentNameReport        = "report_agent"
+	AgentNameAnalysis      = "analysis_agent"
+	AgentNameCollection    = "collection_agent"
+	AgentNamePlaybook      = "playbook_agent"
+	AgentNameGeneral       = "general_agent"
+	AgentNameInvestigation = "investigation_agent"
+	AgentNamePostmortem    = "postmortem_agent"
 )
 
 // RequiredSubAgents 定义系统必需的子 Agent 列表
@@ -15,4 +19,29 @@ var RequiredSubAgents = []string{
 	AgentNameService,
 	AgentNamePrediction,
 	AgentNameReport,
+	AgentNameGeneral,
+	AgentNameInvestigation,
+	AgentNamePostmortem,
+}
+
+// ReservedAgents 由代码组装、不能在 [agents] 中配置的内置 Agent
+var ReservedAgents = []string{
+	AgentNameAnalysis,
+	AgentNameCollection,
+	AgentNamePlaybook,
+}
+
+// BuiltinAgents 定义由代码内置实现的 Agent 名称
+// 其余出现在 [agents] 中的名称均视为配置声明的自定义 Agent；analysis_agent、collection_agent 与 playbook_agent 为保留名称，见 ReservedAgents
+var BuiltinAgents = []string{
+	AgentNameOrchestrator,
+	AgentNameService,
+	AgentNamePrediction,
+	AgentNameReport,
+	AgentNameAnalysis,
+	AgentNameCollection,
+	AgentNamePlaybook,
+	AgentNameGeneral,
+	AgentNameInvestigation,
+	AgentNamePostmortem,
 }
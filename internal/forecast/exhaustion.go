@@ -0,0 +1,88 @@
+package forecast
+
+import "time"
+
+// Exhaustion 按线性趋势估计序列达到容量上限的时间
+type Exhaustion struct {
+	// Current 最后一个采样点时刻的拟合值
+	Current float64
+	// Capacity 容量上限；falling 时为下限，如剩余空间的 0
+	Capacity float64
+	// SlopePerHour 每小时的变化量
+	SlopePerHour float64
+	// Exhausted 当前已达到容量上限（falling 时为已降到下限）
+	Exhausted bool
+	// Growing 趋势是否朝 capacity 变化；否则 At / Earliest / Latest 为空
+	Growing bool
+	// At 预计达到上限的时间，十年内不会耗尽时为空
+	At *time.Time
+	// Earliest 与 Latest 为按斜率 95% 置信区间计算的最早与最晚时间；
+	// 斜率下限不增长时 Latest 为空，表示可能不会耗尽
+	Earliest *time.Time
+	Latest   *time.Time
+	// R2 线性拟合的决定系数
+	R2 float64
+}
+
+// TimeToExhaustion 对序列做线性回归，估计达到 capacity 的时间，适用于磁盘、内存等单调增长的资源
+// falling 为 true 时序列向下逼近 capacity，如剩余磁盘空间降到 0。
+func TimeToExhaustion(series []Point, capacity float64, falling bool) (*Exhaustion, error) {
+	if !falling {
+		return timeToExhaustion(series, capacity)
+	}
+	// 下降序列取反后按增长序列计算，时间不受影响
+	mirrored := make([]Point, len(series))
+	for i, p := range series {
+		mirrored[i] = Point{Time: p.Time, Value: -p.Value}
+	}
+	e, err := timeToExhaustion(mirrored, -capacity)
+	if err != nil {
+		return nil, err
+	}
+	e.Current, e.Capacity, e.SlopePerHour = -e.Current, capacity, -e.SlopePerHour
+	return e, nil
+}
+
+func timeToExhaustion(series []Point, capacity float64) (*Exhaustion, error) {
+	l, err := FitLinear(series)
+	if err != nil {
+		return nil, err
+	}
+	last := series[len(series)-1].Time
+	e := &Exhaustion{
+		Current:      l.Predict(last).Value,
+		Capacity:     capacity,
+		SlopePerHour: l.Slope,
+		R2:           l.R2,
+	}
+	if e.Current >= capacity {
+		e.Exhausted = true
+		e.At, e.Earliest = &last, &last
+		return e, nil
+	}
+	if l.Slope <= 0 {
+		return e, nil
+	}
+	e.Growing = true
+	e.At = eta(last, e.Current, capacity, l.Slope)
+
+	lower, upper := l.SlopeInterval()
+	e.Earliest = eta(last, e.Current, capacity, upper)
+	if lower > 0 {
+		e.Latest = eta(last, e.Current, capacity, lower)
+	}
+	return e, nil
+}
+
+// maxExhaustionHorizon 超过该时长的耗尽时间视为不会耗尽
+const maxExhaustionHorizon = 10 * 365 * 24 * time.Hour
+
+// eta 返回按 slope 增长到 capacity 的时间，超过 maxExhaustionHorizon 时返回 nil
+func eta(from time.Time, current, capacity, slope float64) *time.Time {
+	hours := (capacity - current) / slope
+	if hours > maxExhaustionHorizon.Hours() {
+		return nil
+	}
+	t := from.Add(time.Duration(hours * float64(time.Hour)))
+	return &t
+}
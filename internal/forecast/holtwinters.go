@@ -0,0 +1,133 @@
+package forecast
+
+import "math"
+
+// HoltWinters 加法 Holt-Winters 指数平滑模型；Period 为 0 时退化为 Holt 线性趋势模型
+type HoltWinters struct {
+	// Alpha / Beta / Gamma 为水平、趋势与季节分量的平滑系数，由网格搜索使一步预测误差平方和最小
+	Alpha float64
+	Beta  float64
+	Gamma float64
+	// Period 季节周期（采样点数）
+	Period int
+	// RMSE 一步预测的均方根误差
+	RMSE float64
+
+	level    float64
+	trend    float64
+	seasonal []float64
+	// n 拟合使用的采样点数
+	n int
+}
+
+// 平滑系数的搜索网格
+var (
+	smoothingGrid = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9}
+	trendGrid     = []float64{0.01, 0.05, 0.1, 0.2, 0.3, 0.5}
+)
+
+// FitHoltWinters 拟合等间隔序列；period >= 2 时使用季节模型，至少需要两个完整周期，否则至少需要 3 个采样点
+func FitHoltWinters(values []float64, period int) (*HoltWinters, error) {
+	if period < 2 {
+		period = 0
+	}
+	if len(values) < 3 || (period > 0 && len(values) < 2*period) {
+		return nil, ErrInsufficientData
+	}
+
+	gammas := []float64{0}
+	if period > 0 {
+		gammas = smoothingGrid
+	}
+	var best *HoltWinters
+	bestSSE := math.Inf(1)
+	for _, alpha := range smoothingGrid {
+		for _, beta := range trendGrid {
+			for _, gamma := range gammas {
+				m := &HoltWinters{Alpha: alpha, Beta: beta, Gamma: gamma, Period: period}
+				if sse := m.fit(values); sse < bestSSE {
+					best, bestSSE = m, sse
+				}
+			}
+		}
+	}
+	return best, nil
+}
+
+// fit 以给定系数平滑整个序列，返回一步预测误差平方和
+func (m *HoltWinters) fit(values []float64) float64 {
+	start := m.init(values)
+	var sse float64
+	for t := start; t < len(values); t++ {
+		y := values[t]
+		s := m.season(t)
+		err := y - (m.level + m.trend + s)
+		sse += err * err
+
+		level := m.Alpha*(y-s) + (1-m.Alpha)*(m.level+m.trend)
+		m.trend = m.Beta*(level-m.level) + (1-m.Beta)*m.trend
+		if m.Period > 0 {
+			m.seasonal[t%m.Period] = m.Gamma*(y-level) + (1-m.Gamma)*s
+		}
+		m.level = level
+	}
+	m.n = len(values)
+	m.RMSE = math.Sqrt(sse / float64(len(values)-start))
+	return sse
+}
+
+// init 初始化各分量，返回开始平滑的位置
+func (m *HoltWinters) init(values []float64) int {
+	if m.Period == 0 {
+		m.level = values[0]
+		m.trend = values[1] - values[0]
+		return 1
+	}
+	// 周期均值对应周期中点的水平，level 推到第一个周期末尾；
+	// 季节分量取各完整周期内去除趋势后偏离周期均值的平均值，避免只用第一个周期时混入噪声
+	p := m.Period
+	seasons := len(values) / p
+	first, second := mean(values[:p]), mean(values[p:2*p])
+	mid := float64(p-1) / 2
+	m.trend = (second - first) / float64(p)
+	m.level = first + m.trend*mid
+	m.seasonal = make([]float64, p)
+	for k := range seasons {
+		avg := mean(values[k*p : (k+1)*p])
+		for i := range p {
+			m.seasonal[i] += (values[k*p+i] - avg - m.trend*(float64(i)-mid)) / float64(seasons)
+		}
+	}
+	return p
+}
+
+func (m *HoltWinters) season(t int) float64 {
+	if m.Period == 0 {
+		return 0
+	}
+	return m.seasonal[t%m.Period]
+}
+
+// Forecast 返回之后第 h 个采样点（h >= 1）的预测值与 95% 预测区间，
+// 方差按加法 Holt-Winters 的解析公式 σ²(1 + Σ c_j²) 计算，c_j = α(1 + jβ) + γ·[j 为周期整数倍]
+func (m *HoltWinters) Forecast(h int) (value, lower, upper float64) {
+	value = m.level + float64(h)*m.trend + m.season(m.n+h-1)
+	variance := 1.0
+	for j := 1; j < h; j++ {
+		c := m.Alpha * (1 + float64(j)*m.Beta)
+		if m.Period > 0 && j%m.Period == 0 {
+			c += m.Gamma
+		}
+		variance += c * c
+	}
+	half := z95 * m.RMSE * math.Sqrt(variance)
+	return value, value - half, value + half
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
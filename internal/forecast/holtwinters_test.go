@@ -0,0 +1,47 @@
+package forecast
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFitHoltWinters_Seasonal(t *testing.T) {
+	values := seasonal(24*7, 24, 0.3, 10)
+	m, err := FitHoltWinters(values, 24)
+	require.NoError(t, err)
+	assert.Equal(t, 24, m.Period)
+
+	// 向后预测一天，与真实曲线的误差应远小于季节振幅
+	want := seasonal(24*8, 24, 0.3, 10)[24*7:]
+	for h := 1; h <= 24; h++ {
+		value, lower, upper := m.Forecast(h)
+		assert.InDelta(t, want[h-1], value, 2, "h=%d", h)
+		assert.LessOrEqual(t, lower, value)
+		assert.GreaterOrEqual(t, upper, value)
+	}
+	_, lower1, upper1 := m.Forecast(1)
+	_, lower24, upper24 := m.Forecast(24)
+	assert.Greater(t, upper24-lower24, upper1-lower1)
+}
+
+func TestFitHoltWinters_Trend(t *testing.T) {
+	values := make([]float64, 30)
+	for i := range values {
+		values[i] = 10 + 2*float64(i)
+	}
+	m, err := FitHoltWinters(values, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, m.Period)
+	value, _, _ := m.Forecast(10)
+	assert.InDelta(t, 10+2*39, value, 1e-6)
+}
+
+func TestFitHoltWinters_InsufficientData(t *testing.T) {
+	_, err := FitHoltWinters([]float64{1, 2}, 0)
+	assert.ErrorIs(t, err, ErrInsufficientData)
+
+	_, err = FitHoltWinters(seasonal(30, 24, 0, 1), 24)
+	assert.ErrorIs(t, err, ErrInsufficientData)
+}
@@ -0,0 +1,180 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// hourly 生成每小时一个点的序列
+func hourly(values ...float64) []Point {
+	out := make([]Point, len(values))
+	for i, v := range values {
+		out[i] = Point{Time: epoch.Add(time.Duration(i) * time.Hour), Value: v}
+	}
+	return out
+}
+
+// noisyLine 生成 y = intercept + slope*x 加上确定性扰动的序列
+func noisyLine(n int, intercept, slope, noise float64) []Point {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = intercept + slope*float64(i) + noise*math.Sin(float64(i)*1.7)
+	}
+	return hourly(values...)
+}
+
+func TestFitLinear(t *testing.T) {
+	l, err := FitLinear(hourly(10, 12, 14, 16, 18))
+	require.NoError(t, err)
+	assert.InDelta(t, 2, l.Slope, 1e-9)
+	assert.InDelta(t, 10, l.Intercept, 1e-9)
+	assert.InDelta(t, 1, l.R2, 1e-9)
+
+	// 完全拟合时预测区间退化为一个点
+	e := l.Predict(epoch.Add(10 * time.Hour))
+	assert.InDelta(t, 30, e.Value, 1e-9)
+	assert.InDelta(t, 30, e.Lower, 1e-9)
+	assert.InDelta(t, 30, e.Upper, 1e-9)
+}
+
+func TestFitLinear_Interval(t *testing.T) {
+	l, err := FitLinear(noisyLine(48, 100, 0.5, 2))
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, l.Slope, 0.05)
+	assert.Greater(t, l.R2, 0.9)
+
+	near := l.Predict(epoch.Add(48 * time.Hour))
+	far := l.Predict(epoch.Add(480 * time.Hour))
+	assert.Less(t, near.Lower, near.Value)
+	assert.Greater(t, near.Upper, near.Value)
+	// 离样本越远，区间越宽
+	assert.Greater(t, far.Upper-far.Lower, near.Upper-near.Lower)
+
+	lower, upper := l.SlopeInterval()
+	assert.Less(t, lower, l.Slope)
+	assert.Greater(t, upper, l.Slope)
+}
+
+func TestFitLinear_InsufficientData(t *testing.T) {
+	_, err := FitLinear(hourly(1, 2))
+	assert.ErrorIs(t, err, ErrInsufficientData)
+
+	_, err = FitLinear([]Point{{Time: epoch, Value: 1}, {Time: epoch, Value: 2}, {Time: epoch, Value: 3}})
+	assert.EqualError(t, err, "all data points share the same timestamp")
+}
+
+func TestTQuantile(t *testing.T) {
+	tests := []struct {
+		df   int
+		want float64
+	}{
+		{df: 1, want: 12.706},
+		{df: 4, want: 2.776},
+		{df: 5, want: 2.571},
+		{df: 11, want: 2.201},
+		{df: 10, want: 2.228},
+		{df: 30, want: 2.042},
+		{df: 1000, want: 1.962},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, tQuantile(tt.df), tt.want*0.01, "df=%d", tt.df)
+	}
+}
+
+func TestTimeToExhaustion(t *testing.T) {
+	tests := []struct {
+		name         string
+		series       []Point
+		capacity     float64
+		falling      bool
+		wantAt       time.Time
+		wantGrowing  bool
+		wantExhaust  bool
+		wantNoLatest bool
+	}{
+		{
+			name:        "linear growth",
+			series:      hourly(50, 51, 52, 53, 54),
+			capacity:    100,
+			wantAt:      epoch.Add(50 * time.Hour),
+			wantGrowing: true,
+		},
+		{
+			name:     "shrinking",
+			series:   hourly(54, 53, 52, 51, 50),
+			capacity: 100,
+		},
+		{
+			name:        "already exhausted",
+			series:      hourly(98, 99, 100, 101, 102),
+			capacity:    100,
+			wantAt:      epoch.Add(4 * time.Hour),
+			wantExhaust: true,
+		},
+		{
+			name:        "falling to zero",
+			series:      hourly(50, 49, 48, 47, 46),
+			falling:     true,
+			wantAt:      epoch.Add(50 * time.Hour),
+			wantGrowing: true,
+		},
+		{
+			name:     "falling series recovering",
+			series:   hourly(46, 47, 48, 49, 50),
+			falling:  true,
+			capacity: 10,
+		},
+		{
+			name:        "falling series already exhausted",
+			series:      hourly(2, 1, 0, -1, -2),
+			falling:     true,
+			wantAt:      epoch.Add(4 * time.Hour),
+			wantExhaust: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := TimeToExhaustion(tt.series, tt.capacity, tt.falling)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGrowing, e.Growing)
+			assert.Equal(t, tt.wantExhaust, e.Exhausted)
+			if tt.wantAt.IsZero() {
+				assert.Nil(t, e.At)
+				return
+			}
+			require.NotNil(t, e.At)
+			assert.WithinDuration(t, tt.wantAt, *e.At, time.Second)
+		})
+	}
+}
+
+func TestTimeToExhaustion_Interval(t *testing.T) {
+	e, err := TimeToExhaustion(noisyLine(48, 50, 0.5, 2), 100, false)
+	require.NoError(t, err)
+	require.True(t, e.Growing)
+	require.NotNil(t, e.At)
+	require.NotNil(t, e.Earliest)
+	require.NotNil(t, e.Latest)
+	assert.True(t, e.Earliest.Before(*e.At))
+	assert.True(t, e.Latest.After(*e.At))
+}
+
+func TestTimeToExhaustion_Falling(t *testing.T) {
+	e, err := TimeToExhaustion(noisyLine(48, 100, -0.5, 2), 0, true)
+	require.NoError(t, err)
+	require.True(t, e.Growing)
+	assert.InDelta(t, -0.5, e.SlopePerHour, 0.05)
+	assert.Equal(t, 0.0, e.Capacity)
+	assert.Greater(t, e.Current, 0.0)
+	require.NotNil(t, e.At)
+	require.NotNil(t, e.Earliest)
+	require.NotNil(t, e.Latest)
+	assert.True(t, e.Earliest.Before(*e.At))
+	assert.True(t, e.Latest.After(*e.At))
+}
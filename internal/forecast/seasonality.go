@@ -0,0 +1,76 @@
+package forecast
+
+// SeasonalityThreshold 自相关系数达到该值才认为存在周期
+const SeasonalityThreshold = 0.3
+
+// Seasonality 周期性检测结果
+type Seasonality struct {
+	// Period 周期长度（采样点数），没有周期时为 0
+	Period int
+	// Strength 该周期的自相关系数，-1 到 1
+	Strength float64
+}
+
+// DetectSeasonality 在去除线性趋势后计算自相关，返回 [minPeriod, len(values)/2] 内自相关最强的局部峰值；
+// 峰值低于 SeasonalityThreshold 时 Period 为 0
+func DetectSeasonality(values []float64, minPeriod int) Seasonality {
+	if minPeriod < 2 {
+		minPeriod = 2
+	}
+	n := len(values)
+	maxPeriod := n / 2
+	if maxPeriod < minPeriod+1 {
+		return Seasonality{}
+	}
+
+	residuals := detrend(values)
+	var variance float64
+	for _, r := range residuals {
+		variance += r * r
+	}
+	if variance == 0 {
+		return Seasonality{}
+	}
+	acf := make([]float64, maxPeriod+2)
+	for lag := 1; lag < len(acf) && lag < n; lag++ {
+		var sum float64
+		for i := lag; i < n; i++ {
+			sum += residuals[i] * residuals[i-lag]
+		}
+		acf[lag] = sum / variance
+	}
+
+	var best Seasonality
+	for lag := minPeriod; lag <= maxPeriod; lag++ {
+		if acf[lag] <= acf[lag-1] || acf[lag] < acf[lag+1] {
+			continue
+		}
+		if acf[lag] > best.Strength {
+			best = Seasonality{Period: lag, Strength: acf[lag]}
+		}
+	}
+	if best.Strength < SeasonalityThreshold {
+		return Seasonality{Strength: best.Strength}
+	}
+	return best
+}
+
+// detrend 返回减去最小二乘直线后的残差
+func detrend(values []float64) []float64 {
+	n := float64(len(values))
+	var sumX, sumY, sumXY, sumXX float64
+	for i, v := range values {
+		x := float64(i)
+		sumX += x
+		sumY += v
+		sumXY += x * v
+		sumXX += x * x
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	intercept := (sumY - slope*sumX) / n
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = v - (intercept + slope*float64(i))
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// seasonal 生成周期为 period、带线性趋势的序列
+func seasonal(n, period int, trend, amplitude float64) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = 100 + trend*float64(i) + amplitude*math.Sin(2*math.Pi*float64(i)/float64(period))
+	}
+	return values
+}
+
+func TestDetectSeasonality(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []float64
+		wantPeriod int
+	}{
+		{name: "daily cycle with trend", values: seasonal(24*7, 24, 0.3, 10), wantPeriod: 24},
+		{name: "short cycle", values: seasonal(60, 6, 0, 5), wantPeriod: 6},
+		{name: "pure trend", values: seasonal(100, 1, 1, 0), wantPeriod: 0},
+		{name: "too short", values: []float64{1, 2, 1}, wantPeriod: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectSeasonality(tt.values, 2)
+			assert.Equal(t, tt.wantPeriod, got.Period)
+			if tt.wantPeriod > 0 {
+				assert.GreaterOrEqual(t, got.Strength, SeasonalityThreshold)
+			}
+		})
+	}
+}
@@ -0,0 +1,571 @@
+package forecast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/blades/tools"
+)
+
+// 工具的默认参数与限制
+const (
+	DefaultLookback = 7 * 24 * time.Hour
+	DefaultHorizon  = 24 * time.Hour
+	// maxSamples 按 lookback 推算默认 step 时每条序列的目标采样点数
+	maxSamples = 500
+	// maxSeries 单次查询参与计算的最多序列数，其余只计数
+	maxSeries = 10
+	// maxForecastSteps Holt-Winters 最多向后预测的采样点数
+	maxForecastSteps = 2000
+	// forecastPoints Holt-Winters 返回的预测点数
+	forecastPoints = 12
+)
+
+// now 返回当前时间，测试中可替换
+var now = time.Now
+
+// Source 提供区间数据的 Prometheus service 工具，Name 为 service 名称
+type Source struct {
+	Name string
+	Tool tools.Tool
+}
+
+// RangeQuery 各预测工具共用的区间查询参数
+type RangeQuery struct {
+	Service  string `json:"service,omitempty" jsonschema:"Prometheus service name. Optional when only one Prometheus service is configured."`
+	PromQL   string `json:"promql" jsonschema:"PromQL expression returning the series to analyse."`
+	Lookback string `json:"lookback,omitempty" jsonschema:"How much history to fetch, e.g. 6h, 7d or 4w. Defaults to 7d."`
+	Step     string `json:"step,omitempty" jsonschema:"Sample interval, e.g. 5m or 1h. Defaults to lookback/500 and at least 1m."`
+}
+
+// QueryInfo 实际执行的区间查询
+type QueryInfo struct {
+	Service string `json:"service"`
+	PromQL  string `json:"promql"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Step    string `json:"step"`
+	// Series 查询返回的序列数，超出 maxSeries 的部分未参与计算
+	Series int `json:"series"`
+}
+
+// EstimateResult 某一时刻的预测值与 95% 区间
+type EstimateResult struct {
+	Time  string  `json:"time"`
+	Value float64 `json:"value"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// SeriesResult 单条序列的公共信息；Error 非空时该序列没有计算结果
+type SeriesResult struct {
+	Series string  `json:"series"`
+	Points int     `json:"points"`
+	Last   float64 `json:"last"`
+	Error  string  `json:"error,omitempty"`
+}
+
+type LinearRequest struct {
+	RangeQuery
+	Horizon string `json:"horizon,omitempty" jsonschema:"How far past now to forecast, e.g. 24h or 7d. Defaults to 24h."`
+}
+
+type LinearResult struct {
+	SeriesResult
+	SlopePerHour float64         `json:"slope_per_hour,omitempty"`
+	R2           float64         `json:"r2,omitempty"`
+	Forecast     *EstimateResult `json:"forecast,omitempty"`
+}
+
+type LinearResponse struct {
+	Query   QueryInfo      `json:"query"`
+	Results []LinearResult `json:"results"`
+}
+
+type HoltWintersRequest struct {
+	RangeQuery
+	Horizon string `json:"horizon,omitempty" jsonschema:"How far past now to forecast, e.g. 24h or 7d. Defaults to 24h."`
+	Period  string `json:"period,omitempty" jsonschema:"Seasonal period such as 1d or 1w. Detected automatically when empty; use 0 to disable seasonality."`
+}
+
+type HoltWintersResult struct {
+	SeriesResult
+	Period   string           `json:"period,omitempty"`
+	Alpha    float64          `json:"alpha,omitempty"`
+	Beta     float64          `json:"beta,omitempty"`
+	Gamma    float64          `json:"gamma,omitempty"`
+	RMSE     float64          `json:"rmse,omitempty"`
+	Forecast []EstimateResult `json:"forecast,omitempty"`
+}
+
+type HoltWintersResponse struct {
+	Query   QueryInfo           `json:"query"`
+	Results []HoltWintersResult `json:"results"`
+}
+
+type ExhaustionRequest struct {
+	RangeQuery
+	Capacity *float64 `json:"capacity" jsonschema:"Capacity limit in the unit of the series, e.g. 1 for a usage ratio, the disk size in bytes, or 0 for a falling series such as free bytes."`
+	Falling  bool     `json:"falling,omitempty" jsonschema:"Set to true when the series decreases toward capacity, e.g. node_filesystem_avail_bytes reaching 0."`
+}
+
+type ExhaustionResult struct {
+	SeriesResult
+	Current      float64 `json:"current"`
+	SlopePerHour float64 `json:"slope_per_hour"`
+	R2           float64 `json:"r2"`
+	Exhausted    bool    `json:"exhausted"`
+	Growing      bool    `json:"growing"`
+	// ETA 与 Earliest / Latest 为空时表示十年内不会耗尽
+	ETA      string `json:"eta,omitempty"`
+	Earliest string `json:"earliest,omitempty"`
+	Latest   string `json:"latest,omitempty"`
+	// HoursLeft 距离 ETA 的小时数
+	HoursLeft float64 `json:"hours_left,omitempty"`
+}
+
+type ExhaustionResponse struct {
+	Query   QueryInfo          `json:"query"`
+	Results []ExhaustionResult `json:"results"`
+}
+
+type SeasonalityRequest struct {
+	RangeQuery
+}
+
+type SeasonalityResult struct {
+	SeriesResult
+	Seasonal bool    `json:"seasonal"`
+	Period   string  `json:"period,omitempty"`
+	Strength float64 `json:"strength"`
+}
+
+type SeasonalityResponse struct {
+	Query   QueryInfo           `json:"query"`
+	Results []SeasonalityResult `json:"results"`
+}
+
+// NewTools 返回基于 Prometheus 区间数据的预测工具：ForecastLinear、ForecastHoltWinters、TimeToExhaustion 与 DetectSeasonality
+func NewTools(sources []Source) ([]tools.Tool, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("forecast tools require at least one prometheus service")
+	}
+	f := &fetcher{sources: sources}
+
+	linear, err := tools.NewFunc(
+		"ForecastLinear",
+		"Fit a least-squares linear trend to Prometheus range data and forecast each series at now+horizon with a 95% prediction interval.",
+		f.linear,
+	)
+	if err != nil {
+		return nil, err
+	}
+	holtWinters, err := tools.NewFunc(
+		"ForecastHoltWinters",
+		"Forecast Prometheus range data with additive Holt-Winters smoothing (seasonal when a period is detected or given) and return forecast points with 95% prediction intervals.",
+		f.holtWinters,
+	)
+	if err != nil {
+		return nil, err
+	}
+	exhaustion, err := tools.NewFunc(
+		"TimeToExhaustion",
+		"Estimate when a growing resource series (disk, memory, connections...) reaches a capacity limit, or a falling series (free bytes...) drops to it, with the earliest and latest time from the 95% interval of the growth rate.",
+		f.exhaustion,
+	)
+	if err != nil {
+		return nil, err
+	}
+	seasonality, err := tools.NewFunc(
+		"DetectSeasonality",
+		"Detect a repeating period (such as daily or weekly cycles) in Prometheus range data using autocorrelation of the detrended series.",
+		f.seasonality,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return []tools.Tool{linear, holtWinters, exhaustion, seasonality}, nil
+}
+
+// fetcher 通过 Prometheus service 工具获取区间数据
+type fetcher struct {
+	sources []Source
+}
+
+// series 一条带标签的序列
+type series struct {
+	labels string
+	points []Point
+}
+
+// window 一次区间查询的结果
+type window struct {
+	info   QueryInfo
+	end    time.Time
+	step   time.Duration
+	series []series
+}
+
+func (f *fetcher) linear(ctx context.Context, req LinearRequest) (LinearResponse, error) {
+	horizon, err := durationOr(req.Horizon, DefaultHorizon)
+	if err != nil {
+		return LinearResponse{}, fmt.Errorf("invalid horizon: %w", err)
+	}
+	w, err := f.fetch(ctx, req.RangeQuery)
+	if err != nil {
+		return LinearResponse{}, err
+	}
+	resp := LinearResponse{Query: w.info}
+	for _, s := range w.series {
+		r := LinearResult{SeriesResult: s.result()}
+		l, err := FitLinear(s.points)
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.SlopePerHour, r.R2 = round(l.Slope), round(l.R2)
+			r.Forecast = estimateResult(l.Predict(w.end.Add(horizon)))
+		}
+		resp.Results = append(resp.Results, r)
+	}
+	return resp, nil
+}
+
+func (f *fetcher) holtWinters(ctx context.Context, req HoltWintersRequest) (HoltWintersResponse, error) {
+	horizon, err := durationOr(req.Horizon, DefaultHorizon)
+	if err != nil {
+		return HoltWintersResponse{}, fmt.Errorf("invalid horizon: %w", err)
+	}
+	period := time.Duration(-1)
+	switch p := strings.TrimSpace(req.Period); p {
+	case "":
+	case "0":
+		period = 0
+	default:
+		if period, err = parseDuration(p); err != nil {
+			return HoltWintersResponse{}, fmt.Errorf("invalid period: %w", err)
+		}
+	}
+	w, err := f.fetch(ctx, req.RangeQuery)
+	if err != nil {
+		return HoltWintersResponse{}, err
+	}
+	if period > 0 && period < w.step {
+		return HoltWintersResponse{}, fmt.Errorf("invalid period: %s is shorter than step %s", period, w.step)
+	}
+
+	resp := HoltWintersResponse{Query: w.info}
+	for _, s := range w.series {
+		r := HoltWintersResult{SeriesResult: s.result()}
+		values := s.resample(w.step)
+		// 未指定周期时自动检测，检测不到则使用无季节的 Holt 模型
+		periodSteps := int(period / w.step)
+		if period < 0 {
+			periodSteps = DetectSeasonality(values, 2).Period
+		}
+		m, err := FitHoltWinters(values, periodSteps)
+		if err != nil {
+			r.Error = err.Error()
+			resp.Results = append(resp.Results, r)
+			continue
+		}
+		if m.Period > 0 {
+			r.Period = (time.Duration(m.Period) * w.step).String()
+		}
+		r.Alpha, r.Beta, r.Gamma, r.RMSE = m.Alpha, m.Beta, m.Gamma, round(m.RMSE)
+		// 与线性预测一致，预测到查询结束时刻 + horizon；采集延迟或序列中断时需要补上最后一个点之后的时间
+		last := s.points[len(s.points)-1].Time
+		steps := min(int(math.Ceil(float64(w.end.Sub(last)+horizon)/float64(w.step))), maxForecastSteps)
+		for _, h := range sampleSteps(steps, forecastPoints) {
+			value, lower, upper := m.Forecast(h)
+			r.Forecast = append(r.Forecast, *estimateResult(Estimate{
+				Time:  last.Add(time.Duration(h) * w.step),
+				Value: value,
+				Lower: lower,
+				Upper: upper,
+			}))
+		}
+		resp.Results = append(resp.Results, r)
+	}
+	return resp, nil
+}
+
+func (f *fetcher) exhaustion(ctx context.Context, req ExhaustionRequest) (ExhaustionResponse, error) {
+	if req.Capacity == nil {
+		return ExhaustionResponse{}, fmt.Errorf("capacity is required")
+	}
+	w, err := f.fetch(ctx, req.RangeQuery)
+	if err != nil {
+		return ExhaustionResponse{}, err
+	}
+	resp := ExhaustionResponse{Query: w.info}
+	for _, s := range w.series {
+		r := ExhaustionResult{SeriesResult: s.result()}
+		e, err := TimeToExhaustion(s.points, *req.Capacity, req.Falling)
+		if err != nil {
+			r.Error = err.Error()
+			resp.Results = append(resp.Results, r)
+			continue
+		}
+		r.Current, r.SlopePerHour, r.R2 = round(e.Current), round(e.SlopePerHour), round(e.R2)
+		r.Exhausted, r.Growing = e.Exhausted, e.Growing
+		r.ETA, r.Earliest, r.Latest = formatTime(e.At), formatTime(e.Earliest), formatTime(e.Latest)
+		if e.At != nil {
+			r.HoursLeft = round(e.At.Sub(now()).Hours())
+		}
+		resp.Results = append(resp.Results, r)
+	}
+	return resp, nil
+}
+
+func (f *fetcher) seasonality(ctx context.Context, req SeasonalityRequest) (SeasonalityResponse, error) {
+	w, err := f.fetch(ctx, req.RangeQuery)
+	if err != nil {
+		return SeasonalityResponse{}, err
+	}
+	resp := SeasonalityResponse{Query: w.info}
+	for _, s := range w.series {
+		d := DetectSeasonality(s.resample(w.step), 2)
+		r := SeasonalityResult{SeriesResult: s.result(), Seasonal: d.Period > 0, Strength: round(d.Strength)}
+		if d.Period > 0 {
+			r.Period = (time.Duration(d.Period) * w.step).String()
+		}
+		resp.Results = append(resp.Results, r)
+	}
+	return resp, nil
+}
+
+// fetch 调用 Prometheus service 的 query_range 并解析返回的矩阵
+func (f *fetcher) fetch(ctx context.Context, q RangeQuery) (*window, error) {
+	if strings.TrimSpace(q.PromQL) == "" {
+		return nil, fmt.Errorf("promql is required")
+	}
+	source, err := f.source(q.Service)
+	if err != nil {
+		return nil, err
+	}
+	lookback, err := durationOr(q.Lookback, DefaultLookback)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lookback: %w", err)
+	}
+	step := max((lookback / maxSamples).Truncate(time.Minute), time.Minute)
+	if q.Step != "" {
+		if step, err = parseDuration(q.Step); err != nil {
+			return nil, fmt.Errorf("invalid step: %w", err)
+		}
+	}
+	end := now().UTC()
+	start := end.Add(-lookback)
+
+	input, err := json.Marshal(map[string]any{
+		"operation": "query_range",
+		"query_range": map[string]string{
+			"promql":     q.PromQL,
+			"start_time": start.Format(time.RFC3339),
+			"end_time":   end.Format(time.RFC3339),
+			"step":       step.String(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	output, err := source.Tool.Handle(ctx, string(input))
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", source.Name, err)
+	}
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Data    []struct {
+			Metric map[string]string    `json:"metric"`
+			Values [][2]json.RawMessage `json:"values"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(output), &resp); err != nil {
+		return nil, fmt.Errorf("query %s: decode response: %w", source.Name, err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("query %s failed: %s", source.Name, resp.Message)
+	}
+
+	w := &window{
+		info: QueryInfo{
+			Service: source.Name,
+			PromQL:  q.PromQL,
+			Start:   start.Format(time.RFC3339),
+			End:     end.Format(time.RFC3339),
+			Step:    step.String(),
+			Series:  len(resp.Data),
+		},
+		end:  end,
+		step: step,
+	}
+	for _, d := range resp.Data[:min(len(resp.Data), maxSeries)] {
+		s := series{labels: formatLabels(d.Metric)}
+		for _, v := range d.Values {
+			p, ok := parseSample(v)
+			if ok {
+				s.points = append(s.points, p)
+			}
+		}
+		if len(s.points) > 0 {
+			w.series = append(w.series, s)
+		}
+	}
+	if len(w.series) == 0 {
+		return nil, fmt.Errorf("query %s returned no data for %s", source.Name, q.PromQL)
+	}
+	return w, nil
+}
+
+// source 返回请求的 service；只配置了一个 service 时可以省略名称
+func (f *fetcher) source(name string) (Source, error) {
+	name = strings.TrimSpace(name)
+	if name == "" && len(f.sources) == 1 {
+		return f.sources[0], nil
+	}
+	names := make([]string, 0, len(f.sources))
+	for _, s := range f.sources {
+		if s.Name == name {
+			return s, nil
+		}
+		names = append(names, s.Name)
+	}
+	if name == "" {
+		return Source{}, fmt.Errorf("service is required, one of: %s", strings.Join(names, ", "))
+	}
+	return Source{}, fmt.Errorf("unknown prometheus service %s, one of: %s", name, strings.Join(names, ", "))
+}
+
+// resample 将采样点按 step 对齐到等间隔网格，缺失的点按相邻两点线性插值
+// Prometheus 会跳过无数据的 step，直接使用原始值会让 Holt-Winters 与季节检测的周期错位。
+func (s series) resample(step time.Duration) []float64 {
+	first, last := s.points[0].Time, s.points[len(s.points)-1].Time
+	n := int(last.Sub(first)/step) + 1
+	out := make([]float64, n)
+	j := 0
+	for i := range out {
+		t := first.Add(time.Duration(i) * step)
+		for j < len(s.points)-1 && !s.points[j+1].Time.After(t) {
+			j++
+		}
+		p := s.points[j]
+		if j == len(s.points)-1 || p.Time.Equal(t) {
+			out[i] = p.Value
+			continue
+		}
+		next := s.points[j+1]
+		frac := float64(t.Sub(p.Time)) / float64(next.Time.Sub(p.Time))
+		out[i] = p.Value + frac*(next.Value-p.Value)
+	}
+	return out
+}
+
+func (s series) result() SeriesResult {
+	return SeriesResult{Series: s.labels, Points: len(s.points), Last: s.points[len(s.points)-1].Value}
+}
+
+// parseSample 解析 [<unix 秒>, "<值>"] 形式的采样点，跳过 NaN 与 Inf
+func parseSample(v [2]json.RawMessage) (Point, bool) {
+	var ts float64
+	var raw string
+	if json.Unmarshal(v[0], &ts) != nil || json.Unmarshal(v[1], &raw) != nil {
+		return Point{}, false
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return Point{}, false
+	}
+	sec, frac := math.Modf(ts)
+	return Point{Time: time.Unix(int64(sec), int64(frac*1e9)).UTC(), Value: value}, true
+}
+
+// formatLabels 将标签格式化为 name{k="v",...}，标签按名称排序
+func formatLabels(metric map[string]string) string {
+	var b strings.Builder
+	b.WriteString(metric["__name__"])
+	keys := slices.Sorted(maps.Keys(metric))
+	keys = slices.DeleteFunc(keys, func(k string) bool { return k == "__name__" })
+	b.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "%s=%q", k, metric[k])
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// sampleSteps 在 1..steps 中均匀取至多 n 个步数，总是包含最后一步
+func sampleSteps(steps, n int) []int {
+	if steps <= n {
+		out := make([]int, steps)
+		for i := range out {
+			out[i] = i + 1
+		}
+		return out
+	}
+	out := make([]int, n)
+	for i := range out {
+		out[i] = (i + 1) * steps / n
+	}
+	return out
+}
+
+func estimateResult(e Estimate) *EstimateResult {
+	return &EstimateResult{
+		Time:  e.Time.UTC().Format(time.RFC3339),
+		Value: round(e.Value),
+		Lower: round(e.Lower),
+		Upper: round(e.Upper),
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// round 保留 6 位有效数字，避免浮点噪声占用上下文
+func round(v float64) float64 {
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return v
+	}
+	r, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 6, 64), 64)
+	return r
+}
+
+func durationOr(s string, def time.Duration) (time.Duration, error) {
+	if strings.TrimSpace(s) == "" {
+		return def, nil
+	}
+	return parseDuration(s)
+}
+
+// parseDuration 在 time.ParseDuration 的基础上支持 d（天）与 w（周），只接受正数
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	d, err := time.ParseDuration(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, perr := strconv.ParseFloat(n, 64)
+			d, err = time.Duration(v*float64(unit)), perr
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,286 @@
+package forecast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// promTool 模拟 Prometheus service 工具：返回 values 组成的矩阵，采样时间从请求的 start_time 起每 step 一个点，并记录请求
+func promTool(t *testing.T, requests *[]string, values ...[]float64) tools.Tool {
+	return tools.NewTool("prom", "prometheus", tools.HandleFunc(func(ctx context.Context, input string) (string, error) {
+		*requests = append(*requests, input)
+		var req struct {
+			QueryRange struct {
+				StartTime string `json:"start_time"`
+				Step      string `json:"step"`
+			} `json:"query_range"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(input), &req))
+		start, err := time.Parse(time.RFC3339, req.QueryRange.StartTime)
+		require.NoError(t, err)
+		step, err := time.ParseDuration(req.QueryRange.Step)
+		require.NoError(t, err)
+
+		var data []string
+		for i, series := range values {
+			samples := make([]string, len(series))
+			for j, v := range series {
+				samples[j] = fmt.Sprintf(`[%d, "%g"]`, start.Add(time.Duration(j)*step).Unix(), v)
+			}
+			data = append(data, fmt.Sprintf(`{"metric": {"__name__": "disk_used_ratio", "mountpoint": "/data%d"}, "values": [%s]}`, i, strings.Join(samples, ",")))
+		}
+		return fmt.Sprintf(`{"operation": "query_range", "success": true, "data": [%s]}`, strings.Join(data, ",")), nil
+	}))
+}
+
+func newTestTools(t *testing.T, sources ...Source) map[string]tools.Tool {
+	t.Helper()
+	fixed := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	orig := now
+	now = func() time.Time { return fixed }
+	t.Cleanup(func() { now = orig })
+
+	list, err := NewTools(sources)
+	require.NoError(t, err)
+	out := make(map[string]tools.Tool)
+	for _, tool := range list {
+		out[tool.Name()] = tool
+	}
+	return out
+}
+
+func linearValues(n int, start, slope float64) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = start + slope*float64(i)
+	}
+	return values
+}
+
+func TestForecastLinear(t *testing.T) {
+	var requests []string
+	tools := newTestTools(t, Source{Name: "prom", Tool: promTool(t, &requests, linearValues(25, 0.5, 0.01))})
+
+	output, err := tools["ForecastLinear"].Handle(context.Background(), `{"promql": "disk_used_ratio", "lookback": "1d", "step": "1h", "horizon": "10h"}`)
+	require.NoError(t, err)
+	var resp LinearResponse
+	require.NoError(t, json.Unmarshal([]byte(output), &resp))
+
+	assert.Equal(t, QueryInfo{
+		Service: "prom",
+		PromQL:  "disk_used_ratio",
+		Start:   "2026-10-17T00:00:00Z",
+		End:     "2026-10-18T00:00:00Z",
+		Step:    "1h0m0s",
+		Series:  1,
+	}, resp.Query)
+	require.Len(t, resp.Results, 1)
+	r := resp.Results[0]
+	assert.Equal(t, `disk_used_ratio{mountpoint="/data0"}`, r.Series)
+	assert.Equal(t, 25, r.Points)
+	assert.InDelta(t, 0.01, r.SlopePerHour, 1e-9)
+	require.NotNil(t, r.Forecast)
+	assert.Equal(t, "2026-10-18T10:00:00Z", r.Forecast.Time)
+	assert.InDelta(t, 0.84, r.Forecast.Value, 1e-6)
+}
+
+func TestForecastHoltWinters(t *testing.T) {
+	var requests []string
+	tools := newTestTools(t, Source{Name: "prom", Tool: promTool(t, &requests, seasonal(24*7+1, 24, 0.3, 10))})
+
+	output, err := tools["ForecastHoltWinters"].Handle(context.Background(), `{"promql": "qps", "step": "1h", "horizon": "48h"}`)
+	require.NoError(t, err)
+	var resp HoltWintersResponse
+	require.NoError(t, json.Unmarshal([]byte(output), &resp))
+
+	require.Len(t, resp.Results, 1)
+	r := resp.Results[0]
+	assert.Empty(t, r.Error)
+	assert.Equal(t, "24h0m0s", r.Period, "未指定周期时自动检测")
+	require.Len(t, r.Forecast, forecastPoints)
+	assert.Equal(t, "2026-10-20T00:00:00Z", r.Forecast[len(r.Forecast)-1].Time)
+	for _, e := range r.Forecast {
+		assert.LessOrEqual(t, e.Lower, e.Value)
+		assert.GreaterOrEqual(t, e.Upper, e.Value)
+	}
+
+	output, err = tools["ForecastHoltWinters"].Handle(context.Background(), `{"promql": "qps", "step": "1h", "period": "0"}`)
+	require.NoError(t, err)
+	var holt HoltWintersResponse
+	require.NoError(t, json.Unmarshal([]byte(output), &holt))
+	require.Len(t, holt.Results, 1)
+	assert.Empty(t, holt.Results[0].Period, "period 为 0 时不使用季节模型")
+}
+
+// TestForecastHoltWinters_StaleSeries 验证序列在查询结束前中断时，预测仍以查询结束时刻为起点
+func TestForecastHoltWinters_StaleSeries(t *testing.T) {
+	var requests []string
+	// 最后一个点比查询结束时刻早 6 小时
+	tools := newTestTools(t, Source{Name: "prom", Tool: promTool(t, &requests, seasonal(24*7+1-6, 24, 0.3, 10))})
+
+	output, err := tools["ForecastHoltWinters"].Handle(context.Background(), `{"promql": "qps", "step": "1h", "horizon": "48h"}`)
+	require.NoError(t, err)
+	var resp HoltWintersResponse
+	require.NoError(t, json.Unmarshal([]byte(output), &resp))
+	require.Len(t, resp.Results, 1)
+	require.NotEmpty(t, resp.Results[0].Forecast)
+	assert.Equal(t, "2026-10-20T00:00:00Z", resp.Results[0].Forecast[len(resp.Results[0].Forecast)-1].Time)
+
+	_, err = tools["ForecastHoltWinters"].Handle(context.Background(), `{"promql": "qps", "step": "1h", "period": "30m"}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid period: 30m0s is shorter than step 1h0m0s")
+}
+
+func TestTimeToExhaustionTool(t *testing.T) {
+	var requests []string
+	tools := newTestTools(t, Source{Name: "prom", Tool: promTool(t, &requests,
+		linearValues(25, 0.5, 0.01),
+		linearValues(25, 0.5, -0.01),
+	)})
+
+	output, err := tools["TimeToExhaustion"].Handle(context.Background(), `{"promql": "disk_used_ratio", "lookback": "1d", "step": "1h", "capacity": 1}`)
+	require.NoError(t, err)
+	var resp ExhaustionResponse
+	require.NoError(t, json.Unmarshal([]byte(output), &resp))
+
+	require.Len(t, resp.Results, 2)
+	growing := resp.Results[0]
+	assert.True(t, growing.Growing)
+	assert.InDelta(t, 0.74, growing.Current, 1e-6)
+	// 0.74 → 1 需要 26 小时
+	assert.Equal(t, "2026-10-19T02:00:00Z", growing.ETA)
+	assert.InDelta(t, 26, growing.HoursLeft, 1e-6)
+
+	shrinking := resp.Results[1]
+	assert.False(t, shrinking.Growing)
+	assert.Empty(t, shrinking.ETA)
+
+	// 剩余空间等下降序列以 0 为下限
+	output, err = tools["TimeToExhaustion"].Handle(context.Background(), `{"promql": "disk_free_ratio", "lookback": "1d", "step": "1h", "capacity": 0, "falling": true}`)
+	require.NoError(t, err)
+	var falling ExhaustionResponse
+	require.NoError(t, json.Unmarshal([]byte(output), &falling))
+	require.Len(t, falling.Results, 2)
+	assert.False(t, falling.Results[0].Growing)
+	dropping := falling.Results[1]
+	assert.True(t, dropping.Growing)
+	assert.InDelta(t, 0.26, dropping.Current, 1e-6)
+	assert.InDelta(t, -0.01, dropping.SlopePerHour, 1e-6)
+	// 0.26 → 0 需要 26 小时
+	assert.InDelta(t, 26, dropping.HoursLeft, 1e-3)
+
+	_, err = tools["TimeToExhaustion"].Handle(context.Background(), `{"promql": "disk_used_ratio"}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "capacity is required")
+}
+
+func TestSeriesResample(t *testing.T) {
+	// 02:00 与 03:00 缺失
+	s := series{points: hourly(0, 1, 4, 5)}
+	s.points[2].Time = epoch.Add(4 * time.Hour)
+	s.points[3].Time = epoch.Add(5 * time.Hour)
+	assert.Equal(t, []float64{0, 1, 2, 3, 4, 5}, s.resample(time.Hour))
+}
+
+func TestDetectSeasonalityTool(t *testing.T) {
+	var requests []string
+	tools := newTestTools(t, Source{Name: "prom", Tool: promTool(t, &requests, seasonal(24*7, 24, 0, 10))})
+
+	output, err := tools["DetectSeasonality"].Handle(context.Background(), `{"promql": "qps", "lookback": "1w", "step": "1h"}`)
+	require.NoError(t, err)
+	var resp SeasonalityResponse
+	require.NoError(t, json.Unmarshal([]byte(output), &resp))
+	require.Len(t, resp.Results, 1)
+	assert.True(t, resp.Results[0].Seasonal)
+	assert.Equal(t, "24h0m0s", resp.Results[0].Period)
+}
+
+func TestForecastTools_Errors(t *testing.T) {
+	var requests []string
+	failing := tools.NewTool("prom", "prometheus", tools.HandleFunc(func(ctx context.Context, input string) (string, error) {
+		return `{"success": false, "message": "bad_data: parse error"}`, nil
+	}))
+	empty := tools.NewTool("prom", "prometheus", tools.HandleFunc(func(ctx context.Context, input string) (string, error) {
+		return `{"success": true, "data": []}`, nil
+	}))
+
+	tests := []struct {
+		name    string
+		sources []Source
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "service required",
+			sources: []Source{{Name: "core", Tool: promTool(t, &requests)}, {Name: "edge", Tool: promTool(t, &requests)}},
+			input:   `{"promql": "up"}`,
+			wantErr: "service is required, one of: core, edge",
+		},
+		{
+			name:    "unknown service",
+			sources: []Source{{Name: "core", Tool: promTool(t, &requests)}},
+			input:   `{"service": "edge", "promql": "up"}`,
+			wantErr: "unknown prometheus service edge, one of: core",
+		},
+		{
+			name:    "invalid lookback",
+			sources: []Source{{Name: "core", Tool: promTool(t, &requests)}},
+			input:   `{"promql": "up", "lookback": "-1d"}`,
+			wantErr: `invalid lookback: duration "-1d" must be positive`,
+		},
+		{
+			name:    "query failed",
+			sources: []Source{{Name: "core", Tool: failing}},
+			input:   `{"promql": "up("}`,
+			wantErr: "query core failed: bad_data: parse error",
+		},
+		{
+			name:    "no data",
+			sources: []Source{{Name: "core", Tool: empty}},
+			input:   `{"promql": "up"}`,
+			wantErr: "query core returned no data for up",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools := newTestTools(t, tt.sources...)
+			_, err := tools["ForecastLinear"].Handle(context.Background(), tt.input)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "90m", want: 90 * time.Minute},
+		{in: "7d", want: 7 * 24 * time.Hour},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: "2w", want: 14 * 24 * time.Hour},
+		{in: "0", wantErr: true},
+		{in: "xd", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDuration(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
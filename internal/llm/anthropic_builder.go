@@ -3,6 +3,8 @@ package llm
 import (
 	"context"
 
+	sdk "github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
 	"github.com/go-kratos/blades"
 	"github.com/go-kratos/blades/contrib/anthropic"
 	"github.com/oneblade/config"
@@ -36,16 +38,33 @@ func (b *anthropicBuilder) Build(ctx context.Context, cfg *config.AgentLLMConfig
 		return nil, err
 	}
 
-	baseURL := b.GetBaseURL(cfg)
+	opts := anthropicConfig(cfg)
+	opts.APIKey = apiKey
+	opts.BaseURL = b.GetBaseURL(cfg)
 
-	opts := anthropic.Config{
+	return anthropic.NewModel(b.GetModel(cfg), opts), nil
+}
 
-		APIKey:  apiKey,
-		BaseURL: baseURL,
+// anthropicConfig 将采样参数写入 anthropic.Config，anthropic / bedrock 共用
+func anthropicConfig(cfg *config.AgentLLMConfig) anthropic.Config {
+	opts := anthropic.Config{
+		MaxOutputTokens: int64(*cfg.MaxTokens),
+		StopSequences:   cfg.StopSequences,
+		// 重试统一由 resilientProvider 负责；SDK 默认的 2 次重试会与其叠加并占用单次调用的超时
+		RequestOptions: []option.RequestOption{option.WithMaxRetries(0)},
 	}
-
-	opts.MaxOutputTokens = int64(*cfg.MaxTokens)
-	opts.Temperature = *cfg.Temperature
-
-	return anthropic.NewModel(b.GetModel(cfg), opts), nil
+	if cfg.TopP != nil {
+		opts.TopP = *cfg.TopP
+	}
+	if anthropicThinking(cfg) {
+		// 扩展思考要求 temperature 与 top_k 保持默认值，此时不发送默认 temperature
+		thinking := sdk.ThinkingConfigParamOfEnabled(int64(*cfg.ThinkingBudget))
+		opts.Thinking = &thinking
+	} else {
+		opts.Temperature = *cfg.Temperature
+		if cfg.TopK != nil {
+			opts.TopK = int64(*cfg.TopK)
+		}
+	}
+	return opts
 }
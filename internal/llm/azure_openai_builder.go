@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/contrib/openai"
+	"github.com/openai/openai-go/v3/option"
+
+	"github.com/oneblade/config"
+)
+
+// azureOpenAIBuilder 构建 Azure OpenAI 部署的模型
+//
+// Azure 以部署名而非模型名路由请求：
+// {base_url}/openai/deployments/{deployment}/chat/completions?api-version={api_version}
+// 鉴权使用 api-key 请求头，不发送 OPENAI_API_KEY 等公共端点凭据。
+type azureOpenAIBuilder struct {
+	endpoint   string
+	apiKey     string
+	apiVersion string
+}
+
+func newAzureOpenAIBuilder() ModelBuilder {
+	return &azureOpenAIBuilder{
+		endpoint:   "AZURE_OPENAI_ENDPOINT",
+		apiKey:     "AZURE_OPENAI_API_KEY",
+		apiVersion: "2024-10-21",
+	}
+}
+
+func (b *azureOpenAIBuilder) GetModel(cfg *config.AgentLLMConfig) string {
+	return cfg.Model
+}
+
+func (b *azureOpenAIBuilder) GetBaseURL(cfg *config.AgentLLMConfig) string {
+	return resolveBaseURL(cfg, strings.TrimSpace(os.Getenv(b.endpoint)))
+}
+
+func (b *azureOpenAIBuilder) Build(ctx context.Context, cfg *config.AgentLLMConfig) (blades.ModelProvider, error) {
+	endpoint := b.GetBaseURL(cfg)
+	if endpoint == "" {
+		return nil, fmt.Errorf("azure_openai provider requires base_url (base_url or %s)", b.endpoint)
+	}
+	apiKey, err := resolveAPIKey(cfg, b.apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment := cfg.Deployment
+	if deployment == "" {
+		deployment = cfg.Model
+	}
+	apiVersion := cfg.APIVersion
+	if apiVersion == "" {
+		apiVersion = b.apiVersion
+	}
+
+	opts := openai.Config{
+		RequestOptions: []option.RequestOption{
+			option.WithBaseURL(strings.TrimSuffix(endpoint, "/") + "/openai/deployments/" + url.PathEscape(deployment) + "/"),
+			option.WithQueryAdd("api-version", apiVersion),
+			option.WithHeader("api-key", apiKey),
+			// openai-go 默认读取 OPENAI_* 环境变量，这里清除对应的请求头
+			option.WithHeaderDel("authorization"),
+			option.WithHeaderDel("openai-organization"),
+			option.WithHeaderDel("openai-project"),
+		},
+	}
+	applyOpenAITuning(&opts, cfg)
+
+	return openai.NewModel(b.GetModel(cfg), opts), nil
+}
@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go/bedrock"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/contrib/anthropic"
+
+	"github.com/oneblade/config"
+)
+
+// bedrockBuilder 构建 AWS Bedrock 上的 Anthropic 模型
+//
+// model 为 Bedrock 模型 ID（如 anthropic.claude-3-5-sonnet-20241022-v2:0）。
+// 请求使用 AWS 默认凭据链（环境变量、~/.aws、实例角色等）进行 SigV4 签名，不使用 API Key。
+type bedrockBuilder struct {
+	model string
+}
+
+func newBedrockBuilder() ModelBuilder {
+	return &bedrockBuilder{
+		model: "anthropic.claude-3-5-sonnet-20241022-v2:0",
+	}
+}
+
+func (b *bedrockBuilder) GetModel(cfg *config.AgentLLMConfig) string {
+	return resolveModel(cfg, b.model)
+}
+
+func (b *bedrockBuilder) GetBaseURL(cfg *config.AgentLLMConfig) string {
+	if cfg.BaseURL != "" {
+		return cfg.BaseURL
+	}
+	if cfg.Region == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", cfg.Region)
+}
+
+func (b *bedrockBuilder) Build(ctx context.Context, cfg *config.AgentLLMConfig) (blades.ModelProvider, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if cfg.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(cfg.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("bedrock provider requires region (region or AWS_REGION)")
+	}
+
+	opts := anthropicConfig(cfg)
+	opts.RequestOptions = append(opts.RequestOptions,
+		bedrock.WithConfig(awsCfg),
+		// anthropic-sdk-go 默认读取 ANTHROPIC_API_KEY 等环境变量，不能把这些凭据发送给 AWS
+		option.WithHeaderDel("x-api-key"),
+		option.WithHeaderDel("authorization"),
+	)
+	// base_url 可指向 VPC 终端节点等自定义地址，需在 bedrock.WithConfig 之后覆盖
+	if cfg.BaseURL != "" {
+		opts.RequestOptions = append(opts.RequestOptions, option.WithBaseURL(cfg.BaseURL))
+	}
+
+	return anthropic.NewModel(b.GetModel(cfg), opts), nil
+}
@@ -26,25 +26,31 @@ func resolveModel(cfg *config.AgentLLMConfig, model string) string {
 
 // getAPIKey 通用 API Key 获取逻辑
 func resolveAPIKey(cfg *config.AgentLLMConfig, apiKey string) (string, error) {
-	apiKeyVal := strings.TrimSpace(cfg.APIKey)
-	if apiKeyVal == "" {
-		for _, k := range strings.Split(apiKey, ",") {
-			k = strings.TrimSpace(k)
-			if k == "" {
-				continue
-			}
-			apiKeyVal = strings.TrimSpace(os.Getenv(k))
-			if apiKeyVal != "" {
-				break
-			}
-		}
-	}
+	apiKeyVal := resolveOptionalAPIKey(cfg, apiKey)
 	if apiKeyVal == "" {
 		return "", fmt.Errorf("%s api key not configured (api_key or %s)", cfg.Model, apiKey)
 	}
 	return apiKeyVal, nil
 }
 
+// resolveOptionalAPIKey 依次从配置与逗号分隔的环境变量中获取 API Key，均未配置时返回空字符串
+func resolveOptionalAPIKey(cfg *config.AgentLLMConfig, apiKey string) string {
+	apiKeyVal := strings.TrimSpace(cfg.APIKey)
+	if apiKeyVal != "" {
+		return apiKeyVal
+	}
+	for _, k := range strings.Split(apiKey, ",") {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		if v := strings.TrimSpace(os.Getenv(k)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 // setBaseURL 设置默认 BaseURL
 func resolveBaseURL(cfg *config.AgentLLMConfig, defaultURL string) string {
 	if cfg.BaseURL == "" || strings.TrimSpace(cfg.BaseURL) == "" {
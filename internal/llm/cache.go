@@ -0,0 +1,324 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/blades"
+
+	"github.com/oneblade/config"
+)
+
+// MetadataCacheHit marks responses served from the response cache.
+const MetadataCacheHit = "cache_hit"
+
+// cacheEntry is a cached model response. Responses are stored as fixture
+// turns, the same JSON-friendly form used by recordings.
+type cacheEntry struct {
+	Model    string      `json:"model"`
+	Expires  time.Time   `json:"expires"`
+	Response FixtureTurn `json:"response"`
+}
+
+// cacheStore is a response cache backend.
+type cacheStore interface {
+	get(key string) (cacheEntry, bool, error)
+	set(key string, entry cacheEntry) error
+}
+
+// defaultMemoryCacheEntries bounds the memory cache of a long-running process.
+const defaultMemoryCacheEntries = 1000
+
+// memoryCacheStore keeps up to maxEntries entries in process memory, evicting
+// the least recently used one when full. Expired entries are dropped when
+// they are looked up.
+type memoryCacheStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	// lru holds *memoryCacheItem, most recently used first.
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry cacheEntry
+}
+
+func newMemoryCacheStore(maxEntries int) *memoryCacheStore {
+	return &memoryCacheStore{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (s *memoryCacheStore) get(key string) (cacheEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return cacheEntry{}, false, nil
+	}
+	item := el.Value.(*memoryCacheItem)
+	if time.Now().After(item.entry.Expires) {
+		s.lru.Remove(el)
+		delete(s.entries, key)
+		return cacheEntry{}, false, nil
+	}
+	s.lru.MoveToFront(el)
+	return item.entry, true, nil
+}
+
+func (s *memoryCacheStore) set(key string, entry cacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		el.Value.(*memoryCacheItem).entry = entry
+		s.lru.MoveToFront(el)
+		return nil
+	}
+	s.entries[key] = s.lru.PushFront(&memoryCacheItem{key: key, entry: entry})
+	for s.lru.Len() > s.maxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
+
+// len returns the number of stored entries.
+func (s *memoryCacheStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// fileCacheStore keeps one JSON file per entry under dir, so the cache
+// survives restarts and can be shared by processes using the same dir.
+type fileCacheStore struct {
+	dir string
+}
+
+func (s *fileCacheStore) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key+".json")
+}
+
+func (s *fileCacheStore) get(key string) (cacheEntry, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return cacheEntry{}, false, nil
+	}
+	if err != nil {
+		return cacheEntry{}, false, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cacheEntry{}, false, fmt.Errorf("decode cache entry %s: %w", key, err)
+	}
+	return entry, true, nil
+}
+
+func (s *fileCacheStore) set(key string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// Write to a temp file and rename so readers never see a partial entry.
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// defaultCacheDir returns the file cache location used when cache.dir is empty.
+func defaultCacheDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "oneblade", "llm")
+}
+
+// cachingProvider serves repeated requests from a cache. Errors are never
+// cached, and hits do not count against rate limits or token usage.
+type cachingProvider struct {
+	next  blades.ModelProvider
+	store cacheStore
+	ttl   time.Duration
+	// scope identifies the model and generation settings in cache keys.
+	scope string
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Name implements blades.ModelProvider.
+func (p *cachingProvider) Name() string {
+	return p.next.Name()
+}
+
+// Generate implements blades.ModelProvider.
+func (p *cachingProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	key := p.key(req)
+	if resp, ok := p.lookup(key); ok {
+		return resp, nil
+	}
+	resp, err := p.next.Generate(ctx, req)
+	if err == nil {
+		p.save(key, resp)
+	}
+	return resp, err
+}
+
+// NewStreaming implements blades.ModelProvider. A hit is yielded as a single
+// complete message; on a miss the final chunk is cached.
+func (p *cachingProvider) NewStreaming(ctx context.Context, req *blades.ModelRequest) blades.Generator[*blades.ModelResponse, error] {
+	return func(yield func(*blades.ModelResponse, error) bool) {
+		key := p.key(req)
+		if resp, ok := p.lookup(key); ok {
+			yield(resp, nil)
+			return
+		}
+		var last *blades.ModelResponse
+		for resp, err := range p.next.NewStreaming(ctx, req) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			last = resp
+			if !yield(resp, nil) {
+				return
+			}
+		}
+		if last != nil {
+			p.save(key, last)
+		}
+	}
+}
+
+// Close logs the final counters and closes the wrapped provider.
+func (p *cachingProvider) Close() error {
+	slog.Info("llm.cache.stats",
+		"model", p.next.Name(),
+		"hits", p.hits.Load(),
+		"misses", p.misses.Load(),
+	)
+	if closer, ok := p.next.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (p *cachingProvider) lookup(key string) (*blades.ModelResponse, bool) {
+	entry, ok, err := p.store.get(key)
+	if err != nil {
+		slog.Warn("llm.cache.read_failed", "model", p.next.Name(), "error", err)
+	}
+	if !ok || err != nil || time.Now().After(entry.Expires) {
+		misses := p.misses.Add(1)
+		slog.Debug("llm.cache.miss",
+			"model", p.next.Name(),
+			"hits", p.hits.Load(),
+			"misses", misses,
+		)
+		return nil, false
+	}
+
+	hits := p.hits.Add(1)
+	slog.Info("llm.cache.hit",
+		"model", p.next.Name(),
+		"hits", hits,
+		"misses", p.misses.Load(),
+	)
+	msg := entry.Response.message()
+	msg.Metadata = map[string]any{MetadataCacheHit: true}
+	return &blades.ModelResponse{Message: msg}, true
+}
+
+func (p *cachingProvider) save(key string, resp *blades.ModelResponse) {
+	if resp == nil || resp.Message == nil {
+		return
+	}
+	entry := cacheEntry{
+		Model:    p.next.Name(),
+		Expires:  time.Now().Add(p.ttl),
+		Response: recordTurn(resp, nil),
+	}
+	if err := p.store.set(key, entry); err != nil {
+		slog.Warn("llm.cache.write_failed", "model", p.next.Name(), "error", err)
+	}
+}
+
+// key hashes the model scope, instruction, messages and tool schemas of req.
+func (p *cachingProvider) key(req *blades.ModelRequest) string {
+	type toolSchema struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Input       any    `json:"input,omitempty"`
+		Output      any    `json:"output,omitempty"`
+	}
+	toolSchemas := make([]toolSchema, 0, len(req.Tools))
+	for _, t := range req.Tools {
+		toolSchemas = append(toolSchemas, toolSchema{
+			Name:        t.Name(),
+			Description: t.Description(),
+			Input:       t.InputSchema(),
+			Output:      t.OutputSchema(),
+		})
+	}
+
+	data, _ := json.Marshal(struct {
+		Scope        string          `json:"scope"`
+		Request      RecordedRequest `json:"request"`
+		Tools        []toolSchema    `json:"tools"`
+		OutputSchema any             `json:"output_schema,omitempty"`
+	}{
+		Scope:        p.scope,
+		Request:      recordRequest(req),
+		Tools:        toolSchemas,
+		OutputSchema: req.OutputSchema,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheScope identifies everything in cfg that changes the model output:
+// provider, model, base URL and generation settings.
+func cacheScope(cfg *config.AgentLLMConfig, model, baseURL string) string {
+	scope := *cfg
+	scope.Model, scope.BaseURL = model, baseURL
+	// Drop fields that do not affect the output; the API key must never be
+	// part of the persisted key material either.
+	scope.APIKey, scope.Timeout, scope.Fixture, scope.Record = "", "", "", ""
+	scope.MaxRetries, scope.RequestsPerMinute, scope.TokensPerMinute = nil, 0, 0
+	scope.Fallbacks, scope.Cache = nil, config.LLMCacheConfig{}
+	data, _ := json.Marshal(scope)
+	return string(data)
+}
@@ -0,0 +1,181 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/config"
+)
+
+func newCachingProvider(next blades.ModelProvider, store cacheStore) *cachingProvider {
+	return &cachingProvider{next: next, store: store, ttl: time.Hour, scope: "test"}
+}
+
+func TestCachingProvider_Generate(t *testing.T) {
+	stores := map[string]func(t *testing.T) cacheStore{
+		"memory": func(t *testing.T) cacheStore { return newMemoryCacheStore(defaultMemoryCacheEntries) },
+		"file":   func(t *testing.T) cacheStore { return &fileCacheStore{dir: t.TempDir()} },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			next := &stubProvider{name: "gpt-4o"}
+			p := newCachingProvider(next, newStore(t))
+			req := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("检查 CPU")}}
+
+			first, err := p.Generate(context.Background(), req)
+			require.NoError(t, err)
+			assert.Nil(t, first.Message.Metadata[MetadataCacheHit])
+
+			second, err := p.Generate(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, "from gpt-4o", second.Message.Text())
+			assert.Equal(t, true, second.Message.Metadata[MetadataCacheHit])
+			assert.Equal(t, 1, next.calls)
+			assert.Equal(t, int64(1), p.hits.Load())
+			assert.Equal(t, int64(1), p.misses.Load())
+		})
+	}
+}
+
+func TestCachingProvider_Key(t *testing.T) {
+	next := &stubProvider{name: "gpt-4o"}
+	p := newCachingProvider(next, newMemoryCacheStore(defaultMemoryCacheEntries))
+	ctx := context.Background()
+
+	_, err := p.Generate(ctx, &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("a")}})
+	require.NoError(t, err)
+	_, err = p.Generate(ctx, &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("b")}})
+	require.NoError(t, err)
+	_, err = p.Generate(ctx, &blades.ModelRequest{
+		Instruction: blades.SystemMessage("be brief"),
+		Messages:    []*blades.Message{blades.UserMessage("a")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, next.calls)
+
+	// 相同请求、不同模型设置不共享缓存
+	other := newCachingProvider(next, p.store)
+	other.scope = "other"
+	_, err = other.Generate(ctx, &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("a")}})
+	require.NoError(t, err)
+	assert.Equal(t, 4, next.calls)
+}
+
+func TestCachingProvider_ExpiredAndErrors(t *testing.T) {
+	t.Run("expired entries are refreshed", func(t *testing.T) {
+		next := &stubProvider{name: "gpt-4o"}
+		p := newCachingProvider(next, newMemoryCacheStore(defaultMemoryCacheEntries))
+		p.ttl = -time.Second
+		req := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("a")}}
+
+		for range 2 {
+			_, err := p.Generate(context.Background(), req)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, next.calls)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		next := &stubProvider{name: "gpt-4o", err: openaiStatusError(http.StatusServiceUnavailable)}
+		p := newCachingProvider(next, newMemoryCacheStore(defaultMemoryCacheEntries))
+		req := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("a")}}
+
+		for range 2 {
+			_, err := p.Generate(context.Background(), req)
+			require.Error(t, err)
+		}
+		assert.Equal(t, 2, next.calls)
+	})
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	t.Run("expired entries are deleted on lookup", func(t *testing.T) {
+		s := newMemoryCacheStore(10)
+		require.NoError(t, s.set("a", cacheEntry{Expires: time.Now().Add(-time.Second)}))
+		_, ok, err := s.get("a")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, 0, s.len())
+	})
+
+	t.Run("least recently used entries are evicted", func(t *testing.T) {
+		s := newMemoryCacheStore(2)
+		fresh := cacheEntry{Expires: time.Now().Add(time.Hour)}
+		require.NoError(t, s.set("a", fresh))
+		require.NoError(t, s.set("b", fresh))
+		// 读取 a 后 b 成为最久未使用的条目
+		_, ok, _ := s.get("a")
+		require.True(t, ok)
+		require.NoError(t, s.set("c", fresh))
+
+		assert.Equal(t, 2, s.len())
+		for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+			_, ok, err := s.get(key)
+			require.NoError(t, err)
+			assert.Equal(t, want, ok, key)
+		}
+	})
+}
+
+func TestCachingProvider_NewStreaming(t *testing.T) {
+	next := &stubProvider{name: "gpt-4o", chunks: []string{"partial", "full answer"}}
+	p := newCachingProvider(next, newMemoryCacheStore(defaultMemoryCacheEntries))
+	req := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("a")}}
+
+	collect := func() []string {
+		var texts []string
+		for resp, err := range p.NewStreaming(context.Background(), req) {
+			require.NoError(t, err)
+			texts = append(texts, resp.Message.Text())
+		}
+		return texts
+	}
+
+	assert.Equal(t, []string{"partial", "full answer"}, collect())
+	assert.Equal(t, []string{"full answer"}, collect())
+	assert.Equal(t, 1, next.calls)
+}
+
+func TestFactory_Build_Cache(t *testing.T) {
+	f := NewFactory()
+	zero := 0.0
+	base := config.AgentLLMConfig{Provider: "openai", Model: "gpt-4o", APIKey: "sk-test"}
+
+	tests := []struct {
+		name    string
+		cache   config.LLMCacheConfig
+		temp    *float64
+		cached  bool
+		wantErr bool
+	}{
+		{name: "disabled", cache: config.LLMCacheConfig{}, temp: &zero, cached: false},
+		{name: "temperature 0", cache: config.LLMCacheConfig{Enabled: true, Backend: "memory"}, temp: &zero, cached: true},
+		{name: "default temperature bypasses cache", cache: config.LLMCacheConfig{Enabled: true, Backend: "memory"}, cached: false},
+		{name: "force", cache: config.LLMCacheConfig{Enabled: true, Backend: "memory", Force: true}, cached: true},
+		{name: "file backend", cache: config.LLMCacheConfig{Enabled: true, Dir: t.TempDir()}, temp: &zero, cached: true},
+		{name: "invalid ttl", cache: config.LLMCacheConfig{Enabled: true, TTL: "soon"}, temp: &zero, wantErr: true},
+		{name: "invalid backend", cache: config.LLMCacheConfig{Enabled: true, Backend: "redis"}, temp: &zero, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.Cache = tt.cache
+			cfg.Temperature = tt.temp
+
+			m, err := f.Build(context.Background(), cfg)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			_, ok := m.(*cachingProvider)
+			assert.Equal(t, tt.cached, ok)
+		})
+	}
+}
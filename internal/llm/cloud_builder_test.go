@@ -0,0 +1,188 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/config"
+)
+
+func TestAzureOpenAIBuilder_Build(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-real-openai-key")
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+
+	var gotReq *http.Request
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReq = r
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &gotBody))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+  "id": "chatcmpl-1",
+  "object": "chat.completion",
+  "created": 1,
+  "model": "gpt-4o",
+  "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]
+}`)
+	}))
+	defer srv.Close()
+
+	m, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+		Provider:   "azure_openai",
+		Model:      "gpt-4o",
+		BaseURL:    srv.URL,
+		APIKey:     "azure-key",
+		Deployment: "prod-gpt4o",
+		APIVersion: "2024-06-01",
+	})
+	require.NoError(t, err)
+
+	resp, err := m.Generate(context.Background(), &blades.ModelRequest{
+		Messages: []*blades.Message{blades.UserMessage("hi")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Message.Text())
+
+	assert.Equal(t, "/openai/deployments/prod-gpt4o/chat/completions", gotReq.URL.Path)
+	assert.Equal(t, "2024-06-01", gotReq.URL.Query().Get("api-version"))
+	assert.Equal(t, "azure-key", gotReq.Header.Get("Api-Key"))
+	assert.Empty(t, gotReq.Header.Get("Authorization"))
+	assert.Equal(t, "gpt-4o", gotBody["model"])
+}
+
+func TestAzureOpenAIBuilder_RequiresEndpoint(t *testing.T) {
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "")
+	_, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+		Provider: "azure_openai",
+		Model:    "gpt-4o",
+		APIKey:   "azure-key",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires base_url")
+}
+
+func TestVertexBuilder_Build(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	t.Setenv("GOOGLE_CLOUD_LOCATION", "")
+	t.Setenv("GOOGLE_CLOUD_REGION", "")
+
+	t.Run("project is required", func(t *testing.T) {
+		_, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{Provider: "vertex", Model: "gemini-2.5-flash"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requires project")
+	})
+
+	t.Run("credentials file", func(t *testing.T) {
+		credsFile := filepath.Join(t.TempDir(), "adc.json")
+		require.NoError(t, os.WriteFile(credsFile, []byte(`{
+  "type": "authorized_user",
+  "client_id": "id.apps.googleusercontent.com",
+  "client_secret": "secret",
+  "refresh_token": "token"
+}`), 0o600))
+
+		cfg := config.AgentLLMConfig{
+			Provider:        "vertex",
+			Model:           "gemini-2.5-flash",
+			Project:         "my-project",
+			CredentialsFile: credsFile,
+		}
+		m, err := NewFactory().Build(context.Background(), cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "gemini-2.5-flash", m.Name())
+		assert.Equal(t, "https://us-central1-aiplatform.googleapis.com/", newVertexBuilder().GetBaseURL(&cfg))
+	})
+
+	t.Run("invalid credentials file", func(t *testing.T) {
+		_, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+			Provider:        "vertex",
+			Model:           "gemini-2.5-flash",
+			Project:         "my-project",
+			CredentialsFile: filepath.Join(t.TempDir(), "missing.json"),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "load vertex credentials")
+	})
+}
+
+func TestBedrockBuilder_Build(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-real-key")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	var gotReq *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReq = r
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+  "id": "msg_1",
+  "type": "message",
+  "role": "assistant",
+  "model": "claude",
+  "content": [{"type": "text", "text": "ok"}],
+  "stop_reason": "end_turn",
+  "usage": {"input_tokens": 1, "output_tokens": 1}
+}`)
+	}))
+	defer srv.Close()
+
+	m, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+		Provider: "bedrock",
+		Model:    "anthropic.claude-3-5-sonnet-20241022-v2:0",
+		Region:   "us-east-1",
+		BaseURL:  srv.URL,
+	})
+	require.NoError(t, err)
+
+	resp, err := m.Generate(context.Background(), &blades.ModelRequest{
+		Messages: []*blades.Message{blades.UserMessage("hi")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Message.Text())
+
+	assert.Equal(t, "/model/anthropic.claude-3-5-sonnet-20241022-v2:0/invoke", gotReq.URL.Path)
+	assert.True(t, strings.HasPrefix(gotReq.Header.Get("Authorization"), "AWS4-HMAC-SHA256"))
+	assert.Empty(t, gotReq.Header.Get("X-Api-Key"))
+}
+
+func TestGeminiBuilder_BaseURL(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "ok"}]}, "finishReason": "STOP"}]}`)
+	}))
+	defer srv.Close()
+
+	m, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+		Provider: "gemini",
+		Model:    "gemini-2.5-flash",
+		APIKey:   "gemini-key",
+		BaseURL:  srv.URL,
+	})
+	require.NoError(t, err)
+
+	resp, err := m.Generate(context.Background(), &blades.ModelRequest{
+		Messages: []*blades.Message{blades.UserMessage("hi")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Message.Text())
+	assert.Contains(t, gotPath, "models/gemini-2.5-flash:generateContent")
+}
@@ -0,0 +1,224 @@
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AnthropicWire speaks the Anthropic Messages API (POST /v1/messages).
+type AnthropicWire struct{}
+
+type anthropicRequest struct {
+	Stream   bool            `json:"stream"`
+	System   json.RawMessage `json:"system"`
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+	Tools []struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		InputSchema json.RawMessage `json:"input_schema"`
+	} `json:"tools"`
+}
+
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	Thinking  string          `json:"thinking,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+}
+
+// decodeAnthropicBlocks decodes content given either as a string or as blocks.
+func decodeAnthropicBlocks(raw json.RawMessage) ([]anthropicBlock, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []anthropicBlock{{Type: "text", Text: text}}, nil
+	}
+	var blocks []anthropicBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+func anthropicText(blocks []anthropicBlock) string {
+	var texts []string
+	for _, b := range blocks {
+		if b.Type == "text" {
+			texts = append(texts, b.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// DecodeRequest implements Wire.
+func (AnthropicWire) DecodeRequest(r *http.Request) (Request, error) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/messages" {
+		return Request{}, fmt.Errorf("unexpected endpoint %s %s", r.Method, r.URL.Path)
+	}
+	var body anthropicRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return Request{}, err
+	}
+
+	system, err := decodeAnthropicBlocks(body.System)
+	if err != nil {
+		return Request{}, fmt.Errorf("decode system: %w", err)
+	}
+	req := Request{Stream: body.Stream, System: anthropicText(system)}
+	for _, m := range body.Messages {
+		blocks, err := decodeAnthropicBlocks(m.Content)
+		if err != nil {
+			return Request{}, fmt.Errorf("decode %s message: %w", m.Role, err)
+		}
+		msg := Message{Role: m.Role, Text: anthropicText(blocks)}
+		for i, b := range blocks {
+			switch b.Type {
+			case "thinking":
+				// Like the API, require thinking blocks to lead the turn and keep their signature.
+				if i != len(msg.Thinking) || b.Signature == "" {
+					return Request{}, fmt.Errorf("thinking block %d must precede other content and carry a signature", i)
+				}
+				msg.Thinking = append(msg.Thinking, Thinking{Text: b.Thinking, Signature: b.Signature})
+			case "tool_use":
+				msg.ToolCalls = append(msg.ToolCalls, ToolCall{ID: b.ID, Name: b.Name, Arguments: string(b.Input)})
+			case "tool_result":
+				content, err := decodeAnthropicBlocks(b.Content)
+				if err != nil {
+					return Request{}, fmt.Errorf("decode tool_result: %w", err)
+				}
+				msg.ToolResults = append(msg.ToolResults, ToolResult{ID: b.ToolUseID, Content: anthropicText(content)})
+			}
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+	for _, tool := range body.Tools {
+		req.Tools = append(req.Tools, Tool{Name: tool.Name, Description: tool.Description, Parameters: tool.InputSchema})
+	}
+	return req, nil
+}
+
+func anthropicContent(reply Reply) []anthropicBlock {
+	content := []anthropicBlock{}
+	for _, th := range reply.Thinking {
+		content = append(content, anthropicBlock{Type: "thinking", Thinking: th.Text, Signature: th.Signature})
+	}
+	if text := reply.Text(); text != "" {
+		content = append(content, anthropicBlock{Type: "text", Text: text})
+	}
+	for _, tc := range reply.ToolCalls {
+		content = append(content, anthropicBlock{Type: "tool_use", ID: tc.ID, Name: tc.Name, Input: json.RawMessage(tc.Arguments)})
+	}
+	return content
+}
+
+func anthropicStopReason(reply Reply) string {
+	if len(reply.ToolCalls) > 0 {
+		return "tool_use"
+	}
+	return "end_turn"
+}
+
+// WriteReply implements Wire.
+func (AnthropicWire) WriteReply(w http.ResponseWriter, reply Reply) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":            "msg_conformance",
+		"type":          "message",
+		"role":          "assistant",
+		"model":         "conformance",
+		"content":       anthropicContent(reply),
+		"stop_reason":   anthropicStopReason(reply),
+		"stop_sequence": nil,
+		"usage": map[string]any{
+			"input_tokens":  reply.Usage.Input,
+			"output_tokens": reply.Usage.Output,
+		},
+	})
+}
+
+// WriteStream implements Wire.
+func (AnthropicWire) WriteStream(w http.ResponseWriter, reply Reply) {
+	startSSE(w)
+	writeSSE(w, "message_start", map[string]any{
+		"type": "message_start",
+		"message": map[string]any{
+			"id":          "msg_conformance",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       "conformance",
+			"content":     []any{},
+			"stop_reason": nil,
+			"usage":       map[string]any{"input_tokens": reply.Usage.Input, "output_tokens": 0},
+		},
+	})
+
+	index := 0
+	for _, th := range reply.Thinking {
+		writeSSE(w, "content_block_start", map[string]any{
+			"type": "content_block_start", "index": index,
+			"content_block": map[string]any{"type": "thinking", "thinking": "", "signature": ""},
+		})
+		writeSSE(w, "content_block_delta", map[string]any{
+			"type": "content_block_delta", "index": index,
+			"delta": map[string]any{"type": "thinking_delta", "thinking": th.Text},
+		})
+		writeSSE(w, "content_block_delta", map[string]any{
+			"type": "content_block_delta", "index": index,
+			"delta": map[string]any{"type": "signature_delta", "signature": th.Signature},
+		})
+		writeSSE(w, "content_block_stop", map[string]any{"type": "content_block_stop", "index": index})
+		index++
+	}
+	if len(reply.Chunks) > 0 {
+		writeSSE(w, "content_block_start", map[string]any{
+			"type": "content_block_start", "index": index,
+			"content_block": map[string]any{"type": "text", "text": ""},
+		})
+		for _, chunk := range reply.Chunks {
+			writeSSE(w, "content_block_delta", map[string]any{
+				"type": "content_block_delta", "index": index,
+				"delta": map[string]any{"type": "text_delta", "text": chunk},
+			})
+		}
+		writeSSE(w, "content_block_stop", map[string]any{"type": "content_block_stop", "index": index})
+		index++
+	}
+	for _, tc := range reply.ToolCalls {
+		writeSSE(w, "content_block_start", map[string]any{
+			"type": "content_block_start", "index": index,
+			"content_block": map[string]any{"type": "tool_use", "id": tc.ID, "name": tc.Name, "input": map[string]any{}},
+		})
+		writeSSE(w, "content_block_delta", map[string]any{
+			"type": "content_block_delta", "index": index,
+			"delta": map[string]any{"type": "input_json_delta", "partial_json": tc.Arguments},
+		})
+		writeSSE(w, "content_block_stop", map[string]any{"type": "content_block_stop", "index": index})
+		index++
+	}
+
+	writeSSE(w, "message_delta", map[string]any{
+		"type":  "message_delta",
+		"delta": map[string]any{"stop_reason": anthropicStopReason(reply), "stop_sequence": nil},
+		"usage": map[string]any{"output_tokens": reply.Usage.Output},
+	})
+	writeSSE(w, "message_stop", map[string]any{"type": "message_stop"})
+}
+
+// WriteError implements Wire.
+func (AnthropicWire) WriteError(w http.ResponseWriter, err APIError) {
+	writeJSON(w, err.Status, map[string]any{
+		"type":  "error",
+		"error": map[string]any{"type": "invalid_request_error", "message": err.Message},
+	})
+}
@@ -0,0 +1,514 @@
+// Package conformance is a reusable test suite for blades.ModelProvider
+// implementations.
+//
+// The suite runs a provider against an httptest stand-in of its HTTP API and
+// checks the behaviour agents rely on: text generation, streaming
+// accumulation, tool-call round trips, system instructions, token usage and
+// error propagation. Each provider API is described by a Wire, which
+// translates between the provider-neutral Request/Reply types used by the
+// scenarios and the provider's JSON.
+//
+// Run it whenever a forked provider (third_party/blades_contrib) is upgraded.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Provider describes a provider under test.
+type Provider struct {
+	// Wire speaks the provider's HTTP API.
+	Wire Wire
+	// New builds the provider with its base URL pointing at the stand-in.
+	// SDK-level retries must be disabled so error scenarios fail fast.
+	New func(t *testing.T, baseURL string) blades.ModelProvider
+	// NewThinking, if set, builds the provider with extended thinking
+	// enabled; the suite then checks that signed thinking blocks survive a
+	// tool-call round trip.
+	NewThinking func(t *testing.T, baseURL string) blades.ModelProvider
+}
+
+// Wire translates between the neutral types and a provider's HTTP API.
+type Wire interface {
+	// DecodeRequest parses a request sent by the provider.
+	DecodeRequest(r *http.Request) (Request, error)
+	// WriteReply writes reply as a non-streaming response.
+	WriteReply(w http.ResponseWriter, reply Reply)
+	// WriteStream writes reply as a streaming response, one event per chunk.
+	WriteStream(w http.ResponseWriter, reply Reply)
+	// WriteError writes an API error response.
+	WriteError(w http.ResponseWriter, err APIError)
+}
+
+// Request is the provider-neutral view of a request sent to the API.
+type Request struct {
+	Stream   bool
+	System   string
+	Messages []Message
+	Tools    []Tool
+}
+
+// Message is one conversation turn in a Request. Roles are "user" or
+// "assistant"; tool results are carried by whichever role the API uses.
+type Message struct {
+	Role        string
+	Text        string
+	Thinking    []Thinking
+	ToolCalls   []ToolCall
+	ToolResults []ToolResult
+}
+
+// Thinking is a signed thinking block produced by the model.
+type Thinking struct {
+	Text      string
+	Signature string
+}
+
+// Tool is a tool declaration in a Request.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+}
+
+// ToolCall is a tool invocation requested by the model.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// ToolResult is the output of a tool call sent back to the model.
+type ToolResult struct {
+	ID      string
+	Name    string
+	Content string
+}
+
+// Reply is a scripted model response.
+type Reply struct {
+	// Chunks are the text deltas of a streaming response; non-streaming
+	// responses carry their concatenation.
+	Chunks    []string
+	Thinking  []Thinking
+	ToolCalls []ToolCall
+	Usage     Usage
+	// Error, if set, is returned instead of a response.
+	Error *APIError
+}
+
+// Text returns the full reply text.
+func (r Reply) Text() string {
+	return strings.Join(r.Chunks, "")
+}
+
+// Usage is the token usage reported with a Reply.
+type Usage struct {
+	Input  int64
+	Output int64
+}
+
+// APIError is an error response of the API.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+// Server is an httptest stand-in serving scripted replies in order.
+type Server struct {
+	*httptest.Server
+
+	t    *testing.T
+	wire Wire
+
+	mu       sync.Mutex
+	replies  []Reply
+	requests []Request
+}
+
+// NewServer starts a stand-in for wire that serves replies in order. It is
+// closed when the test ends.
+func NewServer(t *testing.T, wire Wire, replies ...Reply) *Server {
+	t.Helper()
+	s := &Server{t: t, wire: wire, replies: replies}
+	s.Server = httptest.NewServer(s)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := s.wire.DecodeRequest(r)
+	if err != nil {
+		s.t.Errorf("decode request %s %s: %v", r.Method, r.URL.Path, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	if len(s.replies) == 0 {
+		s.mu.Unlock()
+		s.t.Errorf("unexpected request %d: no scripted reply left", len(s.requests))
+		s.wire.WriteError(w, APIError{Status: http.StatusBadRequest, Message: "no scripted reply"})
+		return
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	s.mu.Unlock()
+
+	switch {
+	case reply.Error != nil:
+		s.wire.WriteError(w, *reply.Error)
+	case req.Stream:
+		s.wire.WriteStream(w, reply)
+	default:
+		s.wire.WriteReply(w, reply)
+	}
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Run runs the conformance suite against p.
+func Run(t *testing.T, p Provider) {
+	t.Run("generate text", func(t *testing.T) { testGenerateText(t, p) })
+	t.Run("system instruction", func(t *testing.T) { testSystemInstruction(t, p) })
+	t.Run("streaming accumulation", func(t *testing.T) { testStreaming(t, p) })
+	t.Run("tool call round trip", func(t *testing.T) { testToolRoundTrip(t, p, false) })
+	t.Run("streaming tool call round trip", func(t *testing.T) { testToolRoundTrip(t, p, true) })
+	if p.NewThinking != nil {
+		t.Run("thinking tool call round trip", func(t *testing.T) { testThinkingRoundTrip(t, p, false) })
+		t.Run("streaming thinking tool call round trip", func(t *testing.T) { testThinkingRoundTrip(t, p, true) })
+	}
+	t.Run("token usage", func(t *testing.T) { testTokenUsage(t, p) })
+	t.Run("error propagation", func(t *testing.T) { testErrors(t, p) })
+}
+
+func testGenerateText(t *testing.T, p Provider) {
+	srv := NewServer(t, p.Wire, Reply{Chunks: []string{"所有服务运行正常。"}})
+	m := p.New(t, srv.URL)
+
+	resp, err := m.Generate(context.Background(), &blades.ModelRequest{
+		Messages: []*blades.Message{blades.UserMessage("巡检一下")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, blades.RoleAssistant, resp.Message.Role)
+	assert.Equal(t, blades.StatusCompleted, resp.Message.Status)
+	assert.Equal(t, "所有服务运行正常。", resp.Message.Text())
+
+	reqs := srv.Requests()
+	require.Len(t, reqs, 1)
+	assert.False(t, reqs[0].Stream)
+	require.Len(t, reqs[0].Messages, 1)
+	assert.Equal(t, "user", reqs[0].Messages[0].Role)
+	assert.Equal(t, "巡检一下", reqs[0].Messages[0].Text)
+}
+
+func testSystemInstruction(t *testing.T, p Provider) {
+	srv := NewServer(t, p.Wire, Reply{Chunks: []string{"好的"}})
+	m := p.New(t, srv.URL)
+
+	_, err := m.Generate(context.Background(), &blades.ModelRequest{
+		Instruction: blades.SystemMessage("你是巡检助手"),
+		Messages:    []*blades.Message{blades.UserMessage("你好")},
+	})
+	require.NoError(t, err)
+
+	reqs := srv.Requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "你是巡检助手", reqs[0].System)
+	for _, msg := range reqs[0].Messages {
+		assert.NotContains(t, msg.Text, "你是巡检助手", "instruction must not be sent as a conversation turn")
+	}
+}
+
+func testStreaming(t *testing.T, p Provider) {
+	chunks := []string{"CPU ", "使用率", "正常"}
+	srv := NewServer(t, p.Wire, Reply{Chunks: chunks})
+	m := p.New(t, srv.URL)
+
+	responses := collect(t, m, &blades.ModelRequest{
+		Messages: []*blades.Message{blades.UserMessage("CPU 怎么样")},
+	})
+	require.NotEmpty(t, responses)
+
+	final := responses[len(responses)-1]
+	assert.Equal(t, blades.StatusCompleted, final.Message.Status, "the last chunk is the complete message")
+	assert.Equal(t, "CPU 使用率正常", final.Message.Text())
+
+	var deltas strings.Builder
+	for _, resp := range responses[:len(responses)-1] {
+		assert.Equal(t, blades.StatusIncomplete, resp.Message.Status)
+		deltas.WriteString(resp.Message.Text())
+	}
+	assert.Equal(t, "CPU 使用率正常", deltas.String(), "incomplete chunks carry deltas")
+
+	reqs := srv.Requests()
+	require.Len(t, reqs, 1)
+	assert.True(t, reqs[0].Stream)
+}
+
+// metricsQuery is the input of the tool used by the tool scenarios.
+type metricsQuery struct {
+	Metric string `json:"metric" jsonschema:"指标名称"`
+}
+
+func metricsTool(t *testing.T) tools.Tool {
+	t.Helper()
+	tool, err := tools.NewFunc("query_metrics", "查询指标", func(ctx context.Context, in metricsQuery) (string, error) {
+		return "42%", nil
+	})
+	require.NoError(t, err)
+	return tool
+}
+
+// testToolRoundTrip requests a tool call, then sends the tool result back the
+// way blades agents do: a RoleTool message whose ToolParts carry both the
+// request and the response.
+func testToolRoundTrip(t *testing.T, p Provider, stream bool) {
+	call := ToolCall{ID: "call_1", Name: "query_metrics", Arguments: `{"metric":"cpu"}`}
+	srv := NewServer(t, p.Wire,
+		Reply{ToolCalls: []ToolCall{call}},
+		Reply{Chunks: []string{"CPU 使用率为 42%"}},
+	)
+	m := p.New(t, srv.URL)
+	tool := metricsTool(t)
+	question := blades.UserMessage("CPU 使用率多少？")
+
+	generate := func(req *blades.ModelRequest) *blades.ModelResponse {
+		if !stream {
+			resp, err := m.Generate(context.Background(), req)
+			require.NoError(t, err)
+			return resp
+		}
+		responses := collect(t, m, req)
+		require.NotEmpty(t, responses)
+		return responses[len(responses)-1]
+	}
+
+	resp := generate(&blades.ModelRequest{
+		Messages: []*blades.Message{question},
+		Tools:    []tools.Tool{tool},
+	})
+	assert.Equal(t, blades.RoleTool, resp.Message.Role, "tool calls must be returned with RoleTool so agents execute them")
+	parts := toolParts(resp.Message)
+	require.Len(t, parts, 1)
+	assert.Equal(t, call.ID, parts[0].ID)
+	assert.Equal(t, call.Name, parts[0].Name)
+	assert.JSONEq(t, call.Arguments, parts[0].Request)
+
+	reqs := srv.Requests()
+	require.Len(t, reqs, 1)
+	require.Len(t, reqs[0].Tools, 1)
+	assert.Equal(t, "query_metrics", reqs[0].Tools[0].Name)
+	assert.Equal(t, "查询指标", reqs[0].Tools[0].Description)
+	assert.Contains(t, string(reqs[0].Tools[0].Parameters), `"metric"`)
+
+	// Execute the tool as blades.Agent does.
+	toolMessage := resp.Message
+	for i, part := range toolMessage.Parts {
+		if tp, ok := part.(blades.ToolPart); ok {
+			tp.Response = "42%"
+			toolMessage.Parts[i] = tp
+		}
+	}
+	resp = generate(&blades.ModelRequest{
+		Messages: []*blades.Message{question, toolMessage},
+		Tools:    []tools.Tool{tool},
+	})
+	assert.Equal(t, blades.RoleAssistant, resp.Message.Role)
+	assert.Equal(t, "CPU 使用率为 42%", resp.Message.Text())
+
+	reqs = srv.Requests()
+	require.Len(t, reqs, 2)
+	assertToolTurns(t, reqs[1].Messages, call, "42%")
+}
+
+// testThinkingRoundTrip checks that the signed thinking blocks and the text of
+// a tool-calling turn are replayed with the tool call, as providers with
+// extended thinking require.
+func testThinkingRoundTrip(t *testing.T, p Provider, stream bool) {
+	thinking := []Thinking{{Text: "需要先查询 CPU 指标", Signature: "sig-cpu-1"}}
+	call := ToolCall{ID: "call_1", Name: "query_metrics", Arguments: `{"metric":"cpu"}`}
+	srv := NewServer(t, p.Wire,
+		Reply{Thinking: thinking, Chunks: []string{"先查一下 CPU。"}, ToolCalls: []ToolCall{call}},
+		Reply{Chunks: []string{"CPU 使用率为 42%"}},
+	)
+	m := p.NewThinking(t, srv.URL)
+	tool := metricsTool(t)
+	question := blades.UserMessage("CPU 使用率多少？")
+
+	generate := func(req *blades.ModelRequest) *blades.ModelResponse {
+		if !stream {
+			resp, err := m.Generate(context.Background(), req)
+			require.NoError(t, err)
+			return resp
+		}
+		responses := collect(t, m, req)
+		require.NotEmpty(t, responses)
+		return responses[len(responses)-1]
+	}
+
+	resp := generate(&blades.ModelRequest{
+		Messages: []*blades.Message{question},
+		Tools:    []tools.Tool{tool},
+	})
+	require.Equal(t, blades.RoleTool, resp.Message.Role)
+
+	toolMessage := resp.Message
+	for i, part := range toolMessage.Parts {
+		if tp, ok := part.(blades.ToolPart); ok {
+			tp.Response = "42%"
+			toolMessage.Parts[i] = tp
+		}
+	}
+	resp = generate(&blades.ModelRequest{
+		Messages: []*blades.Message{question, toolMessage},
+		Tools:    []tools.Tool{tool},
+	})
+	assert.Equal(t, "CPU 使用率为 42%", resp.Message.Text())
+
+	reqs := srv.Requests()
+	require.Len(t, reqs, 2)
+	assertToolTurns(t, reqs[1].Messages, call, "42%")
+	for _, msg := range reqs[1].Messages {
+		if len(msg.ToolCalls) == 0 {
+			continue
+		}
+		assert.Equal(t, thinking, msg.Thinking, "signed thinking must be replayed with the tool call")
+		assert.Equal(t, "先查一下 CPU。", msg.Text, "text of the tool-calling turn must be replayed")
+	}
+}
+
+// assertToolTurns checks that the tool call is sent back as a model turn,
+// followed by its result.
+func assertToolTurns(t *testing.T, messages []Message, call ToolCall, result string) {
+	t.Helper()
+	callAt, resultAt := -1, -1
+	for i, msg := range messages {
+		for _, tc := range msg.ToolCalls {
+			if tc.ID == call.ID {
+				callAt = i
+				assert.Equal(t, "assistant", msg.Role)
+				assert.Equal(t, call.Name, tc.Name)
+				assert.JSONEq(t, call.Arguments, tc.Arguments)
+			}
+		}
+		for _, tr := range msg.ToolResults {
+			if tr.ID == call.ID {
+				resultAt = i
+				assert.Equal(t, result, tr.Content)
+				if tr.Name != "" {
+					assert.Equal(t, call.Name, tr.Name)
+				}
+			}
+		}
+	}
+	require.NotEqual(t, -1, callAt, "tool call %s not sent back: %+v", call.ID, messages)
+	require.NotEqual(t, -1, resultAt, "tool result %s not sent: %+v", call.ID, messages)
+	assert.Less(t, callAt, resultAt, "tool result must follow its call")
+}
+
+func testTokenUsage(t *testing.T, p Provider) {
+	usage := Usage{Input: 120, Output: 30}
+	want := blades.TokenUsage{InputTokens: 120, OutputTokens: 30, TotalTokens: 150}
+	srv := NewServer(t, p.Wire,
+		Reply{Chunks: []string{"正常"}, Usage: usage},
+		Reply{Chunks: []string{"正", "常"}, Usage: usage},
+	)
+	m := p.New(t, srv.URL)
+	req := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("状态")}}
+
+	resp, err := m.Generate(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, want, resp.Message.TokenUsage)
+
+	responses := collect(t, m, req)
+	require.NotEmpty(t, responses)
+	assert.Equal(t, want, responses[len(responses)-1].Message.TokenUsage)
+}
+
+func testErrors(t *testing.T, p Provider) {
+	apiErr := &APIError{Status: http.StatusBadRequest, Message: "invalid model parameter"}
+	srv := NewServer(t, p.Wire, Reply{Error: apiErr}, Reply{Error: apiErr})
+	m := p.New(t, srv.URL)
+	req := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("状态")}}
+
+	_, err := m.Generate(context.Background(), req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), apiErr.Message)
+
+	var streamErr error
+	for _, err := range m.NewStreaming(context.Background(), req) {
+		if err != nil {
+			streamErr = err
+			break
+		}
+	}
+	require.Error(t, streamErr, "streaming must yield the API error")
+	assert.Contains(t, streamErr.Error(), apiErr.Message)
+}
+
+// collect drains a streaming response, failing the test on errors.
+func collect(t *testing.T, m blades.ModelProvider, req *blades.ModelRequest) []*blades.ModelResponse {
+	t.Helper()
+	var responses []*blades.ModelResponse
+	for resp, err := range m.NewStreaming(context.Background(), req) {
+		require.NoError(t, err)
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func toolParts(msg *blades.Message) []blades.ToolPart {
+	var parts []blades.ToolPart
+	for _, part := range msg.Parts {
+		if tp, ok := part.(blades.ToolPart); ok {
+			parts = append(parts, tp)
+		}
+	}
+	return parts
+}
+
+// writeJSON writes v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeSSE writes one server-sent event; event may be empty.
+func writeSSE(w http.ResponseWriter, event string, data any) {
+	payload, _ := json.Marshal(data)
+	if event != "" {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", payload)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// startSSE writes the headers of a streaming response.
+func startSSE(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,57 @@
+package conformance_test
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/contrib/anthropic"
+	"github.com/go-kratos/blades/contrib/gemini"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+
+	"github.com/oneblade/internal/llm/conformance"
+)
+
+func TestAnthropic(t *testing.T) {
+	conformance.Run(t, conformance.Provider{
+		Wire: conformance.AnthropicWire{},
+		New: func(t *testing.T, baseURL string) blades.ModelProvider {
+			return anthropic.NewModel("claude-sonnet-4-5", anthropic.Config{
+				BaseURL:         baseURL,
+				APIKey:          "test-key",
+				MaxOutputTokens: 1024,
+				RequestOptions:  []option.RequestOption{option.WithMaxRetries(0)},
+			})
+		},
+		NewThinking: func(t *testing.T, baseURL string) blades.ModelProvider {
+			thinking := sdk.ThinkingConfigParamOfEnabled(1024)
+			return anthropic.NewModel("claude-sonnet-4-5", anthropic.Config{
+				BaseURL:         baseURL,
+				APIKey:          "test-key",
+				MaxOutputTokens: 2048,
+				Thinking:        &thinking,
+				RequestOptions:  []option.RequestOption{option.WithMaxRetries(0)},
+			})
+		},
+	})
+}
+
+func TestGemini(t *testing.T) {
+	conformance.Run(t, conformance.Provider{
+		Wire: conformance.GeminiWire{},
+		New: func(t *testing.T, baseURL string) blades.ModelProvider {
+			m, err := gemini.NewModel(context.Background(), "gemini-2.5-flash", gemini.Config{
+				ClientConfig: genai.ClientConfig{
+					APIKey:      "test-key",
+					Backend:     genai.BackendGeminiAPI,
+					HTTPOptions: genai.HTTPOptions{BaseURL: baseURL},
+				},
+			})
+			require.NoError(t, err)
+			return m
+		},
+	})
+}
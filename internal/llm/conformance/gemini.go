@@ -0,0 +1,180 @@
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GeminiWire speaks the Gemini API generateContent and streamGenerateContent
+// endpoints (Gemini API backend).
+type GeminiWire struct{}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	ID       string         `json:"id,omitempty"`
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent `json:"contents"`
+	SystemInstruction *geminiContent  `json:"systemInstruction"`
+	Tools             []struct {
+		FunctionDeclarations []struct {
+			Name                 string          `json:"name"`
+			Description          string          `json:"description"`
+			Parameters           json.RawMessage `json:"parameters"`
+			ParametersJSONSchema json.RawMessage `json:"parametersJsonSchema"`
+		} `json:"functionDeclarations"`
+	} `json:"tools"`
+}
+
+func geminiText(parts []geminiPart) string {
+	var texts []string
+	for _, p := range parts {
+		if p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// geminiResult unwraps function responses: plain-text tool output is sent as
+// {"output": "..."}, anything else is compared as JSON.
+func geminiResult(response map[string]any) string {
+	if output, ok := response["output"].(string); ok && len(response) == 1 {
+		return output
+	}
+	data, _ := json.Marshal(response)
+	return string(data)
+}
+
+// DecodeRequest implements Wire.
+func (GeminiWire) DecodeRequest(r *http.Request) (Request, error) {
+	var stream bool
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, ":generateContent"):
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, ":streamGenerateContent"):
+		stream = true
+	default:
+		return Request{}, fmt.Errorf("unexpected endpoint %s %s", r.Method, r.URL.Path)
+	}
+	var body geminiRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return Request{}, err
+	}
+
+	req := Request{Stream: stream}
+	if body.SystemInstruction != nil {
+		req.System = geminiText(body.SystemInstruction.Parts)
+	}
+	for _, c := range body.Contents {
+		role := c.Role
+		if role == "model" {
+			role = "assistant"
+		}
+		msg := Message{Role: role, Text: geminiText(c.Parts)}
+		for _, p := range c.Parts {
+			if fc := p.FunctionCall; fc != nil {
+				msg.ToolCalls = append(msg.ToolCalls, ToolCall{ID: fc.ID, Name: fc.Name, Arguments: string(fc.Args)})
+			}
+			if fr := p.FunctionResponse; fr != nil {
+				msg.ToolResults = append(msg.ToolResults, ToolResult{ID: fr.ID, Name: fr.Name, Content: geminiResult(fr.Response)})
+			}
+		}
+		req.Messages = append(req.Messages, msg)
+	}
+	for _, tool := range body.Tools {
+		for _, fd := range tool.FunctionDeclarations {
+			params := fd.ParametersJSONSchema
+			if len(params) == 0 {
+				params = fd.Parameters
+			}
+			req.Tools = append(req.Tools, Tool{Name: fd.Name, Description: fd.Description, Parameters: params})
+		}
+	}
+	return req, nil
+}
+
+func geminiResponse(parts []geminiPart, finish bool, usage *Usage) map[string]any {
+	candidate := map[string]any{
+		"index":   0,
+		"content": geminiContent{Role: "model", Parts: parts},
+	}
+	if finish {
+		candidate["finishReason"] = "STOP"
+	}
+	resp := map[string]any{"candidates": []any{candidate}}
+	if usage != nil {
+		resp["usageMetadata"] = map[string]any{
+			"promptTokenCount":     usage.Input,
+			"candidatesTokenCount": usage.Output,
+			"totalTokenCount":      usage.Input + usage.Output,
+		}
+	}
+	return resp
+}
+
+func geminiCalls(reply Reply) []geminiPart {
+	var parts []geminiPart
+	for _, tc := range reply.ToolCalls {
+		parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{ID: tc.ID, Name: tc.Name, Args: json.RawMessage(tc.Arguments)}})
+	}
+	return parts
+}
+
+// WriteReply implements Wire.
+func (GeminiWire) WriteReply(w http.ResponseWriter, reply Reply) {
+	var parts []geminiPart
+	if text := reply.Text(); text != "" {
+		parts = append(parts, geminiPart{Text: text})
+	}
+	parts = append(parts, geminiCalls(reply)...)
+	writeJSON(w, http.StatusOK, geminiResponse(parts, true, &reply.Usage))
+}
+
+// WriteStream implements Wire. Usage and the finish reason come with the
+// last chunk, as the API does.
+func (GeminiWire) WriteStream(w http.ResponseWriter, reply Reply) {
+	startSSE(w)
+	var chunks [][]geminiPart
+	for _, chunk := range reply.Chunks {
+		chunks = append(chunks, []geminiPart{{Text: chunk}})
+	}
+	if calls := geminiCalls(reply); len(calls) > 0 {
+		chunks = append(chunks, calls)
+	}
+	for i, parts := range chunks {
+		last := i == len(chunks)-1
+		var usage *Usage
+		if last {
+			usage = &reply.Usage
+		}
+		writeSSE(w, "", geminiResponse(parts, last, usage))
+	}
+}
+
+// WriteError implements Wire.
+func (GeminiWire) WriteError(w http.ResponseWriter, err APIError) {
+	writeJSON(w, err.Status, map[string]any{
+		"error": map[string]any{"code": err.Status, "message": err.Message, "status": "INVALID_ARGUMENT"},
+	})
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"google.golang.org/genai"
+)
+
+// IsRetryable reports whether err is a transient provider failure that is
+// worth retrying or falling back on: timeouts, connection resets, HTTP 408,
+// 409, 429 and 5xx responses.
+//
+// Client-side errors such as invalid requests, authentication failures and
+// context cancellation by the caller are not retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if code, ok := statusCode(err); ok {
+		return code == http.StatusRequestTimeout ||
+			code == http.StatusConflict ||
+			code == http.StatusTooManyRequests ||
+			code >= http.StatusInternalServerError
+	}
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// statusCode extracts the HTTP status code from provider SDK errors.
+func statusCode(err error) (int, bool) {
+	var openaiErr *openai.Error
+	if errors.As(err, &openaiErr) {
+		return openaiErr.StatusCode, true
+	}
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) {
+		return anthropicErr.StatusCode, true
+	}
+	var genaiErr genai.APIError
+	if errors.As(err, &genaiErr) {
+		return genaiErr.Code, true
+	}
+	var genaiErrPtr *genai.APIError
+	if errors.As(err, &genaiErrPtr) {
+		return genaiErrPtr.Code, true
+	}
+	return 0, false
+}
@@ -3,7 +3,9 @@ package llm
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/go-kratos/blades"
 	"github.com/go-playground/validator/v10"
@@ -15,36 +17,162 @@ import (
 //
 // It applies defaults for optional fields, validates required fields, and
 // dispatches to provider-specific builders.
+//
+// Every provider is wrapped with the configured timeout, retries and rate
+// limits, and with output schema validation. Rate limiters are owned by the
+// Factory, so a single Factory should be reused for all agents (and across
+// config reloads) for limits to be shared between agents using the same API
+// key.
 type Factory struct {
 	validate *validator.Validate
+	limiters *limiterRegistry
+	// memCache backs cache.backend = "memory", shared by all agents
+	memCache *memoryCacheStore
 }
 
+// defaultMaxTokens is used when max_tokens is not configured.
+const defaultMaxTokens = 2048
+
 // builderRegistry 存储所有 provider 的 builder
 var builderRegistry = map[string]ModelBuilder{
 	"openai":    newOpenAIBuilder(),
 	"anthropic": newAnthropicBuilder(),
 	"gemini":    newGeminiBuilder(),
+	// 云厂商托管的同系列模型
+	"azure_openai": newAzureOpenAIBuilder(),
+	"vertex":       newVertexBuilder(),
+	"bedrock":      newBedrockBuilder(),
+	// OpenAI 兼容的自托管服务，API Key 可选
+	"ollama":            newOllamaBuilder(),
+	"openai_compatible": newOpenAICompatibleBuilder(),
+	// 从 fixture 回放脚本化响应，用于测试与演示
+	"mock": newMockBuilder(),
 }
 
 func NewFactory() *Factory {
-	return &Factory{validate: validator.New()}
+	return &Factory{
+		validate: validator.New(),
+		limiters: newLimiterRegistry(),
+		memCache: newMemoryCacheStore(defaultMemoryCacheEntries),
+	}
 }
 
+// Build builds the model provider for cfg. When cfg.Fallbacks is set, the
+// primary and fallback providers are wrapped into a fallback chain.
+//
+// Within a chain only the last provider retries: the others fail over on
+// their first retryable error instead of spending their backoff budget
+// while the next provider could already be serving the request.
 func (f *Factory) Build(ctx context.Context, cfg config.AgentLLMConfig) (blades.ModelProvider, error) {
+	if len(cfg.Fallbacks) == 0 {
+		return f.build(ctx, cfg)
+	}
+
+	noRetries := 0
+	primaryCfg := cfg
+	primaryCfg.MaxRetries = &noRetries
+	primary, err := f.build(ctx, primaryCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := []blades.ModelProvider{primary}
+	for i, fb := range cfg.Fallbacks {
+		if len(fb.Fallbacks) > 0 {
+			_ = closeChain(providers)
+			return nil, fmt.Errorf("fallback %d: nested fallbacks are not supported", i)
+		}
+		if i < len(cfg.Fallbacks)-1 {
+			fb.MaxRetries = &noRetries
+		}
+		m, err := f.build(ctx, fb)
+		if err != nil {
+			_ = closeChain(providers)
+			return nil, fmt.Errorf("build fallback %d (%s/%s): %w", i, fb.Provider, fb.Model, err)
+		}
+		providers = append(providers, m)
+	}
+	return NewFallbackProvider(providers...), nil
+}
+
+// build builds a single provider without its fallbacks.
+func (f *Factory) build(ctx context.Context, cfg config.AgentLLMConfig) (blades.ModelProvider, error) {
 	cfg.Provider = normalizeProvider(cfg.Provider)
 
 	if err := f.validate.Struct(cfg); err != nil {
 		return nil, fmt.Errorf("validate llm config: %w", err)
 	}
+	if err := validateTuning(&cfg); err != nil {
+		return nil, fmt.Errorf("validate llm config: %w", err)
+	}
 
 	applyDefaults(&cfg)
 
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil || timeout <= 0 {
+		return nil, fmt.Errorf("invalid llm timeout %q", cfg.Timeout)
+	}
+
 	builder, ok := builderRegistry[cfg.Provider]
 	if !ok {
 		return nil, fmt.Errorf("unsupported llm provider: %s", cfg.Provider)
 	}
 
-	return builder.Build(ctx, &cfg)
+	m, err := builder.Build(ctx, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Record != "" {
+		m = NewRecordingProvider(m, cfg.Record)
+	}
+	m = &resilientProvider{
+		next:       m,
+		timeout:    timeout,
+		maxRetries: *cfg.MaxRetries,
+		limiter:    f.limiters.get(&cfg, builder.GetBaseURL(&cfg)),
+	}
+	m = NewStructuredProvider(m, cfg.Provider)
+	return f.withCache(m, &cfg, builder)
+}
+
+// withCache wraps m with the response cache configured in cfg.Cache.
+// The cache sits outside retries and rate limits so hits cost nothing.
+func (f *Factory) withCache(m blades.ModelProvider, cfg *config.AgentLLMConfig, builder ModelBuilder) (blades.ModelProvider, error) {
+	if !cfg.Cache.Enabled {
+		return m, nil
+	}
+	if *cfg.Temperature > 0 && !cfg.Cache.Force {
+		slog.Info("llm.cache.bypass",
+			"model", builder.GetModel(cfg),
+			"temperature", *cfg.Temperature,
+			"reason", "temperature > 0, set cache.force to cache anyway",
+		)
+		return m, nil
+	}
+
+	ttl, err := time.ParseDuration(cfg.Cache.TTL)
+	if err != nil || ttl <= 0 {
+		return nil, fmt.Errorf("invalid llm cache ttl %q", cfg.Cache.TTL)
+	}
+
+	var store cacheStore
+	switch cfg.Cache.Backend {
+	case "memory":
+		store = f.memCache
+	default:
+		dir := cfg.Cache.Dir
+		if dir == "" {
+			dir = defaultCacheDir()
+		}
+		store = &fileCacheStore{dir: dir}
+	}
+
+	return &cachingProvider{
+		next:  m,
+		store: store,
+		ttl:   ttl,
+		scope: cacheScope(cfg, builder.GetModel(cfg), builder.GetBaseURL(cfg)),
+	}, nil
 }
 
 func normalizeProvider(p string) string {
@@ -60,11 +188,18 @@ func applyDefaults(cfg *config.AgentLLMConfig) {
 
 	// 为可选字段设置默认值，避免各 builder 中重复的 nil 检查
 	if cfg.MaxTokens == nil {
-		defaultMaxTokens := 2048
-		cfg.MaxTokens = &defaultMaxTokens
+		maxTokens := defaultMaxTokens
+		cfg.MaxTokens = &maxTokens
 	}
 	if cfg.Temperature == nil {
 		defaultTemperature := 0.7
 		cfg.Temperature = &defaultTemperature
 	}
+	if cfg.Cache.TTL == "" {
+		cfg.Cache.TTL = "1h"
+	}
+	if cfg.MaxRetries == nil {
+		defaultMaxRetries := 2
+		cfg.MaxRetries = &defaultMaxRetries
+	}
 }
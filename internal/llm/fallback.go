@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-kratos/blades"
+)
+
+// Message metadata keys set by the fallback provider.
+const (
+	// MetadataServedBy is the name of the model that actually produced the response.
+	MetadataServedBy = "served_by_model"
+	// MetadataFallbackIndex is the position of that model in the chain (0 = primary).
+	MetadataFallbackIndex = "fallback_index"
+)
+
+// fallbackProvider tries an ordered chain of providers and moves on to the
+// next one when the current provider fails with a retryable error.
+//
+// Non-retryable errors (bad request, auth, caller cancellation) are returned
+// immediately, since another provider is unlikely to do better.
+type fallbackProvider struct {
+	providers []blades.ModelProvider
+}
+
+// NewFallbackProvider wraps providers into a single blades.ModelProvider.
+// The first provider is the primary; the rest are tried in order.
+func NewFallbackProvider(providers ...blades.ModelProvider) blades.ModelProvider {
+	if len(providers) == 1 {
+		return providers[0]
+	}
+	return &fallbackProvider{providers: providers}
+}
+
+// Name returns the primary model name.
+func (p *fallbackProvider) Name() string {
+	return p.providers[0].Name()
+}
+
+// Generate implements blades.ModelProvider.
+func (p *fallbackProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	var errs []error
+	for i, m := range p.providers {
+		resp, err := m.Generate(ctx, req)
+		if err == nil {
+			p.logServed(i)
+			p.served(resp, i)
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", m.Name(), err))
+		if !p.next(ctx, i, err) {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// NewStreaming implements blades.ModelProvider.
+//
+// Falling back is only possible before the first chunk has been yielded;
+// once output has reached the caller, later errors are passed through.
+func (p *fallbackProvider) NewStreaming(ctx context.Context, req *blades.ModelRequest) blades.Generator[*blades.ModelResponse, error] {
+	return func(yield func(*blades.ModelResponse, error) bool) {
+		var errs []error
+		for i, m := range p.providers {
+			started := false
+			var streamErr error
+			for resp, err := range m.NewStreaming(ctx, req) {
+				if err != nil && !started {
+					streamErr = err
+					break
+				}
+				if err == nil {
+					if !started {
+						started = true
+						p.logServed(i)
+					}
+					p.served(resp, i)
+				}
+				if !yield(resp, err) {
+					return
+				}
+			}
+			if streamErr == nil {
+				return
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", m.Name(), streamErr))
+			if !p.next(ctx, i, streamErr) {
+				break
+			}
+		}
+		yield(nil, errors.Join(errs...))
+	}
+}
+
+// Close closes every provider in the chain that implements io.Closer.
+func (p *fallbackProvider) Close() error {
+	return closeChain(p.providers)
+}
+
+// closeChain closes the providers of a (possibly partially built) chain.
+func closeChain(providers []blades.ModelProvider) error {
+	models := make(map[string]blades.ModelProvider, len(providers))
+	for i, m := range providers {
+		models[fmt.Sprintf("%s#%d", m.Name(), i)] = m
+	}
+	return closeModels(models)
+}
+
+// next reports whether the chain should continue after provider i failed with err.
+func (p *fallbackProvider) next(ctx context.Context, i int, err error) bool {
+	if ctx.Err() != nil || !IsRetryable(err) || i == len(p.providers)-1 {
+		return false
+	}
+	slog.Warn("llm.fallback.next",
+		"from", p.providers[i].Name(),
+		"to", p.providers[i+1].Name(),
+		"error", err,
+	)
+	return true
+}
+
+// logServed logs when a request was served by a fallback rather than the primary.
+func (p *fallbackProvider) logServed(i int) {
+	if i == 0 {
+		return
+	}
+	slog.Info("llm.fallback.served",
+		"primary", p.providers[0].Name(),
+		"model", p.providers[i].Name(),
+		"fallback_index", i,
+	)
+}
+
+// served records in the message metadata which model produced resp.
+func (p *fallbackProvider) served(resp *blades.ModelResponse, i int) {
+	if resp == nil || resp.Message == nil {
+		return
+	}
+	if resp.Message.Metadata == nil {
+		resp.Message.Metadata = make(map[string]any)
+	}
+	resp.Message.Metadata[MetadataServedBy] = p.providers[i].Name()
+	resp.Message.Metadata[MetadataFallbackIndex] = i
+}
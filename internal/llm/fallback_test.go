@@ -0,0 +1,275 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/openai/openai-go/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+
+	"github.com/oneblade/config"
+)
+
+// stubProvider is a blades.ModelProvider returning canned responses or errors.
+type stubProvider struct {
+	name   string
+	err    error
+	chunks []string
+	// streamErr is yielded after chunks, if set.
+	streamErr error
+	calls     int
+	closed    bool
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &blades.ModelResponse{Message: blades.AssistantMessage("from " + p.name)}, nil
+}
+
+func (p *stubProvider) NewStreaming(ctx context.Context, req *blades.ModelRequest) blades.Generator[*blades.ModelResponse, error] {
+	return func(yield func(*blades.ModelResponse, error) bool) {
+		p.calls++
+		if p.err != nil {
+			yield(nil, p.err)
+			return
+		}
+		for _, chunk := range p.chunks {
+			if !yield(&blades.ModelResponse{Message: blades.AssistantMessage(chunk)}, nil) {
+				return
+			}
+		}
+		if p.streamErr != nil {
+			yield(nil, p.streamErr)
+		}
+	}
+}
+
+func (p *stubProvider) Close() error {
+	p.closed = true
+	return nil
+}
+
+func openaiStatusError(code int) error {
+	return &openai.Error{StatusCode: code}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "openai 429", err: openaiStatusError(http.StatusTooManyRequests), want: true},
+		{name: "openai 503 wrapped", err: fmt.Errorf("generate: %w", openaiStatusError(http.StatusServiceUnavailable)), want: true},
+		{name: "openai 400", err: openaiStatusError(http.StatusBadRequest), want: false},
+		{name: "openai 401", err: openaiStatusError(http.StatusUnauthorized), want: false},
+		{name: "genai 500", err: genai.APIError{Code: http.StatusInternalServerError}, want: true},
+		{name: "genai 404", err: genai.APIError{Code: http.StatusNotFound}, want: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{name: "caller canceled", err: context.Canceled, want: false},
+		{name: "plain error", err: errors.New("boom"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestFallbackProvider_Generate(t *testing.T) {
+	t.Run("primary succeeds", func(t *testing.T) {
+		primary := &stubProvider{name: "gpt-4o"}
+		backup := &stubProvider{name: "claude"}
+		p := NewFallbackProvider(primary, backup)
+
+		resp, err := p.Generate(context.Background(), &blades.ModelRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "gpt-4o", resp.Message.Metadata[MetadataServedBy])
+		assert.Equal(t, 0, resp.Message.Metadata[MetadataFallbackIndex])
+		assert.Equal(t, 0, backup.calls)
+	})
+
+	t.Run("retryable error falls back", func(t *testing.T) {
+		primary := &stubProvider{name: "gpt-4o", err: openaiStatusError(http.StatusServiceUnavailable)}
+		backup := &stubProvider{name: "claude"}
+		p := NewFallbackProvider(primary, backup)
+
+		resp, err := p.Generate(context.Background(), &blades.ModelRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "from claude", resp.Message.Text())
+		assert.Equal(t, "claude", resp.Message.Metadata[MetadataServedBy])
+		assert.Equal(t, 1, resp.Message.Metadata[MetadataFallbackIndex])
+		assert.Equal(t, "gpt-4o", p.Name())
+	})
+
+	t.Run("non-retryable error stops the chain", func(t *testing.T) {
+		primary := &stubProvider{name: "gpt-4o", err: openaiStatusError(http.StatusBadRequest)}
+		backup := &stubProvider{name: "claude"}
+		p := NewFallbackProvider(primary, backup)
+
+		_, err := p.Generate(context.Background(), &blades.ModelRequest{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "gpt-4o")
+		assert.Equal(t, 0, backup.calls)
+	})
+
+	t.Run("all providers fail", func(t *testing.T) {
+		primary := &stubProvider{name: "gpt-4o", err: openaiStatusError(http.StatusTooManyRequests)}
+		backup := &stubProvider{name: "claude", err: context.DeadlineExceeded}
+		p := NewFallbackProvider(primary, backup)
+
+		_, err := p.Generate(context.Background(), &blades.ModelRequest{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "gpt-4o")
+		assert.Contains(t, err.Error(), "claude")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestFallbackProvider_NewStreaming(t *testing.T) {
+	collect := func(p blades.ModelProvider) ([]string, []any, error) {
+		var texts []string
+		var servedBy []any
+		for resp, err := range p.NewStreaming(context.Background(), &blades.ModelRequest{}) {
+			if err != nil {
+				return texts, servedBy, err
+			}
+			texts = append(texts, resp.Message.Text())
+			servedBy = append(servedBy, resp.Message.Metadata[MetadataServedBy])
+		}
+		return texts, servedBy, nil
+	}
+
+	t.Run("falls back before the first chunk", func(t *testing.T) {
+		primary := &stubProvider{name: "gpt-4o", err: openaiStatusError(http.StatusBadGateway)}
+		backup := &stubProvider{name: "claude", chunks: []string{"a", "b"}}
+
+		texts, servedBy, err := collect(NewFallbackProvider(primary, backup))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, texts)
+		assert.Equal(t, []any{"claude", "claude"}, servedBy)
+	})
+
+	t.Run("errors after the first chunk are passed through", func(t *testing.T) {
+		primary := &stubProvider{
+			name:      "gpt-4o",
+			chunks:    []string{"a"},
+			streamErr: openaiStatusError(http.StatusBadGateway),
+		}
+		backup := &stubProvider{name: "claude", chunks: []string{"b"}}
+
+		texts, _, err := collect(NewFallbackProvider(primary, backup))
+		require.Error(t, err)
+		assert.Equal(t, []string{"a"}, texts)
+		assert.Equal(t, 0, backup.calls)
+	})
+}
+
+func TestFallbackProvider_Close(t *testing.T) {
+	primary := &stubProvider{name: "gpt-4o"}
+	backup := &stubProvider{name: "claude"}
+	p := NewFallbackProvider(primary, backup)
+
+	require.NoError(t, p.(interface{ Close() error }).Close())
+	assert.True(t, primary.closed)
+	assert.True(t, backup.closed)
+}
+
+func TestFactory_Build_Fallbacks(t *testing.T) {
+	f := NewFactory()
+	cfg := config.AgentLLMConfig{
+		Provider: "openai",
+		Model:    "gpt-4o",
+		APIKey:   "sk-test",
+		Fallbacks: []config.AgentLLMConfig{
+			{Provider: "anthropic", Model: "claude-sonnet-4", APIKey: "ak-test"},
+		},
+	}
+
+	t.Run("builds a fallback chain", func(t *testing.T) {
+		m, err := f.Build(context.Background(), cfg)
+		require.NoError(t, err)
+		chain, ok := m.(*fallbackProvider)
+		require.True(t, ok)
+		require.Len(t, chain.providers, 2)
+		assert.Equal(t, "gpt-4o", chain.Name())
+	})
+
+	t.Run("invalid fallback is rejected", func(t *testing.T) {
+		invalid := cfg
+		invalid.Fallbacks = []config.AgentLLMConfig{{Provider: "anthropic"}}
+		_, err := f.Build(context.Background(), invalid)
+		require.Error(t, err)
+	})
+
+	t.Run("nested fallbacks are rejected", func(t *testing.T) {
+		nested := cfg
+		nested.Fallbacks = []config.AgentLLMConfig{{
+			Provider:  "anthropic",
+			Model:     "claude-sonnet-4",
+			APIKey:    "ak-test",
+			Fallbacks: []config.AgentLLMConfig{{Provider: "openai", Model: "gpt-4o-mini", APIKey: "sk-test"}},
+		}}
+		_, err := f.Build(context.Background(), nested)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "nested fallbacks")
+	})
+}
+
+// TestFactory_Build_FallbackWithoutPrimaryRetries 主模型出现可重试错误时立即切换，只有链尾的模型重试
+func TestFactory_Build_FallbackWithoutPrimaryRetries(t *testing.T) {
+	shortBackoff(t)
+	var primaryCalls, fallbackCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fallbackCalls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id": "chatcmpl-1", "object": "chat.completion", "created": 1, "model": "gpt-4o-mini",
+  "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "ok"}}]}`)
+	}))
+	defer fallback.Close()
+
+	retries := 2
+	m, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+		Provider:   "openai",
+		Model:      "gpt-4o",
+		APIKey:     "sk-test",
+		BaseURL:    primary.URL,
+		MaxRetries: &retries,
+		Fallbacks: []config.AgentLLMConfig{
+			{Provider: "openai", Model: "gpt-4o-mini", APIKey: "sk-test", BaseURL: fallback.URL, MaxRetries: &retries},
+		},
+	})
+	require.NoError(t, err)
+
+	resp, err := m.Generate(context.Background(), &blades.ModelRequest{
+		Messages: []*blades.Message{blades.UserMessage("hi")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Message.Text())
+	assert.Equal(t, int32(1), primaryCalls.Load(), "主模型不重试")
+	assert.Equal(t, int32(2), fallbackCalls.Load(), "链尾模型按 max_retries 重试")
+	assert.Equal(t, 1, resp.Message.Metadata[MetadataFallbackIndex])
+}
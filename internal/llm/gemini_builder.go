@@ -29,7 +29,7 @@ func (b *geminiBuilder) GetModel(cfg *config.AgentLLMConfig) string {
 }
 
 func (b *geminiBuilder) GetBaseURL(cfg *config.AgentLLMConfig) string {
-	return cfg.BaseURL
+	return resolveBaseURL(cfg, b.baseURL)
 }
 
 func (b *geminiBuilder) Build(ctx context.Context, cfg *config.AgentLLMConfig) (blades.ModelProvider, error) {
@@ -38,12 +38,44 @@ func (b *geminiBuilder) Build(ctx context.Context, cfg *config.AgentLLMConfig) (
 		return nil, err
 	}
 
-	var opts gemini.Config
+	opts := geminiConfig(cfg)
 	opts.ClientConfig = genai.ClientConfig{
 		APIKey: apiKey,
+		// 显式指定 Gemini API，避免 GOOGLE_GENAI_USE_VERTEXAI 环境变量切换到 Vertex
+		Backend: genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{
+			BaseURL: cfg.BaseURL,
+		},
 	}
-	opts.MaxOutputTokens = int32(*cfg.MaxTokens)
-	opts.Temperature = float32(*cfg.Temperature)
 
 	return gemini.NewModel(ctx, b.GetModel(cfg), opts)
 }
+
+// geminiConfig 将采样参数写入 gemini.Config，gemini / vertex 共用
+func geminiConfig(cfg *config.AgentLLMConfig) gemini.Config {
+	opts := gemini.Config{
+		MaxOutputTokens: int32(*cfg.MaxTokens),
+		Temperature:     float32(*cfg.Temperature),
+		StopSequences:   cfg.StopSequences,
+	}
+	if cfg.TopP != nil {
+		opts.TopP = float32(*cfg.TopP)
+	}
+	if cfg.TopK != nil {
+		opts.TopK = float32(*cfg.TopK)
+	}
+	if cfg.Seed != nil {
+		opts.Seed = int32(*cfg.Seed)
+	}
+	if cfg.PresencePenalty != nil {
+		opts.PresencePenalty = float32(*cfg.PresencePenalty)
+	}
+	if cfg.FrequencyPenalty != nil {
+		opts.FrequencyPenalty = float32(*cfg.FrequencyPenalty)
+	}
+	if cfg.ThinkingBudget != nil {
+		budget := int32(*cfg.ThinkingBudget)
+		opts.ThinkingConfig = &genai.ThinkingConfig{ThinkingBudget: &budget}
+	}
+	return opts
+}
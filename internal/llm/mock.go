@@ -0,0 +1,286 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/blades"
+
+	"github.com/oneblade/config"
+)
+
+// handoffToolName is the routing tool exposed by agent.NewRoutingAgent.
+// FixtureTurn.Handoff is shorthand for calling it.
+const handoffToolName = "handoff_to_agent"
+
+// Fixture is a scripted conversation served by the mock provider.
+//
+// Each request is answered by the first unused turn whose Match matches it;
+// a turn is used once unless Repeat is set. Agents built from the same
+// fixture file each get their own provider, so Match.Instruction is usually
+// enough to keep their turns apart.
+type Fixture struct {
+	Turns []FixtureTurn `json:"turns"`
+}
+
+// FixtureTurn is one scripted model response.
+type FixtureTurn struct {
+	Match FixtureMatch `json:"match,omitempty"`
+	// Text is returned as a completed assistant message.
+	Text string `json:"text,omitempty"`
+	// ToolCalls are returned as a tool message for the agent to execute.
+	ToolCalls []FixtureToolCall `json:"tool_calls,omitempty"`
+	// Handoff is shorthand for a handoff_to_agent tool call to this agent.
+	Handoff string `json:"handoff,omitempty"`
+	// Error makes the provider fail the request with this message.
+	Error string `json:"error,omitempty"`
+	// Repeat allows the turn to answer any number of requests.
+	Repeat bool `json:"repeat,omitempty"`
+}
+
+// FixtureMatch selects the requests a turn answers. Empty fields match anything.
+type FixtureMatch struct {
+	// Instruction must be a substring of the system instruction.
+	Instruction string `json:"instruction,omitempty"`
+	// LastMessage must be a substring of the last message (text or tool result).
+	LastMessage string `json:"last_message,omitempty"`
+	// AfterTool requires the last message to carry the result of this tool.
+	AfterTool string `json:"after_tool,omitempty"`
+	// RequestKey must equal RequestKey(req); set by recordings for exact replay.
+	RequestKey string `json:"request_key,omitempty"`
+}
+
+// FixtureToolCall is a scripted tool call.
+type FixtureToolCall struct {
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// LoadFixture reads a scripted fixture (.json) or a recording (.jsonl)
+// written by the recording provider.
+func LoadFixture(path string) (*Fixture, error) {
+	if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		return loadRecordingFixture(path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture %s: %w", path, err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parse fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// loadRecordingFixture turns recorded exchanges into exact-match turns.
+func loadRecordingFixture(path string) (*Fixture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var fixture Fixture
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var rec Recording
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("parse fixture %s line %d: %w", path, line, err)
+		}
+		turn := rec.Response
+		turn.Match = FixtureMatch{RequestKey: rec.RequestKey}
+		fixture.Turns = append(fixture.Turns, turn)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// mockProvider serves responses from a Fixture. It never touches the network.
+type mockProvider struct {
+	model string
+
+	mu      sync.Mutex
+	fixture *Fixture
+	used    []bool
+}
+
+// NewMockProvider returns a provider that answers from fixture.
+func NewMockProvider(model string, fixture *Fixture) blades.ModelProvider {
+	return &mockProvider{
+		model:   model,
+		fixture: fixture,
+		used:    make([]bool, len(fixture.Turns)),
+	}
+}
+
+// Name implements blades.ModelProvider.
+func (p *mockProvider) Name() string {
+	return p.model
+}
+
+// Generate implements blades.ModelProvider.
+func (p *mockProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	turn, err := p.next(req)
+	if err != nil {
+		return nil, err
+	}
+	if turn.Error != "" {
+		return nil, errors.New(turn.Error)
+	}
+	return &blades.ModelResponse{Message: turn.message()}, nil
+}
+
+// NewStreaming implements blades.ModelProvider by yielding the whole response at once.
+func (p *mockProvider) NewStreaming(ctx context.Context, req *blades.ModelRequest) blades.Generator[*blades.ModelResponse, error] {
+	return func(yield func(*blades.ModelResponse, error) bool) {
+		yield(p.Generate(ctx, req))
+	}
+}
+
+// next picks the turn answering req.
+func (p *mockProvider) next(req *blades.ModelRequest) (FixtureTurn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, turn := range p.fixture.Turns {
+		if p.used[i] || !turn.Match.matches(req) {
+			continue
+		}
+		if !turn.Repeat {
+			p.used[i] = true
+		}
+		return turn, nil
+	}
+	return FixtureTurn{}, fmt.Errorf("mock %s: no fixture turn matches request (last message: %q)",
+		p.model, truncate(lastMessageContent(req), 200))
+}
+
+func (m FixtureMatch) matches(req *blades.ModelRequest) bool {
+	if m.RequestKey != "" && m.RequestKey != RequestKey(req) {
+		return false
+	}
+	if m.Instruction != "" && (req.Instruction == nil || !strings.Contains(req.Instruction.Text(), m.Instruction)) {
+		return false
+	}
+	if m.LastMessage != "" && !strings.Contains(lastMessageContent(req), m.LastMessage) {
+		return false
+	}
+	if m.AfterTool != "" {
+		if len(req.Messages) == 0 {
+			return false
+		}
+		found := false
+		for _, part := range req.Messages[len(req.Messages)-1].Parts {
+			if tp, ok := part.(blades.ToolPart); ok && tp.Name == m.AfterTool {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// message builds the response message for the turn.
+func (t FixtureTurn) message() *blades.Message {
+	calls := t.ToolCalls
+	if t.Handoff != "" {
+		args, _ := json.Marshal(map[string]string{"agentName": t.Handoff})
+		calls = append(calls, FixtureToolCall{Name: handoffToolName, Arguments: args})
+	}
+
+	msg := blades.NewAssistantMessage(blades.StatusCompleted)
+	if len(calls) == 0 {
+		msg.Parts = []blades.Part{blades.TextPart{Text: t.Text}}
+		return msg
+	}
+
+	msg.Role = blades.RoleTool
+	if t.Text != "" {
+		msg.Parts = append(msg.Parts, blades.TextPart{Text: t.Text})
+	}
+	for i, call := range calls {
+		id := call.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d", i+1)
+		}
+		args := string(call.Arguments)
+		if args == "" {
+			args = "{}"
+		}
+		msg.Parts = append(msg.Parts, blades.ToolPart{ID: id, Name: call.Name, Request: args})
+	}
+	return msg
+}
+
+// lastMessageContent returns the text and tool results of the last message.
+func lastMessageContent(req *blades.ModelRequest) string {
+	if len(req.Messages) == 0 {
+		return ""
+	}
+	return messageContent(req.Messages[len(req.Messages)-1])
+}
+
+// messageContent flattens the text and tool results of m.
+func messageContent(m *blades.Message) string {
+	var b strings.Builder
+	for _, part := range m.Parts {
+		switch v := part.(type) {
+		case blades.TextPart:
+			b.WriteString(v.Text)
+		case blades.ToolPart:
+			b.WriteString(v.Response)
+		}
+	}
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// mockBuilder builds mock providers from AgentLLMConfig.Fixture.
+type mockBuilder struct{}
+
+func newMockBuilder() ModelBuilder {
+	return &mockBuilder{}
+}
+
+func (b *mockBuilder) GetModel(cfg *config.AgentLLMConfig) string {
+	return cfg.Model
+}
+
+func (b *mockBuilder) GetBaseURL(cfg *config.AgentLLMConfig) string {
+	return ""
+}
+
+func (b *mockBuilder) Build(ctx context.Context, cfg *config.AgentLLMConfig) (blades.ModelProvider, error) {
+	if cfg.Fixture == "" {
+		return nil, fmt.Errorf("mock provider requires fixture")
+	}
+	fixture, err := LoadFixture(cfg.Fixture)
+	if err != nil {
+		return nil, err
+	}
+	return NewMockProvider(b.GetModel(cfg), fixture), nil
+}
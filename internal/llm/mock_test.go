@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/config"
+)
+
+func writeFixture(t *testing.T, fixture Fixture) string {
+	t.Helper()
+	data, err := json.Marshal(fixture)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestMockProvider_Generate(t *testing.T) {
+	fixture := &Fixture{Turns: []FixtureTurn{
+		{
+			Match:     FixtureMatch{LastMessage: "检查 CPU"},
+			ToolCalls: []FixtureToolCall{{Name: "query_metrics", Arguments: json.RawMessage(`{"metric":"cpu"}`)}},
+		},
+		{Match: FixtureMatch{AfterTool: "query_metrics"}, Text: "CPU 正常"},
+		{Match: FixtureMatch{Instruction: "router"}, Handoff: "report_agent"},
+		{Match: FixtureMatch{LastMessage: "fail"}, Error: "boom"},
+		{Match: FixtureMatch{LastMessage: "ping"}, Text: "pong", Repeat: true},
+	}}
+	p := NewMockProvider("mock-model", fixture)
+	ctx := context.Background()
+
+	t.Run("tool call", func(t *testing.T) {
+		resp, err := p.Generate(ctx, &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("检查 CPU")}})
+		require.NoError(t, err)
+		assert.Equal(t, blades.RoleTool, resp.Message.Role)
+		require.Len(t, resp.Message.Parts, 1)
+		part := resp.Message.Parts[0].(blades.ToolPart)
+		assert.Equal(t, "query_metrics", part.Name)
+		assert.JSONEq(t, `{"metric":"cpu"}`, part.Request)
+		assert.NotEmpty(t, part.ID)
+	})
+
+	t.Run("after tool", func(t *testing.T) {
+		toolResult := &blades.Message{Role: blades.RoleTool, Parts: []blades.Part{
+			blades.ToolPart{ID: "call_1", Name: "query_metrics", Response: "42%"},
+		}}
+		resp, err := p.Generate(ctx, &blades.ModelRequest{Messages: []*blades.Message{toolResult}})
+		require.NoError(t, err)
+		assert.Equal(t, blades.RoleAssistant, resp.Message.Role)
+		assert.Equal(t, blades.StatusCompleted, resp.Message.Status)
+		assert.Equal(t, "CPU 正常", resp.Message.Text())
+	})
+
+	t.Run("handoff shorthand", func(t *testing.T) {
+		resp, err := p.Generate(ctx, &blades.ModelRequest{Instruction: blades.SystemMessage("you are a router")})
+		require.NoError(t, err)
+		part := resp.Message.Parts[0].(blades.ToolPart)
+		assert.Equal(t, handoffToolName, part.Name)
+		assert.JSONEq(t, `{"agentName":"report_agent"}`, part.Request)
+	})
+
+	t.Run("scripted error", func(t *testing.T) {
+		_, err := p.Generate(ctx, &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("fail")}})
+		require.EqualError(t, err, "boom")
+	})
+
+	t.Run("turns are consumed unless repeated", func(t *testing.T) {
+		req := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("检查 CPU")}}
+		_, err := p.Generate(ctx, req)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no fixture turn matches")
+
+		for range 2 {
+			resp, err := p.Generate(ctx, &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("ping")}})
+			require.NoError(t, err)
+			assert.Equal(t, "pong", resp.Message.Text())
+		}
+	})
+}
+
+func TestMockProvider_NewStreaming(t *testing.T) {
+	p := NewMockProvider("mock-model", &Fixture{Turns: []FixtureTurn{{Text: "hello"}}})
+
+	var texts []string
+	for resp, err := range p.NewStreaming(context.Background(), &blades.ModelRequest{}) {
+		require.NoError(t, err)
+		texts = append(texts, resp.Message.Text())
+	}
+	assert.Equal(t, []string{"hello"}, texts)
+}
+
+func TestFactory_Build_Mock(t *testing.T) {
+	f := NewFactory()
+
+	t.Run("fixture is required", func(t *testing.T) {
+		_, err := f.Build(context.Background(), config.AgentLLMConfig{Provider: "mock", Model: "mock"})
+		require.Error(t, err)
+	})
+
+	t.Run("missing fixture file", func(t *testing.T) {
+		_, err := f.Build(context.Background(), config.AgentLLMConfig{
+			Provider: "mock",
+			Model:    "mock",
+			Fixture:  filepath.Join(t.TempDir(), "missing.json"),
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("record and replay", func(t *testing.T) {
+		fixture := writeFixture(t, Fixture{Turns: []FixtureTurn{
+			{Match: FixtureMatch{LastMessage: "a"}, Text: "answer a"},
+			{Match: FixtureMatch{LastMessage: "b"}, ToolCalls: []FixtureToolCall{{ID: "call_b", Name: "lookup", Arguments: json.RawMessage(`{"q":"b"}`)}}},
+		}})
+		recording := filepath.Join(t.TempDir(), "session.jsonl")
+
+		recorder, err := f.Build(context.Background(), config.AgentLLMConfig{
+			Provider: "mock",
+			Model:    "mock",
+			Fixture:  fixture,
+			Record:   recording,
+		})
+		require.NoError(t, err)
+
+		reqA := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("a")}}
+		reqB := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("b")}}
+		_, err = recorder.Generate(context.Background(), reqA)
+		require.NoError(t, err)
+		for _, err := range recorder.NewStreaming(context.Background(), reqB) {
+			require.NoError(t, err)
+		}
+
+		// 回放按请求内容精确匹配，与录制顺序无关
+		replay, err := f.Build(context.Background(), config.AgentLLMConfig{
+			Provider: "mock",
+			Model:    "mock",
+			Fixture:  recording,
+		})
+		require.NoError(t, err)
+
+		resp, err := replay.Generate(context.Background(), reqB)
+		require.NoError(t, err)
+		part := resp.Message.Parts[0].(blades.ToolPart)
+		assert.Equal(t, "call_b", part.ID)
+		assert.JSONEq(t, `{"q":"b"}`, part.Request)
+
+		resp, err = replay.Generate(context.Background(), reqA)
+		require.NoError(t, err)
+		assert.Equal(t, "answer a", resp.Message.Text())
+	})
+}
+
+func TestRequestKey(t *testing.T) {
+	a := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("hi")}}
+	b := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("hi")}}
+	c := &blades.ModelRequest{Messages: []*blades.Message{blades.UserMessage("bye")}}
+
+	// 消息 ID 不同不影响 key
+	assert.Equal(t, RequestKey(a), RequestKey(b))
+	assert.NotEqual(t, RequestKey(a), RequestKey(c))
+}
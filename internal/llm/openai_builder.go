@@ -6,6 +6,8 @@ import (
 	"github.com/go-kratos/blades"
 	"github.com/go-kratos/blades/contrib/openai"
 	"github.com/oneblade/config"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/shared"
 )
 
 type openaiBuilder struct {
@@ -43,8 +45,29 @@ func (b *openaiBuilder) Build(ctx context.Context, cfg *config.AgentLLMConfig) (
 		BaseURL: baseURL,
 	}
 
-	opts.MaxOutputTokens = int64(*cfg.MaxTokens)
-	opts.Temperature = *cfg.Temperature
+	applyOpenAITuning(&opts, cfg)
 
 	return openai.NewModel(b.GetModel(cfg), opts), nil
 }
+
+// applyOpenAITuning 将采样参数写入 openai.Config，openai / azure_openai / ollama / openai_compatible 共用
+func applyOpenAITuning(opts *openai.Config, cfg *config.AgentLLMConfig) {
+	// 重试统一由 resilientProvider 负责；SDK 默认的 2 次重试会与其叠加并占用单次调用的超时
+	opts.RequestOptions = append(opts.RequestOptions, option.WithMaxRetries(0))
+	opts.MaxOutputTokens = int64(*cfg.MaxTokens)
+	opts.Temperature = *cfg.Temperature
+	if cfg.TopP != nil {
+		opts.TopP = *cfg.TopP
+	}
+	if cfg.Seed != nil {
+		opts.Seed = *cfg.Seed
+	}
+	if cfg.PresencePenalty != nil {
+		opts.PresencePenalty = *cfg.PresencePenalty
+	}
+	if cfg.FrequencyPenalty != nil {
+		opts.FrequencyPenalty = *cfg.FrequencyPenalty
+	}
+	opts.StopSequences = cfg.StopSequences
+	opts.ReasoningEffort = shared.ReasoningEffort(cfg.ReasoningEffort)
+}
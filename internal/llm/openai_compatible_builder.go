@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/contrib/openai"
+	"github.com/openai/openai-go/v3/option"
+
+	"github.com/oneblade/config"
+)
+
+// openaiCompatibleBuilder 构建 OpenAI Chat Completions 兼容服务（Ollama、vLLM、LM Studio 等）的模型
+//
+// 与 openaiBuilder 的区别：
+// - API Key 可选，本地服务通常不需要鉴权。
+// - 不继承 OPENAI_API_KEY / OPENAI_ORG_ID 等环境变量，避免把 OpenAI 凭据发送给自托管服务。
+type openaiCompatibleBuilder struct {
+	provider string
+	baseURL  string
+	model    string
+	apiKey   string
+}
+
+func newOllamaBuilder() ModelBuilder {
+	return &openaiCompatibleBuilder{
+		provider: "ollama",
+		baseURL:  "http://localhost:11434/v1",
+		model:    "llama3.1",
+		apiKey:   "OLLAMA_API_KEY",
+	}
+}
+
+func newOpenAICompatibleBuilder() ModelBuilder {
+	return &openaiCompatibleBuilder{
+		provider: "openai_compatible",
+		model:    "default",
+		apiKey:   "OPENAI_COMPATIBLE_API_KEY",
+	}
+}
+
+func (b *openaiCompatibleBuilder) GetModel(cfg *config.AgentLLMConfig) string {
+	return resolveModel(cfg, b.model)
+}
+
+func (b *openaiCompatibleBuilder) GetBaseURL(cfg *config.AgentLLMConfig) string {
+	return resolveBaseURL(cfg, b.baseURL)
+}
+
+func (b *openaiCompatibleBuilder) Build(ctx context.Context, cfg *config.AgentLLMConfig) (blades.ModelProvider, error) {
+	baseURL := b.GetBaseURL(cfg)
+	if baseURL == "" {
+		return nil, fmt.Errorf("%s provider requires base_url", b.provider)
+	}
+
+	opts := openai.Config{
+		APIKey:  resolveOptionalAPIKey(cfg, b.apiKey),
+		BaseURL: baseURL,
+		// openai-go 默认读取 OPENAI_* 环境变量，这里清除对应的请求头
+		RequestOptions: []option.RequestOption{
+			option.WithHeaderDel("authorization"),
+			option.WithHeaderDel("openai-organization"),
+			option.WithHeaderDel("openai-project"),
+		},
+	}
+
+	applyOpenAITuning(&opts, cfg)
+
+	return openai.NewModel(b.GetModel(cfg), opts), nil
+}
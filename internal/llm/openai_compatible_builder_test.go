@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/config"
+)
+
+// newChatCompletionsServer fakes an OpenAI-compatible /chat/completions
+// endpoint that always answers with a single tool call.
+func newChatCompletionsServer(t *testing.T, gotAuth *string, gotBody *map[string]any) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		*gotAuth = r.Header.Get("Authorization")
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, gotBody))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+  "id": "chatcmpl-1",
+  "object": "chat.completion",
+  "created": 1,
+  "model": "qwen2.5",
+  "choices": [{
+    "index": 0,
+    "finish_reason": "tool_calls",
+    "message": {
+      "role": "assistant",
+      "content": "",
+      "tool_calls": [{
+        "id": "call_1",
+        "type": "function",
+        "function": {"name": "query_prometheus", "arguments": "{\"query\":\"up\"}"}
+      }]
+    }
+  }],
+  "usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+}`)
+	}))
+}
+
+func TestOpenAICompatibleBuilder_Build(t *testing.T) {
+	// OpenAI credentials in the environment must never reach a self-hosted server.
+	t.Setenv("OPENAI_API_KEY", "sk-real-openai-key")
+	t.Setenv("OLLAMA_API_KEY", "")
+	t.Setenv("OPENAI_COMPATIBLE_API_KEY", "")
+
+	queryTool, err := tools.NewFunc("query_prometheus", "Run a PromQL query",
+		func(ctx context.Context, in struct {
+			Query string `json:"query"`
+		}) (string, error) {
+			return "", nil
+		})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		cfg      func(baseURL string) config.AgentLLMConfig
+		wantAuth string
+	}{
+		{
+			name: "ollama without api key",
+			cfg: func(baseURL string) config.AgentLLMConfig {
+				return config.AgentLLMConfig{Provider: "ollama", Model: "qwen2.5", BaseURL: baseURL}
+			},
+			wantAuth: "",
+		},
+		{
+			name: "openai_compatible with api key",
+			cfg: func(baseURL string) config.AgentLLMConfig {
+				return config.AgentLLMConfig{Provider: "openai_compatible", Model: "qwen2.5", BaseURL: baseURL, APIKey: "vllm-key"}
+			},
+			wantAuth: "Bearer vllm-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAuth string
+			var gotBody map[string]any
+			srv := newChatCompletionsServer(t, &gotAuth, &gotBody)
+			defer srv.Close()
+
+			m, err := NewFactory().Build(context.Background(), tt.cfg(srv.URL+"/v1"))
+			require.NoError(t, err)
+
+			resp, err := m.Generate(context.Background(), &blades.ModelRequest{
+				Messages: []*blades.Message{blades.UserMessage("is prometheus up?")},
+				Tools:    []tools.Tool{queryTool},
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantAuth, gotAuth)
+			assert.Equal(t, "qwen2.5", gotBody["model"])
+			assert.Len(t, gotBody["tools"], 1)
+
+			require.Equal(t, blades.RoleTool, resp.Message.Role)
+			require.Len(t, resp.Message.Parts, 1)
+			call, ok := resp.Message.Parts[0].(blades.ToolPart)
+			require.True(t, ok)
+			assert.Equal(t, "query_prometheus", call.Name)
+			assert.JSONEq(t, `{"query":"up"}`, call.Request)
+		})
+	}
+}
+
+func TestOpenAICompatibleBuilder_RequiresBaseURL(t *testing.T) {
+	_, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+		Provider: "openai_compatible",
+		Model:    "qwen2.5",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires base_url")
+}
@@ -0,0 +1,203 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/oneblade/config"
+)
+
+// tokenBucket is a token bucket refilled continuously at perMinute/60 per
+// second with a burst capacity of perMinute.
+//
+// Unlike golang.org/x/time/rate it allows charging after the fact, which is
+// needed to correct token estimates once the provider reports actual usage.
+// The balance may go negative; callers then wait until the debt is repaid.
+type tokenBucket struct {
+	mu        sync.Mutex
+	perMinute float64
+	tokens    float64
+	last      time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	return &tokenBucket{
+		perMinute: float64(perMinute),
+		tokens:    float64(perMinute),
+		last:      time.Now(),
+	}
+}
+
+// setRate changes the refill rate and capacity, keeping the current balance.
+func (b *tokenBucket) setRate(perMinute int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.perMinute = float64(perMinute)
+	b.tokens = math.Min(b.tokens, b.perMinute)
+}
+
+// wait blocks until n tokens are available and takes them.
+// n is capped at the bucket capacity so an oversized request cannot block forever.
+func (b *tokenBucket) wait(ctx context.Context, n float64) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.refill(now)
+		need := math.Min(n, b.perMinute)
+		if b.tokens >= need {
+			b.tokens -= need
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((need - b.tokens) / b.perMinute * float64(time.Minute))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// charge takes n tokens without waiting; a negative n returns tokens.
+func (b *tokenBucket) charge(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.tokens = math.Min(b.tokens-n, b.perMinute)
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last)
+	b.last = now
+	b.tokens = math.Min(b.perMinute, b.tokens+elapsed.Minutes()*b.perMinute)
+}
+
+// rateLimiter limits requests and tokens per minute for one API key.
+// A nil bucket means that dimension is not limited.
+type rateLimiter struct {
+	mu       sync.RWMutex
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+// configure applies the limits; zero disables a dimension.
+func (l *rateLimiter) configure(requestsPerMinute, tokensPerMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = reconfigure(l.requests, requestsPerMinute)
+	l.tokens = reconfigure(l.tokens, tokensPerMinute)
+}
+
+func reconfigure(b *tokenBucket, perMinute int) *tokenBucket {
+	switch {
+	case perMinute <= 0:
+		return nil
+	case b == nil:
+		return newTokenBucket(perMinute)
+	default:
+		b.setRate(perMinute)
+		return b
+	}
+}
+
+// acquire waits for one request slot and the estimated tokens of req.
+// It returns the estimate so the caller can settle it against actual usage.
+func (l *rateLimiter) acquire(ctx context.Context, req *blades.ModelRequest) (float64, error) {
+	l.mu.RLock()
+	requests, tokens := l.requests, l.tokens
+	l.mu.RUnlock()
+
+	if requests != nil {
+		if err := requests.wait(ctx, 1); err != nil {
+			return 0, err
+		}
+	}
+	if tokens == nil {
+		return 0, nil
+	}
+	estimate := estimateTokens(req)
+	if err := tokens.wait(ctx, estimate); err != nil {
+		return 0, err
+	}
+	return estimate, nil
+}
+
+// settle corrects the token bucket once the actual usage is known.
+func (l *rateLimiter) settle(estimate float64, usage blades.TokenUsage) {
+	l.mu.RLock()
+	tokens := l.tokens
+	l.mu.RUnlock()
+
+	if tokens == nil || usage.TotalTokens == 0 {
+		return
+	}
+	tokens.charge(float64(usage.TotalTokens) - estimate)
+}
+
+// estimateTokens roughly estimates the prompt size at 4 characters per token.
+func estimateTokens(req *blades.ModelRequest) float64 {
+	chars := 0
+	if req.Instruction != nil {
+		chars += len(req.Instruction.Text())
+	}
+	for _, m := range req.Messages {
+		chars += len(m.Text())
+	}
+	return math.Max(1, float64(chars)/4)
+}
+
+// limiterRegistry shares rate limiters between agents that use the same
+// provider account (provider + base URL + API key).
+type limiterRegistry struct {
+	mu       sync.Mutex
+	limiters map[string]*rateLimiter
+}
+
+func newLimiterRegistry() *limiterRegistry {
+	return &limiterRegistry{limiters: make(map[string]*rateLimiter)}
+}
+
+// get returns the shared limiter for cfg, or nil when cfg sets no limits.
+// When agents sharing a key configure different limits, the most recently
+// built config wins so that hot-reloaded limits take effect.
+func (r *limiterRegistry) get(cfg *config.AgentLLMConfig, baseURL string) *rateLimiter {
+	if cfg.RequestsPerMinute <= 0 && cfg.TokensPerMinute <= 0 {
+		return nil
+	}
+
+	key := limiterKey(cfg.Provider, baseURL, cfg.APIKey)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.limiters[key]
+	if !ok {
+		l = &rateLimiter{}
+		r.limiters[key] = l
+	}
+	l.configure(cfg.RequestsPerMinute, cfg.TokensPerMinute)
+	slog.Debug("llm.ratelimit.configure",
+		"provider", cfg.Provider,
+		"model", cfg.Model,
+		"requests_per_minute", cfg.RequestsPerMinute,
+		"tokens_per_minute", cfg.TokensPerMinute,
+	)
+	return l
+}
+
+// limiterKey hashes the account identity so API keys are never kept as map keys.
+// An empty apiKey means the key comes from the provider's environment variable,
+// which is shared by every agent of that provider.
+func limiterKey(provider, baseURL, apiKey string) string {
+	sum := sha256.Sum256([]byte(provider + "\x00" + baseURL + "\x00" + apiKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,187 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/blades"
+)
+
+// Recording is one captured request/response exchange, stored as a JSONL line.
+// A recording file can be replayed with the mock provider (fixture = "x.jsonl").
+type Recording struct {
+	Time       time.Time         `json:"time"`
+	Model      string            `json:"model"`
+	RequestKey string            `json:"request_key"`
+	Request    RecordedRequest   `json:"request"`
+	Response   FixtureTurn       `json:"response"`
+	Usage      blades.TokenUsage `json:"usage,omitempty"`
+}
+
+// RecordedRequest is a JSON-friendly view of blades.ModelRequest
+// (blades messages hold interface-typed parts that do not unmarshal).
+type RecordedRequest struct {
+	Instruction string            `json:"instruction,omitempty"`
+	Messages    []RecordedMessage `json:"messages"`
+	Tools       []string          `json:"tools,omitempty"`
+}
+
+// RecordedMessage is a JSON-friendly view of blades.Message.
+type RecordedMessage struct {
+	Role      blades.Role       `json:"role"`
+	Author    string            `json:"author,omitempty"`
+	Text      string            `json:"text,omitempty"`
+	ToolCalls []blades.ToolPart `json:"tool_calls,omitempty"`
+}
+
+// RequestKey identifies a request by its instruction and messages, ignoring
+// message IDs and other per-run noise, so a recorded exchange can be found
+// again on replay.
+func RequestKey(req *blades.ModelRequest) string {
+	data, _ := json.Marshal(recordRequest(req))
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func recordRequest(req *blades.ModelRequest) RecordedRequest {
+	rec := RecordedRequest{Messages: make([]RecordedMessage, 0, len(req.Messages))}
+	if req.Instruction != nil {
+		rec.Instruction = req.Instruction.Text()
+	}
+	for _, m := range req.Messages {
+		rec.Messages = append(rec.Messages, recordMessage(m))
+	}
+	for _, t := range req.Tools {
+		rec.Tools = append(rec.Tools, t.Name())
+	}
+	return rec
+}
+
+func recordMessage(m *blades.Message) RecordedMessage {
+	rec := RecordedMessage{Role: m.Role, Author: m.Author, Text: m.Text()}
+	for _, part := range m.Parts {
+		if tp, ok := part.(blades.ToolPart); ok {
+			rec.ToolCalls = append(rec.ToolCalls, tp)
+		}
+	}
+	return rec
+}
+
+// recordTurn converts a model response into a replayable fixture turn.
+func recordTurn(resp *blades.ModelResponse, err error) FixtureTurn {
+	if err != nil {
+		return FixtureTurn{Error: err.Error()}
+	}
+	turn := FixtureTurn{Text: resp.Message.Text()}
+	for _, part := range resp.Message.Parts {
+		if tp, ok := part.(blades.ToolPart); ok {
+			turn.ToolCalls = append(turn.ToolCalls, FixtureToolCall{
+				ID:        tp.ID,
+				Name:      tp.Name,
+				Arguments: json.RawMessage(tp.Request),
+			})
+		}
+	}
+	return turn
+}
+
+// recordingProvider passes requests through to next and appends every
+// exchange to a JSONL file.
+//
+// Recordings contain prompts and tool results verbatim; treat them like logs.
+type recordingProvider struct {
+	next blades.ModelProvider
+	path string
+	mu   sync.Mutex
+}
+
+// NewRecordingProvider wraps next so that exchanges are appended to path.
+func NewRecordingProvider(next blades.ModelProvider, path string) blades.ModelProvider {
+	return &recordingProvider{next: next, path: path}
+}
+
+// Name implements blades.ModelProvider.
+func (p *recordingProvider) Name() string {
+	return p.next.Name()
+}
+
+// Generate implements blades.ModelProvider.
+func (p *recordingProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	resp, err := p.next.Generate(ctx, req)
+	p.record(req, resp, err)
+	return resp, err
+}
+
+// NewStreaming implements blades.ModelProvider. The last chunk is recorded,
+// matching how agents treat the final streamed message.
+func (p *recordingProvider) NewStreaming(ctx context.Context, req *blades.ModelRequest) blades.Generator[*blades.ModelResponse, error] {
+	return func(yield func(*blades.ModelResponse, error) bool) {
+		var (
+			last    *blades.ModelResponse
+			lastErr error
+		)
+		defer func() {
+			if last != nil || lastErr != nil {
+				p.record(req, last, lastErr)
+			}
+		}()
+		for resp, err := range p.next.NewStreaming(ctx, req) {
+			if err != nil {
+				lastErr = err
+			} else {
+				last = resp
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// Close closes the wrapped provider if it implements io.Closer.
+func (p *recordingProvider) Close() error {
+	if closer, ok := p.next.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (p *recordingProvider) record(req *blades.ModelRequest, resp *blades.ModelResponse, err error) {
+	rec := Recording{
+		Time:       time.Now().UTC(),
+		Model:      p.next.Name(),
+		RequestKey: RequestKey(req),
+		Request:    recordRequest(req),
+		Response:   recordTurn(resp, err),
+	}
+	if err == nil && resp != nil && resp.Message != nil {
+		rec.Usage = resp.Message.TokenUsage
+	}
+	if writeErr := p.append(rec); writeErr != nil {
+		slog.Warn("llm.record.write_failed", "path", p.path, "error", writeErr)
+	}
+}
+
+func (p *recordingProvider) append(rec Recording) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode recording: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f, err := os.OpenFile(p.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -1,31 +1,88 @@
 package llm
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/go-kratos/blades"
+
+	"github.com/oneblade/config"
 )
 
-// ModelRegistry manages the lifecycle and access to model providers
+// ModelRegistry manages the lifecycle and access to model providers.
+//
+// Several names (agents, model profiles) may share one provider: a provider
+// is closed only once no name refers to it any more.
 type ModelRegistry struct {
 	mu     sync.RWMutex
 	models map[string]blades.ModelProvider
+	// keys records the ModelKey each name was registered with, so that
+	// identical configs can reuse the same provider.
+	keys map[string]string
+
+	// seq numbers the next run and inflight holds the runs not yet
+	// released, see Acquire.
+	seq      uint64
+	inflight map[uint64]struct{}
+	// pending holds replaced or removed models waiting for earlier runs
+	// to finish before they are closed.
+	pending []*pendingModels
+}
+
+// pendingModels is a set of stale models closed once every run numbered
+// below before has been released.
+type pendingModels struct {
+	models map[string]blades.ModelProvider
+	before uint64
 }
 
+// DrainTimeout is how long stale models wait for in-flight runs before
+// they are closed anyway, so that a stuck run cannot hold a client forever.
+var DrainTimeout = 5 * time.Minute
+
 // NewRegistry creates a new ModelRegistry
 func NewModelRegistry() *ModelRegistry {
 	return &ModelRegistry{
-		models: make(map[string]blades.ModelProvider),
+		models:   make(map[string]blades.ModelProvider),
+		keys:     make(map[string]string),
+		inflight: make(map[uint64]struct{}),
 	}
 }
 
+// ModelKey identifies an LLM config, so that agents configured identically
+// (provider, model, credentials, tuning, ...) can share one client. The key
+// is a hash, so API keys are never kept in plain text.
+func ModelKey(cfg config.AgentLLMConfig) string {
+	data, _ := json.Marshal(cfg)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ProfileName returns the registry name of the [models.<name>] profile.
+// The "models." prefix keeps profiles apart from agent names.
+func ProfileName(name string) string {
+	return "models." + name
+}
+
 // Register registers a model provider with a given name
 func (r *ModelRegistry) Register(name string, model blades.ModelProvider) {
+	r.RegisterKeyed(name, "", model)
+}
+
+// RegisterKeyed registers a model provider built from the config identified
+// by key (see ModelKey); Lookup(key) then returns it.
+func (r *ModelRegistry) RegisterKeyed(name, key string, model blades.ModelProvider) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.models[name] = model
+	r.keys[name] = key
 }
 
 // Get retrieves a model provider by name
@@ -40,13 +97,183 @@ func (r *ModelRegistry) Get(name string) (blades.ModelProvider, error) {
 	return model, nil
 }
 
+// Lookup returns a registered provider built from the config identified by key.
+func (r *ModelRegistry) Lookup(key string) (blades.ModelProvider, bool) {
+	if key == "" {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for name, k := range r.keys {
+		if k == key {
+			return r.models[name], true
+		}
+	}
+	return nil, false
+}
+
+// Names returns the registered names.
+func (r *ModelRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.models))
+	for name := range r.models {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Clone returns a shallow copy of the registry, used to stage changes
+// (e.g. during config hot reload) without affecting the live registry
+func (r *ModelRegistry) Clone() *ModelRegistry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clone := NewModelRegistry()
+	for name, m := range r.models {
+		clone.models[name] = m
+		clone.keys[name] = r.keys[name]
+	}
+	return clone
+}
+
+// Acquire marks the start of a run that uses the registered models; call the
+// returned release (safe to call more than once) when the run finishes.
+// Models replaced or removed by a later Swap stay open until every run
+// acquired before the swap has been released.
+func (r *ModelRegistry) Acquire() (release func()) {
+	r.mu.Lock()
+	id := r.seq
+	r.seq++
+	r.inflight[id] = struct{}{}
+	r.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.inflight, id)
+			ready := r.drainedLocked()
+			r.mu.Unlock()
+			for _, p := range ready {
+				closeDeferred(p, "drained")
+			}
+		})
+	}
+}
+
+// drainedLocked removes and returns the pending sets whose runs have all
+// been released. The caller must hold the write lock.
+func (r *ModelRegistry) drainedLocked() []*pendingModels {
+	var ready []*pendingModels
+	r.pending = slices.DeleteFunc(r.pending, func(p *pendingModels) bool {
+		for id := range r.inflight {
+			if id < p.before {
+				return false
+			}
+		}
+		ready = append(ready, p)
+		return true
+	})
+	return ready
+}
+
+// closeDeferred closes stale models after the swap has returned, so errors
+// are only logged.
+func closeDeferred(p *pendingModels, reason string) {
+	if err := closeModels(p.models); err != nil {
+		slog.Warn("model.close.failed", "reason", reason, "error", err)
+		return
+	}
+	slog.Info("model.close.deferred", "models", len(p.models), "reason", reason)
+}
+
+// Swap atomically registers the updated models and removes the given names.
+// keys holds the ModelKey of each updated model (missing entries mean unkeyed).
+// Models no longer referenced by any name are closed right away when no run
+// is in flight; otherwise they are closed once the runs acquired before the
+// swap finish, or after DrainTimeout.
+func (r *ModelRegistry) Swap(updated map[string]blades.ModelProvider, keys map[string]string, removed []string) error {
+	r.mu.Lock()
+	previous := make(map[string]blades.ModelProvider, len(r.models))
+	for name, m := range r.models {
+		previous[name] = m
+	}
+	for name, m := range updated {
+		r.models[name] = m
+		r.keys[name] = keys[name]
+	}
+	for _, name := range removed {
+		delete(r.models, name)
+		delete(r.keys, name)
+	}
+	stale := unreferenced(previous, r.models)
+	if len(stale) == 0 || len(r.inflight) == 0 {
+		r.mu.Unlock()
+		return closeModels(stale)
+	}
+	p := &pendingModels{models: stale, before: r.seq}
+	r.pending = append(r.pending, p)
+	inflight := len(r.inflight)
+	r.mu.Unlock()
+
+	slog.Info("model.close.waiting", "models", len(stale), "inflight", inflight, "timeout", DrainTimeout)
+	time.AfterFunc(DrainTimeout, func() {
+		r.mu.Lock()
+		i := slices.Index(r.pending, p)
+		if i >= 0 {
+			r.pending = slices.Delete(r.pending, i, i+1)
+		}
+		r.mu.Unlock()
+		if i >= 0 {
+			closeDeferred(p, "timeout")
+		}
+	})
+	return nil
+}
+
 // Close closes all registered models that implement the Closer interface
 func (r *ModelRegistry) Close() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	errs := []error{closeModels(unreferenced(r.models, nil))}
+	// No longer wait for in-flight runs on shutdown.
+	for _, p := range r.pending {
+		errs = append(errs, closeModels(p.models))
+	}
+	r.pending = nil
+	return errors.Join(errs...)
+}
+
+// unreferenced returns the models in previous that are not referenced by
+// any name in current, each shared model listed once.
+func unreferenced(previous, current map[string]blades.ModelProvider) map[string]blades.ModelProvider {
+	stale := make(map[string]blades.ModelProvider)
+	for name, m := range previous {
+		if referenced(current, m) || referenced(stale, m) {
+			continue
+		}
+		stale[name] = m
+	}
+	return stale
+}
+
+func referenced(models map[string]blades.ModelProvider, m blades.ModelProvider) bool {
+	for _, other := range models {
+		if other == m {
+			return true
+		}
+	}
+	return false
+}
+
+// closeModels closes the models that implement the Closer interface
+func closeModels(models map[string]blades.ModelProvider) error {
 	var errs []error
-	for name, m := range r.models {
+	for name, m := range models {
 		if closer, ok := m.(interface{ Close() error }); ok {
 			if err := closer.Close(); err != nil {
 				errs = append(errs, fmt.Errorf("close model %s: %w", name, err))
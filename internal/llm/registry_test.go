@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/config"
+)
+
+// countingProvider counts Close calls, to check shared models are closed once.
+type countingProvider struct {
+	stubProvider
+	closes int
+}
+
+func (p *countingProvider) Close() error {
+	p.closes++
+	return nil
+}
+
+func TestModelKey(t *testing.T) {
+	base := config.AgentLLMConfig{Provider: "openai", Model: "gpt-4o", APIKey: "sk-secret"}
+
+	same := base
+	assert.Equal(t, ModelKey(base), ModelKey(same))
+
+	other := base
+	other.APIKey = "sk-other"
+	assert.NotEqual(t, ModelKey(base), ModelKey(other))
+
+	temperature := 0.2
+	tuned := base
+	tuned.Temperature = &temperature
+	assert.NotEqual(t, ModelKey(base), ModelKey(tuned))
+
+	assert.NotContains(t, ModelKey(base), "sk-secret")
+}
+
+func TestModelRegistry_Lookup(t *testing.T) {
+	r := NewModelRegistry()
+	shared := &stubProvider{name: "gpt-4o"}
+	r.RegisterKeyed("orchestrator", "k1", shared)
+	r.Register("unkeyed", &stubProvider{name: "other"})
+
+	m, ok := r.Lookup("k1")
+	require.True(t, ok)
+	assert.Same(t, shared, m)
+
+	_, ok = r.Lookup("k2")
+	assert.False(t, ok)
+	_, ok = r.Lookup("")
+	assert.False(t, ok, "unkeyed models are never shared")
+}
+
+func TestModelRegistry_Swap(t *testing.T) {
+	shared := &countingProvider{stubProvider: stubProvider{name: "shared"}}
+	old := &countingProvider{stubProvider: stubProvider{name: "old"}}
+
+	r := NewModelRegistry()
+	r.RegisterKeyed("orchestrator", "k1", shared)
+	r.RegisterKeyed("service_agent", "k1", shared)
+	r.RegisterKeyed("report_agent", "k2", old)
+
+	replacement := &countingProvider{stubProvider: stubProvider{name: "new"}}
+	err := r.Swap(
+		map[string]blades.ModelProvider{"orchestrator": replacement},
+		map[string]string{"orchestrator": "k3"},
+		[]string{"report_agent"},
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, shared.closes, "still referenced by service_agent")
+	assert.Equal(t, 1, old.closes)
+	assert.ElementsMatch(t, []string{"orchestrator", "service_agent"}, r.Names())
+
+	m, ok := r.Lookup("k3")
+	require.True(t, ok)
+	assert.Same(t, replacement, m)
+	_, ok = r.Lookup("k2")
+	assert.False(t, ok)
+}
+
+func TestModelRegistry_Close(t *testing.T) {
+	shared := &countingProvider{stubProvider: stubProvider{name: "shared"}}
+	single := &countingProvider{stubProvider: stubProvider{name: "single"}}
+
+	r := NewModelRegistry()
+	r.RegisterKeyed("orchestrator", "k1", shared)
+	r.RegisterKeyed("models.fast", "k1", shared)
+	r.RegisterKeyed("report_agent", "k2", single)
+
+	require.NoError(t, r.Close())
+	assert.Equal(t, 1, shared.closes)
+	assert.Equal(t, 1, single.closes)
+}
+
+// closableProvider fails calls after it has been closed, like an SDK client
+// whose connections were torn down.
+type closableProvider struct {
+	stubProvider
+	closed atomic.Bool
+}
+
+func (p *closableProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	if p.closed.Load() {
+		return nil, errors.New("client closed")
+	}
+	return p.stubProvider.Generate(ctx, req)
+}
+
+func (p *closableProvider) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+func TestModelRegistry_Swap_WaitsForInflight(t *testing.T) {
+	old := &closableProvider{stubProvider: stubProvider{name: "old"}}
+	r := NewModelRegistry()
+	r.Register("orchestrator", old)
+
+	// A run that started before the reload holds the old provider.
+	release := r.Acquire()
+	held, err := r.Get("orchestrator")
+	require.NoError(t, err)
+
+	replacement := &closableProvider{stubProvider: stubProvider{name: "new"}}
+	require.NoError(t, r.Swap(map[string]blades.ModelProvider{"orchestrator": replacement}, nil, nil))
+
+	// Runs started after the swap do not keep the old provider open.
+	later := r.Acquire()
+	defer later()
+
+	resp, err := held.Generate(context.Background(), &blades.ModelRequest{})
+	require.NoError(t, err, "held reference must keep working until the run finishes")
+	assert.Equal(t, "from old", resp.Message.Text())
+
+	release()
+	release()
+	assert.True(t, old.closed.Load())
+	assert.False(t, replacement.closed.Load())
+}
+
+func TestModelRegistry_Swap_DrainTimeout(t *testing.T) {
+	prev := DrainTimeout
+	DrainTimeout = 10 * time.Millisecond
+	t.Cleanup(func() { DrainTimeout = prev })
+
+	stale := &closableProvider{stubProvider: stubProvider{name: "old"}}
+	r := NewModelRegistry()
+	r.Register("orchestrator", stale)
+
+	release := r.Acquire()
+	defer release()
+	require.NoError(t, r.Swap(nil, nil, []string{"orchestrator"}))
+	assert.False(t, stale.closed.Load())
+	assert.Eventually(t, stale.closed.Load, time.Second, 5*time.Millisecond, "a stuck run must not hold the client forever")
+}
+
+func TestModelRegistry_Close_ClosesPending(t *testing.T) {
+	stale := &closableProvider{stubProvider: stubProvider{name: "old"}}
+	r := NewModelRegistry()
+	r.Register("orchestrator", stale)
+
+	release := r.Acquire()
+	defer release()
+	require.NoError(t, r.Swap(nil, nil, []string{"orchestrator"}))
+	require.NoError(t, r.Close())
+	assert.True(t, stale.closed.Load())
+}
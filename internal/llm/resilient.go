@@ -0,0 +1,198 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/go-kratos/blades"
+	"github.com/openai/openai-go/v3"
+)
+
+// Backoff bounds for retries; the actual delay is drawn uniformly from
+// [0, min(maxBackoff, baseBackoff*2^attempt)] ("full jitter").
+var (
+	baseBackoff = 500 * time.Millisecond
+	maxBackoff  = 20 * time.Second
+)
+
+// resilientProvider decorates a provider with a per-call timeout, retries
+// with jittered exponential backoff on retryable errors, and an optional
+// shared rate limiter.
+type resilientProvider struct {
+	next       blades.ModelProvider
+	timeout    time.Duration
+	maxRetries int
+	limiter    *rateLimiter
+}
+
+// Name implements blades.ModelProvider.
+func (p *resilientProvider) Name() string {
+	return p.next.Name()
+}
+
+// Generate implements blades.ModelProvider.
+func (p *resilientProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	for attempt := 0; ; attempt++ {
+		estimate, err := p.acquire(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, p.timeout)
+		resp, err := p.next.Generate(callCtx, req)
+		cancel()
+		if err == nil {
+			p.settle(estimate, resp)
+			return resp, nil
+		}
+		if !p.retry(ctx, attempt, err) {
+			return nil, err
+		}
+	}
+}
+
+// NewStreaming implements blades.ModelProvider.
+//
+// The timeout covers the whole stream. A failed stream is only retried if
+// nothing has been yielded to the caller yet.
+func (p *resilientProvider) NewStreaming(ctx context.Context, req *blades.ModelRequest) blades.Generator[*blades.ModelResponse, error] {
+	return func(yield func(*blades.ModelResponse, error) bool) {
+		for attempt := 0; ; attempt++ {
+			estimate, err := p.acquire(ctx, req)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			callCtx, cancel := context.WithTimeout(ctx, p.timeout)
+			started := false
+			var (
+				last      *blades.ModelResponse
+				streamErr error
+			)
+			for resp, err := range p.next.NewStreaming(callCtx, req) {
+				if err != nil && !started {
+					streamErr = err
+					break
+				}
+				if err == nil {
+					started = true
+					last = resp
+				}
+				if !yield(resp, err) {
+					cancel()
+					return
+				}
+			}
+			cancel()
+
+			if streamErr == nil {
+				p.settle(estimate, last)
+				return
+			}
+			if !p.retry(ctx, attempt, streamErr) {
+				yield(nil, streamErr)
+				return
+			}
+		}
+	}
+}
+
+// Close closes the wrapped provider if it implements io.Closer.
+func (p *resilientProvider) Close() error {
+	if closer, ok := p.next.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (p *resilientProvider) acquire(ctx context.Context, req *blades.ModelRequest) (float64, error) {
+	if p.limiter == nil {
+		return 0, nil
+	}
+	return p.limiter.acquire(ctx, req)
+}
+
+func (p *resilientProvider) settle(estimate float64, resp *blades.ModelResponse) {
+	if p.limiter == nil || resp == nil || resp.Message == nil {
+		return
+	}
+	p.limiter.settle(estimate, resp.Message.TokenUsage)
+}
+
+// retry reports whether the call should be retried after err and, if so,
+// sleeps for the backoff delay first.
+func (p *resilientProvider) retry(ctx context.Context, attempt int, err error) bool {
+	if ctx.Err() != nil || attempt >= p.maxRetries || !IsRetryable(err) {
+		return false
+	}
+
+	delay := backoff(attempt)
+	if after, ok := retryAfter(err); ok {
+		delay = max(delay, min(after, maxBackoff))
+	}
+	slog.Warn("llm.retry",
+		"model", p.next.Name(),
+		"attempt", attempt+1,
+		"max_retries", p.maxRetries,
+		"delay", delay,
+		"error", err,
+	)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// backoff returns the jittered delay before retry number attempt+1.
+func backoff(attempt int) time.Duration {
+	ceiling := min(maxBackoff, baseBackoff<<min(attempt, 16))
+	return rand.N(ceiling + 1)
+}
+
+// retryAfter reads the server's requested delay from provider errors:
+// retry-after-ms (milliseconds, sent by both SDK backends) takes precedence
+// over Retry-After, which may be either seconds or an HTTP date.
+func retryAfter(err error) (time.Duration, bool) {
+	var resp *http.Response
+	var openaiErr *openai.Error
+	var anthropicErr *anthropic.Error
+	switch {
+	case errors.As(err, &openaiErr):
+		resp = openaiErr.Response
+	case errors.As(err, &anthropicErr):
+		resp = anthropicErr.Response
+	}
+	if resp == nil {
+		return 0, false
+	}
+	return parseRetryAfter(resp.Header, time.Now())
+}
+
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
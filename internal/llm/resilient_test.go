@@ -0,0 +1,240 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/config"
+)
+
+// flakyProvider fails with errs in order, then succeeds.
+type flakyProvider struct {
+	errs  []error
+	calls int
+	usage blades.TokenUsage
+}
+
+func (p *flakyProvider) Name() string { return "flaky" }
+
+func (p *flakyProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	p.calls++
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		return nil, err
+	}
+	msg := blades.AssistantMessage("ok")
+	msg.TokenUsage = p.usage
+	return &blades.ModelResponse{Message: msg}, nil
+}
+
+func (p *flakyProvider) NewStreaming(ctx context.Context, req *blades.ModelRequest) blades.Generator[*blades.ModelResponse, error] {
+	return func(yield func(*blades.ModelResponse, error) bool) {
+		resp, err := p.Generate(ctx, req)
+		yield(resp, err)
+	}
+}
+
+// blockingProvider blocks until the call context is done.
+type blockingProvider struct{}
+
+func (blockingProvider) Name() string { return "blocking" }
+
+func (blockingProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (b blockingProvider) NewStreaming(ctx context.Context, req *blades.ModelRequest) blades.Generator[*blades.ModelResponse, error] {
+	return func(yield func(*blades.ModelResponse, error) bool) {
+		yield(b.Generate(ctx, req))
+	}
+}
+
+func shortBackoff(t *testing.T) {
+	oldBase, oldMax := baseBackoff, maxBackoff
+	baseBackoff, maxBackoff = time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() { baseBackoff, maxBackoff = oldBase, oldMax })
+}
+
+func TestResilientProvider_Generate(t *testing.T) {
+	shortBackoff(t)
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		next := &flakyProvider{errs: []error{
+			openaiStatusError(http.StatusTooManyRequests),
+			openaiStatusError(http.StatusBadGateway),
+		}}
+		p := &resilientProvider{next: next, timeout: time.Second, maxRetries: 2}
+
+		resp, err := p.Generate(context.Background(), &blades.ModelRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp.Message.Text())
+		assert.Equal(t, 3, next.calls)
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		next := &flakyProvider{errs: []error{
+			openaiStatusError(http.StatusServiceUnavailable),
+			openaiStatusError(http.StatusServiceUnavailable),
+		}}
+		p := &resilientProvider{next: next, timeout: time.Second, maxRetries: 1}
+
+		_, err := p.Generate(context.Background(), &blades.ModelRequest{})
+		require.Error(t, err)
+		assert.Equal(t, 2, next.calls)
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		next := &flakyProvider{errs: []error{openaiStatusError(http.StatusUnauthorized)}}
+		p := &resilientProvider{next: next, timeout: time.Second, maxRetries: 3}
+
+		_, err := p.Generate(context.Background(), &blades.ModelRequest{})
+		require.Error(t, err)
+		assert.Equal(t, 1, next.calls)
+	})
+
+	t.Run("enforces the timeout", func(t *testing.T) {
+		p := &resilientProvider{next: blockingProvider{}, timeout: 20 * time.Millisecond}
+
+		start := time.Now()
+		_, err := p.Generate(context.Background(), &blades.ModelRequest{})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestResilientProvider_NewStreaming(t *testing.T) {
+	shortBackoff(t)
+
+	next := &flakyProvider{errs: []error{openaiStatusError(http.StatusInternalServerError)}}
+	p := &resilientProvider{next: next, timeout: time.Second, maxRetries: 1}
+
+	var texts []string
+	for resp, err := range p.NewStreaming(context.Background(), &blades.ModelRequest{}) {
+		require.NoError(t, err)
+		texts = append(texts, resp.Message.Text())
+	}
+	assert.Equal(t, []string{"ok"}, texts)
+	assert.Equal(t, 2, next.calls)
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Run("waits when empty", func(t *testing.T) {
+		b := newTokenBucket(1)
+		require.NoError(t, b.wait(context.Background(), 1))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, b.wait(ctx, 1), context.DeadlineExceeded)
+	})
+
+	t.Run("charges actual usage", func(t *testing.T) {
+		b := newTokenBucket(100)
+		require.NoError(t, b.wait(context.Background(), 10))
+		// actual usage was 150 tokens instead of the 10 estimated
+		b.charge(140)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, b.wait(ctx, 1), context.DeadlineExceeded)
+	})
+}
+
+func TestLimiterRegistry(t *testing.T) {
+	r := newLimiterRegistry()
+	limited := func(apiKey string) *config.AgentLLMConfig {
+		return &config.AgentLLMConfig{Provider: "openai", Model: "gpt-4o", APIKey: apiKey, RequestsPerMinute: 60}
+	}
+
+	a := r.get(limited("key-a"), "https://api.openai.com/v1")
+	b := r.get(limited("key-a"), "https://api.openai.com/v1")
+	c := r.get(limited("key-b"), "https://api.openai.com/v1")
+	require.NotNil(t, a)
+	assert.Same(t, a, b)
+	assert.NotSame(t, a, c)
+
+	unlimited := &config.AgentLLMConfig{Provider: "openai", Model: "gpt-4o", APIKey: "key-a"}
+	assert.Nil(t, r.get(unlimited, "https://api.openai.com/v1"))
+}
+
+func TestFactory_Build_InvalidTimeout(t *testing.T) {
+	_, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+		Provider: "openai",
+		Model:    "gpt-4o",
+		APIKey:   "sk-test",
+		Timeout:  "soon",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid llm timeout")
+}
+
+// TestFactory_Build_NoSDKRetries 确认 SDK 不会在 resilientProvider 之内再次重试
+func TestFactory_Build_NoSDKRetries(t *testing.T) {
+	shortBackoff(t)
+	for _, provider := range []string{"openai", "anthropic"} {
+		t.Run(provider, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("Retry-After-Ms", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer srv.Close()
+
+			maxRetries := 1
+			m, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+				Provider:   provider,
+				Model:      "test-model",
+				APIKey:     "sk-test",
+				BaseURL:    srv.URL,
+				MaxRetries: &maxRetries,
+			})
+			require.NoError(t, err)
+
+			_, err = m.Generate(context.Background(), &blades.ModelRequest{
+				Messages: []*blades.Message{blades.UserMessage("hi")},
+			})
+			require.Error(t, err)
+			assert.Equal(t, int32(2), requests.Load(), "1 次调用 + 1 次 resilientProvider 重试")
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+		ok     bool
+	}{
+		{name: "seconds", header: map[string]string{"Retry-After": "3"}, want: 3 * time.Second, ok: true},
+		{name: "http date", header: map[string]string{"Retry-After": "Sun, 18 Oct 2026 08:00:05 GMT"}, want: 5 * time.Second, ok: true},
+		{name: "past http date", header: map[string]string{"Retry-After": "Sun, 18 Oct 2026 07:59:00 GMT"}, want: 0, ok: true},
+		{name: "milliseconds take precedence", header: map[string]string{"Retry-After-Ms": "1500", "Retry-After": "3"}, want: 1500 * time.Millisecond, ok: true},
+		{name: "fractional milliseconds", header: map[string]string{"retry-after-ms": "2.5"}, want: 2500 * time.Microsecond, ok: true},
+		{name: "negative seconds", header: map[string]string{"Retry-After": "-1"}},
+		{name: "invalid", header: map[string]string{"Retry-After": "soon"}},
+		{name: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+			got, ok := parseRetryAfter(header, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-kratos/blades"
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// MetadataStructuredOutput holds the validated JSON (json.RawMessage) of a
+// response to a request with an OutputSchema.
+const MetadataStructuredOutput = "structured_output"
+
+// defaultStructuredRepairs is how many times an invalid structured response
+// is sent back to the model for repair before giving up.
+const defaultStructuredRepairs = 2
+
+// nativeStructuredOutput reports whether the provider enforces
+// ModelRequest.OutputSchema itself (JSON mode with a schema). Other providers
+// get the schema as an instruction instead.
+func nativeStructuredOutput(provider string, req *blades.ModelRequest) bool {
+	switch provider {
+	case "openai", "azure_openai", "ollama":
+		return true
+	case "gemini", "vertex":
+		// Gemini rejects a JSON response schema combined with function calling.
+		return len(req.Tools) == 0
+	}
+	return false
+}
+
+// structuredProvider enforces ModelRequest.OutputSchema: responses are
+// validated against the schema and, when invalid, sent back to the model
+// with the validation error until they pass or repairs run out.
+//
+// Requests without an OutputSchema and tool-call responses pass through.
+type structuredProvider struct {
+	next       blades.ModelProvider
+	provider   string
+	maxRepairs int
+}
+
+// NewStructuredProvider wraps next, built for the given provider type, with
+// schema validation and repair retries.
+func NewStructuredProvider(next blades.ModelProvider, provider string) blades.ModelProvider {
+	return &structuredProvider{next: next, provider: provider, maxRepairs: defaultStructuredRepairs}
+}
+
+// Name implements blades.ModelProvider.
+func (p *structuredProvider) Name() string {
+	return p.next.Name()
+}
+
+// Generate implements blades.ModelProvider.
+func (p *structuredProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	if req.OutputSchema == nil {
+		return p.next.Generate(ctx, req)
+	}
+	return p.generate(ctx, req, p.next.Generate)
+}
+
+// NewStreaming implements blades.ModelProvider. Structured responses are
+// buffered and yielded once validated, since partial JSON is of no use to
+// readers; the last chunk is taken as the complete message.
+func (p *structuredProvider) NewStreaming(ctx context.Context, req *blades.ModelRequest) blades.Generator[*blades.ModelResponse, error] {
+	if req.OutputSchema == nil {
+		return p.next.NewStreaming(ctx, req)
+	}
+	return func(yield func(*blades.ModelResponse, error) bool) {
+		yield(p.generate(ctx, req, p.collect))
+	}
+}
+
+// Close closes the wrapped provider if it implements io.Closer.
+func (p *structuredProvider) Close() error {
+	if closer, ok := p.next.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (p *structuredProvider) collect(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	var last *blades.ModelResponse
+	for resp, err := range p.next.NewStreaming(ctx, req) {
+		if err != nil {
+			return nil, err
+		}
+		last = resp
+	}
+	if last == nil {
+		return nil, blades.ErrNoFinalResponse
+	}
+	return last, nil
+}
+
+func (p *structuredProvider) generate(ctx context.Context, req *blades.ModelRequest, call func(context.Context, *blades.ModelRequest) (*blades.ModelResponse, error)) (*blades.ModelResponse, error) {
+	resolved, err := req.OutputSchema.Resolve(nil)
+	if err != nil {
+		return nil, fmt.Errorf("resolve output schema: %w", err)
+	}
+
+	attempt := *req
+	attempt.Messages = append([]*blades.Message(nil), req.Messages...)
+	if !nativeStructuredOutput(p.provider, req) {
+		instruction, err := schemaInstruction(req.OutputSchema)
+		if err != nil {
+			return nil, err
+		}
+		// Build a new message: blades.MergeParts would modify the shared
+		// invocation instruction in place.
+		var parts []blades.Part
+		if req.Instruction != nil {
+			parts = append(parts, req.Instruction.Parts...)
+		}
+		merged := blades.SystemMessage(instruction)
+		merged.Parts = append(parts, merged.Parts...)
+		attempt.OutputSchema = nil
+		attempt.Instruction = merged
+	}
+
+	var lastErr error
+	for i := 0; i <= p.maxRepairs; i++ {
+		resp, err := call(ctx, &attempt)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Message == nil || resp.Message.Role == blades.RoleTool {
+			return resp, nil
+		}
+
+		data, err := validateStructured(resolved, resp.Message.Text())
+		if err == nil {
+			if resp.Message.Metadata == nil {
+				resp.Message.Metadata = make(map[string]any)
+			}
+			resp.Message.Metadata[MetadataStructuredOutput] = data
+			return resp, nil
+		}
+		lastErr = err
+		slog.Warn("llm.structured.invalid",
+			"model", p.next.Name(),
+			"attempt", i+1,
+			"error", err,
+		)
+		attempt.Messages = append(attempt.Messages, resp.Message, blades.UserMessage(repairInstruction(err)))
+	}
+	return nil, fmt.Errorf("structured output invalid after %d attempts: %w", p.maxRepairs+1, lastErr)
+}
+
+// StructuredOutput returns the JSON of a structured response. Responses
+// served from the response cache carry no metadata, so the JSON is then
+// extracted from the message text (it was validated before being cached).
+func StructuredOutput(msg *blades.Message) (json.RawMessage, bool) {
+	if data, ok := msg.Metadata[MetadataStructuredOutput].(json.RawMessage); ok {
+		return data, true
+	}
+	data := extractJSON(msg.Text())
+	if !json.Valid([]byte(data)) {
+		return nil, false
+	}
+	return json.RawMessage(data), true
+}
+
+// validateStructured extracts the JSON object from text and validates it.
+func validateStructured(resolved *jsonschema.Resolved, text string) (json.RawMessage, error) {
+	data := extractJSON(text)
+	var instance any
+	if err := json.Unmarshal([]byte(data), &instance); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := resolved.Validate(instance); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
+
+// extractJSON strips Markdown code fences and surrounding prose, which models
+// without a native JSON mode tend to add.
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+		return text[start : end+1]
+	}
+	return text
+}
+
+func schemaInstruction(schema *jsonschema.Schema) (string, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return "", fmt.Errorf("encode output schema: %w", err)
+	}
+	return "只输出一个符合以下 JSON Schema 的 JSON 对象，不要输出 Markdown 代码块或任何其他文字：\n" + string(data), nil
+}
+
+func repairInstruction(err error) string {
+	return fmt.Sprintf("上一次的输出不符合要求的 JSON Schema：%v\n请修正后重新输出，只输出 JSON 对象。", err)
+}
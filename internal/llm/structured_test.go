@@ -0,0 +1,241 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/tools"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedProvider returns the given responses in order and records requests.
+type scriptedProvider struct {
+	responses []*blades.Message
+	requests  []*blades.ModelRequest
+}
+
+func (p *scriptedProvider) Name() string { return "scripted" }
+
+func (p *scriptedProvider) Generate(ctx context.Context, req *blades.ModelRequest) (*blades.ModelResponse, error) {
+	p.requests = append(p.requests, req)
+	msg := p.responses[0]
+	p.responses = p.responses[1:]
+	return &blades.ModelResponse{Message: msg}, nil
+}
+
+func (p *scriptedProvider) NewStreaming(ctx context.Context, req *blades.ModelRequest) blades.Generator[*blades.ModelResponse, error] {
+	return func(yield func(*blades.ModelResponse, error) bool) {
+		resp, _ := p.Generate(ctx, req)
+		// Emit a partial chunk before the complete message, like real providers.
+		if !yield(&blades.ModelResponse{Message: blades.AssistantMessage("{")}, nil) {
+			return
+		}
+		yield(resp, nil)
+	}
+}
+
+func assistantText(text string) *blades.Message {
+	msg := blades.NewAssistantMessage(blades.StatusCompleted)
+	msg.Parts = []blades.Part{blades.TextPart{Text: text}}
+	return msg
+}
+
+func testOutputSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:     "object",
+		Title:    "status",
+		Required: []string{"status"},
+		Properties: map[string]*jsonschema.Schema{
+			"status": {Type: "string", Enum: []any{"ok", "degraded"}},
+		},
+	}
+}
+
+func TestStructuredProvider_Generate(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     string
+		responses    []*blades.Message
+		wantErr      string
+		wantJSON     string
+		wantRequests int
+	}{
+		{
+			name:         "valid first time",
+			provider:     "openai",
+			responses:    []*blades.Message{assistantText(`{"status":"ok"}`)},
+			wantJSON:     `{"status":"ok"}`,
+			wantRequests: 1,
+		},
+		{
+			name:         "code fence stripped",
+			provider:     "anthropic",
+			responses:    []*blades.Message{assistantText("```json\n{\"status\":\"degraded\"}\n```")},
+			wantJSON:     `{"status":"degraded"}`,
+			wantRequests: 1,
+		},
+		{
+			name:     "repaired",
+			provider: "anthropic",
+			responses: []*blades.Message{
+				assistantText(`状态正常`),
+				assistantText(`{"status":"fine"}`),
+				assistantText(`{"status":"ok"}`),
+			},
+			wantJSON:     `{"status":"ok"}`,
+			wantRequests: 3,
+		},
+		{
+			name:     "repairs exhausted",
+			provider: "anthropic",
+			responses: []*blades.Message{
+				assistantText(`{}`),
+				assistantText(`{}`),
+				assistantText(`{}`),
+			},
+			wantErr:      "structured output invalid after 3 attempts",
+			wantRequests: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &scriptedProvider{responses: tt.responses}
+			p := NewStructuredProvider(next, tt.provider)
+
+			resp, err := p.Generate(context.Background(), &blades.ModelRequest{
+				Instruction:  blades.SystemMessage("生成报告"),
+				Messages:     []*blades.Message{blades.UserMessage("巡检")},
+				OutputSchema: testOutputSchema(),
+			})
+			assert.Len(t, next.requests, tt.wantRequests)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			data, ok := resp.Message.Metadata[MetadataStructuredOutput].(json.RawMessage)
+			require.True(t, ok)
+			assert.JSONEq(t, tt.wantJSON, string(data))
+		})
+	}
+}
+
+func TestStructuredProvider_Request(t *testing.T) {
+	t.Run("native provider keeps schema", func(t *testing.T) {
+		next := &scriptedProvider{responses: []*blades.Message{assistantText(`{"status":"ok"}`)}}
+		instruction := blades.SystemMessage("生成报告")
+		_, err := NewStructuredProvider(next, "openai").Generate(context.Background(), &blades.ModelRequest{
+			Instruction:  instruction,
+			OutputSchema: testOutputSchema(),
+		})
+		require.NoError(t, err)
+		assert.NotNil(t, next.requests[0].OutputSchema)
+		assert.Equal(t, "生成报告", next.requests[0].Instruction.Text())
+	})
+
+	t.Run("other providers get schema instruction", func(t *testing.T) {
+		next := &scriptedProvider{responses: []*blades.Message{assistantText(`{"status":"ok"}`)}}
+		instruction := blades.SystemMessage("生成报告")
+		_, err := NewStructuredProvider(next, "anthropic").Generate(context.Background(), &blades.ModelRequest{
+			Instruction:  instruction,
+			OutputSchema: testOutputSchema(),
+		})
+		require.NoError(t, err)
+		assert.Nil(t, next.requests[0].OutputSchema)
+		assert.Contains(t, next.requests[0].Instruction.Text(), `"enum":["ok","degraded"]`)
+		assert.Equal(t, "生成报告", instruction.Text(), "shared instruction must not be modified")
+	})
+
+	t.Run("repair request includes validation error", func(t *testing.T) {
+		next := &scriptedProvider{responses: []*blades.Message{
+			assistantText(`{"status":"fine"}`),
+			assistantText(`{"status":"ok"}`),
+		}}
+		req := &blades.ModelRequest{
+			Messages:     []*blades.Message{blades.UserMessage("巡检")},
+			OutputSchema: testOutputSchema(),
+		}
+		_, err := NewStructuredProvider(next, "openai").Generate(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, next.requests[1].Messages, 3)
+		assert.Equal(t, blades.RoleUser, next.requests[1].Messages[2].Role)
+		assert.Contains(t, next.requests[1].Messages[2].Text(), "JSON Schema")
+		assert.Len(t, req.Messages, 1, "caller messages must not be modified")
+	})
+
+	t.Run("gemini with tools gets schema instruction", func(t *testing.T) {
+		next := &scriptedProvider{responses: []*blades.Message{assistantText(`{"status":"ok"}`)}}
+		tool := tools.NewTool("handoff_to_agent", "handoff", nil)
+		_, err := NewStructuredProvider(next, "gemini").Generate(context.Background(), &blades.ModelRequest{
+			Tools:        []tools.Tool{tool},
+			OutputSchema: testOutputSchema(),
+		})
+		require.NoError(t, err)
+		assert.Nil(t, next.requests[0].OutputSchema)
+	})
+}
+
+func TestStructuredProvider_PassThrough(t *testing.T) {
+	t.Run("no schema", func(t *testing.T) {
+		next := &scriptedProvider{responses: []*blades.Message{assistantText(`not json`)}}
+		resp, err := NewStructuredProvider(next, "openai").Generate(context.Background(), &blades.ModelRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "not json", resp.Message.Text())
+		assert.NotContains(t, resp.Message.Metadata, MetadataStructuredOutput)
+	})
+
+	t.Run("tool call", func(t *testing.T) {
+		call := &blades.Message{Role: blades.RoleTool, Parts: []blades.Part{blades.ToolPart{ID: "1", Name: "handoff_to_agent"}}}
+		next := &scriptedProvider{responses: []*blades.Message{call}}
+		resp, err := NewStructuredProvider(next, "openai").Generate(context.Background(), &blades.ModelRequest{
+			OutputSchema: testOutputSchema(),
+		})
+		require.NoError(t, err)
+		assert.Same(t, call, resp.Message)
+	})
+}
+
+func TestStructuredProvider_NewStreaming(t *testing.T) {
+	next := &scriptedProvider{responses: []*blades.Message{assistantText(`{"status":"ok"}`)}}
+	p := NewStructuredProvider(next, "openai")
+
+	var chunks []*blades.ModelResponse
+	for resp, err := range p.NewStreaming(context.Background(), &blades.ModelRequest{OutputSchema: testOutputSchema()}) {
+		require.NoError(t, err)
+		chunks = append(chunks, resp)
+	}
+	require.Len(t, chunks, 1, "partial JSON chunks are not yielded")
+	assert.Equal(t, `{"status":"ok"}`, chunks[0].Message.Text())
+	assert.Contains(t, chunks[0].Message.Metadata, MetadataStructuredOutput)
+}
+
+func TestStructuredOutput(t *testing.T) {
+	withMetadata := assistantText("渲染后的文本")
+	withMetadata.Metadata = map[string]any{MetadataStructuredOutput: json.RawMessage(`{"status":"ok"}`)}
+
+	tests := []struct {
+		name   string
+		msg    *blades.Message
+		want   string
+		wantOK bool
+	}{
+		{name: "metadata", msg: withMetadata, want: `{"status":"ok"}`, wantOK: true},
+		{name: "cached text", msg: assistantText("```json\n{\"status\":\"ok\"}\n```"), want: `{"status":"ok"}`, wantOK: true},
+		{name: "plain text", msg: assistantText("状态正常"), wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, ok := StructuredOutput(tt.msg)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.JSONEq(t, tt.want, string(data))
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/oneblade/config"
+)
+
+// minAnthropicThinkingBudget is the smallest extended-thinking budget the
+// Anthropic API accepts.
+const minAnthropicThinkingBudget = 1024
+
+// unsupportedTuning lists the tuning options each provider's API rejects or
+// the blades contrib silently drops. Providers not listed accept everything.
+var unsupportedTuning = map[string][]string{
+	"openai":            {"top_k", "thinking_budget"},
+	"azure_openai":      {"top_k", "thinking_budget"},
+	"ollama":            {"top_k", "thinking_budget"},
+	"openai_compatible": {"top_k", "thinking_budget"},
+	"anthropic":         {"seed", "presence_penalty", "frequency_penalty", "reasoning_effort"},
+	"bedrock":           {"seed", "presence_penalty", "frequency_penalty", "reasoning_effort"},
+	"gemini":            {"reasoning_effort"},
+	"vertex":            {"reasoning_effort"},
+}
+
+// setTuning returns the toml names of the tuning options set in cfg.
+func setTuning(cfg *config.AgentLLMConfig) map[string]bool {
+	return map[string]bool{
+		"top_p":             cfg.TopP != nil,
+		"top_k":             cfg.TopK != nil,
+		"stop_sequences":    len(cfg.StopSequences) > 0,
+		"seed":              cfg.Seed != nil,
+		"presence_penalty":  cfg.PresencePenalty != nil,
+		"frequency_penalty": cfg.FrequencyPenalty != nil,
+		"thinking_budget":   cfg.ThinkingBudget != nil,
+		"reasoning_effort":  cfg.ReasoningEffort != "",
+	}
+}
+
+// validateTuning checks the tuning options against what cfg.Provider supports.
+// It must run before applyDefaults so explicit settings can be told apart
+// from defaults.
+func validateTuning(cfg *config.AgentLLMConfig) error {
+	set := setTuning(cfg)
+
+	var errs []error
+	for _, name := range unsupportedTuning[cfg.Provider] {
+		if set[name] {
+			errs = append(errs, fmt.Errorf("%s does not support %s", cfg.Provider, name))
+		}
+	}
+
+	switch cfg.Provider {
+	case "anthropic", "bedrock":
+		errs = append(errs, validateAnthropicThinking(cfg)...)
+	case "gemini", "vertex":
+		if cfg.Seed != nil && *cfg.Seed > math.MaxInt32 {
+			errs = append(errs, fmt.Errorf("%s seed must not exceed %d", cfg.Provider, math.MaxInt32))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateAnthropicThinking enforces the extended-thinking constraints of the
+// Anthropic API, which otherwise only surface as 400 errors at request time.
+func validateAnthropicThinking(cfg *config.AgentLLMConfig) []error {
+	if cfg.ThinkingBudget == nil || *cfg.ThinkingBudget == 0 {
+		return nil
+	}
+
+	var errs []error
+	budget := *cfg.ThinkingBudget
+	if budget < minAnthropicThinkingBudget {
+		errs = append(errs, fmt.Errorf("%s thinking_budget must be at least %d", cfg.Provider, minAnthropicThinkingBudget))
+	}
+	maxTokens := defaultMaxTokens
+	if cfg.MaxTokens != nil {
+		maxTokens = *cfg.MaxTokens
+	}
+	if budget >= maxTokens {
+		errs = append(errs, fmt.Errorf("%s thinking_budget (%d) must be less than max_tokens (%d)", cfg.Provider, budget, maxTokens))
+	}
+	if cfg.Temperature != nil {
+		errs = append(errs, fmt.Errorf("%s thinking_budget cannot be combined with temperature", cfg.Provider))
+	}
+	if cfg.TopK != nil {
+		errs = append(errs, fmt.Errorf("%s thinking_budget cannot be combined with top_k", cfg.Provider))
+	}
+	return errs
+}
+
+// anthropicThinking reports whether extended thinking is enabled for cfg.
+func anthropicThinking(cfg *config.AgentLLMConfig) bool {
+	return cfg.ThinkingBudget != nil && *cfg.ThinkingBudget > 0
+}
@@ -0,0 +1,135 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/blades"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/config"
+)
+
+func TestValidateTuning(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	int64Ptr := func(v int64) *int64 { return &v }
+	floatPtr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		cfg     config.AgentLLMConfig
+		wantErr []string
+	}{
+		{
+			name: "openai accepts sampling and reasoning options",
+			cfg: config.AgentLLMConfig{
+				Provider:         "openai",
+				TopP:             floatPtr(0.9),
+				Seed:             int64Ptr(42),
+				PresencePenalty:  floatPtr(0.5),
+				FrequencyPenalty: floatPtr(0.5),
+				StopSequences:    []string{"END"},
+				ReasoningEffort:  "low",
+			},
+		},
+		{
+			name:    "openai rejects top_k and thinking_budget",
+			cfg:     config.AgentLLMConfig{Provider: "openai", TopK: intPtr(40), ThinkingBudget: intPtr(2048)},
+			wantErr: []string{"openai does not support top_k", "openai does not support thinking_budget"},
+		},
+		{
+			name:    "anthropic rejects seed and penalties",
+			cfg:     config.AgentLLMConfig{Provider: "anthropic", Seed: int64Ptr(1), PresencePenalty: floatPtr(1), ReasoningEffort: "high"},
+			wantErr: []string{"does not support seed", "does not support presence_penalty", "does not support reasoning_effort"},
+		},
+		{
+			name: "anthropic thinking within max_tokens",
+			cfg:  config.AgentLLMConfig{Provider: "anthropic", MaxTokens: intPtr(8192), ThinkingBudget: intPtr(4096), TopP: floatPtr(0.95)},
+		},
+		{
+			name:    "anthropic thinking budget too small",
+			cfg:     config.AgentLLMConfig{Provider: "anthropic", ThinkingBudget: intPtr(512)},
+			wantErr: []string{"at least 1024"},
+		},
+		{
+			name:    "anthropic thinking budget exceeds default max_tokens",
+			cfg:     config.AgentLLMConfig{Provider: "anthropic", ThinkingBudget: intPtr(4096)},
+			wantErr: []string{"must be less than max_tokens (2048)"},
+		},
+		{
+			name:    "anthropic thinking with temperature and top_k",
+			cfg:     config.AgentLLMConfig{Provider: "anthropic", MaxTokens: intPtr(8192), ThinkingBudget: intPtr(2048), Temperature: floatPtr(0.2), TopK: intPtr(5)},
+			wantErr: []string{"combined with temperature", "combined with top_k"},
+		},
+		{
+			name: "gemini dynamic thinking",
+			cfg:  config.AgentLLMConfig{Provider: "gemini", ThinkingBudget: intPtr(-1), TopK: intPtr(40), Seed: int64Ptr(7)},
+		},
+		{
+			name:    "gemini seed overflow",
+			cfg:     config.AgentLLMConfig{Provider: "gemini", Seed: int64Ptr(1 << 40)},
+			wantErr: []string{"gemini seed must not exceed"},
+		},
+		{
+			name: "mock ignores tuning",
+			cfg:  config.AgentLLMConfig{Provider: "mock", TopK: intPtr(1), ReasoningEffort: "high"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTuning(&tt.cfg)
+			if len(tt.wantErr) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestOpenAIBuilder_Tuning(t *testing.T) {
+	var gotAuth string
+	var gotBody map[string]any
+	srv := newChatCompletionsServer(t, &gotAuth, &gotBody)
+	defer srv.Close()
+
+	topP, seed, penalty := 0.9, int64(42), 0.5
+	m, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+		Provider:         "openai_compatible",
+		Model:            "qwen2.5",
+		BaseURL:          srv.URL + "/v1",
+		TopP:             &topP,
+		Seed:             &seed,
+		FrequencyPenalty: &penalty,
+		StopSequences:    []string{"END"},
+		ReasoningEffort:  "low",
+	})
+	require.NoError(t, err)
+
+	_, err = m.Generate(context.Background(), &blades.ModelRequest{
+		Messages: []*blades.Message{blades.UserMessage("hi")},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0.9, gotBody["top_p"])
+	assert.Equal(t, float64(42), gotBody["seed"])
+	assert.Equal(t, 0.5, gotBody["frequency_penalty"])
+	assert.Equal(t, []any{"END"}, gotBody["stop"])
+	assert.Equal(t, "low", gotBody["reasoning_effort"])
+}
+
+func TestFactory_Build_RejectsUnsupportedTuning(t *testing.T) {
+	topK := 40
+	_, err := NewFactory().Build(context.Background(), config.AgentLLMConfig{
+		Provider: "openai",
+		Model:    "gpt-4o",
+		APIKey:   "sk-test",
+		TopK:     &topK,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "openai does not support top_k")
+}
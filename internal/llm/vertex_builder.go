@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/auth/credentials"
+	"github.com/go-kratos/blades"
+	"github.com/go-kratos/blades/contrib/gemini"
+	"google.golang.org/genai"
+
+	"github.com/oneblade/config"
+)
+
+// vertexBuilder 构建 Vertex AI 上的 Gemini 模型
+//
+// 使用项目 + 区域 + Google Cloud 凭据鉴权（credentials_file 或 ADC），不使用 API Key。
+type vertexBuilder struct {
+	model    string
+	project  string
+	location string
+	// defaultLocation 未配置 location 且环境变量为空时使用
+	defaultLocation string
+}
+
+func newVertexBuilder() ModelBuilder {
+	return &vertexBuilder{
+		model:           "gemini-2.5-flash",
+		project:         "GOOGLE_CLOUD_PROJECT",
+		location:        "GOOGLE_CLOUD_LOCATION,GOOGLE_CLOUD_REGION",
+		defaultLocation: "us-central1",
+	}
+}
+
+func (b *vertexBuilder) GetModel(cfg *config.AgentLLMConfig) string {
+	return resolveModel(cfg, b.model)
+}
+
+func (b *vertexBuilder) GetBaseURL(cfg *config.AgentLLMConfig) string {
+	if cfg.BaseURL != "" {
+		return cfg.BaseURL
+	}
+	return fmt.Sprintf("https://%s-aiplatform.googleapis.com/", b.resolveLocation(cfg))
+}
+
+func (b *vertexBuilder) Build(ctx context.Context, cfg *config.AgentLLMConfig) (blades.ModelProvider, error) {
+	project := firstNonEmpty(cfg.Project, lookupEnv(b.project))
+	if project == "" {
+		return nil, fmt.Errorf("vertex provider requires project (project or %s)", b.project)
+	}
+
+	clientConfig := genai.ClientConfig{
+		Backend:  genai.BackendVertexAI,
+		Project:  project,
+		Location: b.resolveLocation(cfg),
+		HTTPOptions: genai.HTTPOptions{
+			BaseURL: cfg.BaseURL,
+		},
+	}
+	// 未配置 credentials_file 时由 genai 使用 ADC
+	if cfg.CredentialsFile != "" {
+		creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+			CredentialsFile: cfg.CredentialsFile,
+			Scopes:          []string{"https://www.googleapis.com/auth/cloud-platform"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("load vertex credentials %s: %w", cfg.CredentialsFile, err)
+		}
+		clientConfig.Credentials = creds
+	}
+
+	opts := geminiConfig(cfg)
+	opts.ClientConfig = clientConfig
+
+	m, err := gemini.NewModel(ctx, b.GetModel(cfg), opts)
+	if err != nil {
+		return nil, fmt.Errorf("create vertex client: %w", err)
+	}
+	return m, nil
+}
+
+func (b *vertexBuilder) resolveLocation(cfg *config.AgentLLMConfig) string {
+	return firstNonEmpty(cfg.Location, lookupEnv(b.location), b.defaultLocation)
+}
+
+// lookupEnv 返回逗号分隔的环境变量中第一个非空值
+func lookupEnv(names string) string {
+	for _, k := range strings.Split(names, ",") {
+		if v := strings.TrimSpace(os.Getenv(strings.TrimSpace(k))); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
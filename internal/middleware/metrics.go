@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 )
 
 // Metrics middleware placeholder
@@ -11,3 +13,78 @@ func Metrics(next func(context.Context, interface{}) (interface{}, error)) func(
 		return next(ctx, req)
 	}
 }
+
+// RoutingMetrics 累计规则路由的请求数、未命中数与各规则的命中次数
+// 按规则名称计数，生命周期独立于 orchestrator，配置热加载重建路由时计数保留。
+type RoutingMetrics struct {
+	requests atomic.Int64
+	misses   atomic.Int64
+
+	mu   sync.Mutex
+	hits map[string]*atomic.Int64
+}
+
+func NewRoutingMetrics() *RoutingMetrics {
+	return &RoutingMetrics{hits: make(map[string]*atomic.Int64)}
+}
+
+// Request 记录一次参与规则匹配的请求，返回累计请求数
+func (m *RoutingMetrics) Request() int64 {
+	return m.requests.Add(1)
+}
+
+// Hit 记录规则 name 命中一次，返回该规则的累计命中次数
+func (m *RoutingMetrics) Hit(name string) int64 {
+	m.mu.Lock()
+	counter, ok := m.hits[name]
+	if !ok {
+		counter = &atomic.Int64{}
+		m.hits[name] = counter
+	}
+	m.mu.Unlock()
+	return counter.Add(1)
+}
+
+// Miss 记录一次未命中任何规则，返回累计未命中次数
+func (m *RoutingMetrics) Miss() int64 {
+	return m.misses.Add(1)
+}
+
+// Snapshot 返回当前计数的快照
+func (m *RoutingMetrics) Snapshot() RoutingStats {
+	m.mu.Lock()
+	hits := make(map[string]int64, len(m.hits))
+	for name, counter := range m.hits {
+		hits[name] = counter.Load()
+	}
+	m.mu.Unlock()
+	return RoutingStats{
+		Requests: m.requests.Load(),
+		Misses:   m.misses.Load(),
+		Hits:     hits,
+	}
+}
+
+// RoutingStats 规则路由的统计快照
+type RoutingStats struct {
+	Requests int64 `json:"requests"`
+	Misses   int64 `json:"misses"`
+	// Hits 各规则的累计命中次数，key 为规则名称
+	Hits map[string]int64 `json:"hits"`
+}
+
+// HitRate 返回命中任一规则的请求占比，没有请求时为 0
+func (s RoutingStats) HitRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Requests-s.Misses) / float64(s.Requests)
+}
+
+// RuleHitRate 返回命中规则 name 的请求占比，没有请求时为 0
+func (s RoutingStats) RuleHitRate(name string) float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Hits[name]) / float64(s.Requests)
+}
@@ -78,3 +78,27 @@ func TestMetrics_ContextPreserved(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestRoutingMetrics(t *testing.T) {
+	m := NewRoutingMetrics()
+	assert.Equal(t, 0.0, m.Snapshot().HitRate())
+
+	m.Request()
+	m.Hit("alerts")
+	m.Request()
+	m.Hit("alerts")
+	m.Request()
+	m.Miss()
+	m.Request()
+	assert.Equal(t, int64(1), m.Hit("report"))
+
+	stats := m.Snapshot()
+	assert.Equal(t, RoutingStats{Requests: 4, Misses: 1, Hits: map[string]int64{"alerts": 2, "report": 1}}, stats)
+	assert.Equal(t, 0.75, stats.HitRate())
+	assert.Equal(t, 0.5, stats.RuleHitRate("alerts"))
+	assert.Equal(t, 0.0, stats.RuleHitRate("unknown"))
+
+	// 快照不受后续计数影响
+	m.Hit("alerts")
+	assert.Equal(t, int64(2), stats.Hits["alerts"])
+}
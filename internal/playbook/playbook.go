@@ -0,0 +1,178 @@
+// Package playbook 实现声明式巡检清单
+//
+// 巡检清单（playbook）是一个 TOML 文件，按顺序列出具名检查项。每个检查项绑定一个 service 的操作与参数，
+// 从响应中取出一个数值，并与 warn / fail 阈值比较得到 pass / warn / fail 结果。
+// 检查项直接调用 service 工具执行，不经过模型，结果可重复。
+package playbook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+)
+
+// Ext 巡检清单文件扩展名
+const Ext = ".toml"
+
+// DefaultCheckTimeout 单个检查项的默认超时
+const DefaultCheckTimeout = 30 * time.Second
+
+// 阈值比较方式：当前值与阈值按该方式比较成立时判定为 warn / fail
+const (
+	CompareGT = ">"
+	CompareGE = ">="
+	CompareLT = "<"
+	CompareLE = "<="
+)
+
+// Playbook 巡检清单
+type Playbook struct {
+	// Name 清单名称，为空时使用文件名
+	Name        string  `toml:"name"`
+	Description string  `toml:"description"`
+	Checks      []Check `toml:"checks" validate:"required,min=1,dive"`
+}
+
+// Check 单个检查项
+type Check struct {
+	Name string `toml:"name" validate:"required"`
+	// Service 执行检查的 service 名称，对应 [services.<name>]
+	Service string `toml:"service" validate:"required"`
+	// Operation service 工具的操作，如 prometheus 的 query_instant
+	Operation string `toml:"operation" validate:"required"`
+	// Params 操作参数，按 service 的请求格式放在对应操作的参数字段下
+	Params map[string]any `toml:"params"`
+	// Value 从响应 JSON 中取值的路径，以 . 分隔，数字为数组下标，# 为数组长度；
+	// 部分常用操作有默认路径（见 DefaultValuePath）
+	Value string `toml:"value"`
+	// Compare 阈值比较方式，默认 >
+	Compare string `toml:"compare" validate:"omitempty,oneof=> >= < <="`
+	// Warn / Fail 阈值，至少配置一个
+	Warn *float64 `toml:"warn"`
+	Fail *float64 `toml:"fail"`
+	// Unit 当前值的单位，仅用于展示
+	Unit string `toml:"unit"`
+	// Timeout 检查超时，默认 DefaultCheckTimeout
+	Timeout time.Duration `toml:"timeout" validate:"omitempty,gt=0"`
+}
+
+// Path 返回 dir 下名为 name 的巡检清单路径；name 带扩展名或包含路径分隔符时视为文件路径
+func Path(dir, name string) string {
+	if strings.HasSuffix(name, Ext) || strings.ContainsRune(name, filepath.Separator) {
+		return name
+	}
+	return filepath.Join(dir, name+Ext)
+}
+
+// Load 读取并校验巡检清单
+func Load(path string) (*Playbook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read playbook: %w", err)
+	}
+
+	var p Playbook
+	meta, err := toml.Decode(string(data), &p)
+	if err != nil {
+		return nil, fmt.Errorf("parse playbook %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+		return nil, fmt.Errorf("parse playbook %s: unknown keys: %s", path, strings.Join(keys, ", "))
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(path), Ext)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("validate playbook %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// Validate 校验清单结构与阈值
+func (p *Playbook) Validate() error {
+	if err := validator.New().Struct(p); err != nil {
+		return err
+	}
+
+	var errs []error
+	seen := make(map[string]bool, len(p.Checks))
+	for _, c := range p.Checks {
+		if seen[c.Name] {
+			errs = append(errs, fmt.Errorf("check %s: duplicate name", c.Name))
+		}
+		seen[c.Name] = true
+
+		if c.Warn == nil && c.Fail == nil {
+			errs = append(errs, fmt.Errorf("check %s: at least one of warn or fail is required", c.Name))
+			continue
+		}
+		// 同时配置时 fail 必须比 warn 更严重，否则 warn 永远不会触发
+		if c.Warn != nil && c.Fail != nil && c.breaches(*c.Warn, *c.Fail) {
+			errs = append(errs, fmt.Errorf("check %s: warn threshold %v is beyond fail threshold %v", c.Name, *c.Warn, *c.Fail))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c Check) compare() string {
+	if c.Compare == "" {
+		return CompareGT
+	}
+	return c.Compare
+}
+
+// breaches 判断 value 按比较方式是否越过 threshold
+func (c Check) breaches(value, threshold float64) bool {
+	switch c.compare() {
+	case CompareGE:
+		return value >= threshold
+	case CompareLT:
+		return value < threshold
+	case CompareLE:
+		return value <= threshold
+	default:
+		return value > threshold
+	}
+}
+
+// evaluate 根据阈值判定当前值的检查结果
+func (c Check) evaluate(value float64) Status {
+	switch {
+	case c.Fail != nil && c.breaches(value, *c.Fail):
+		return StatusFail
+	case c.Warn != nil && c.breaches(value, *c.Warn):
+		return StatusWarn
+	default:
+		return StatusPass
+	}
+}
+
+// thresholds 返回阈值的可读描述，如 "warn > 0.8, fail > 0.9"
+func (c Check) thresholds() string {
+	var parts []string
+	if c.Warn != nil {
+		parts = append(parts, fmt.Sprintf("warn %s %v", c.compare(), *c.Warn))
+	}
+	if c.Fail != nil {
+		parts = append(parts, fmt.Sprintf("fail %s %v", c.compare(), *c.Fail))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (c Check) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultCheckTimeout
+	}
+	return c.Timeout
+}
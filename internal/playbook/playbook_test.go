@@ -0,0 +1,173 @@
+package playbook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dailyCore = `
+description = "核心集群每日巡检"
+
+[[checks]]
+name = "5xx 错误率"
+service = "prom"
+operation = "query_instant"
+warn = 0.01
+fail = 0.05
+[checks.params]
+promql = 'sum(rate(http_requests_total{code=~"5.."}[5m])) / sum(rate(http_requests_total[5m]))'
+
+[[checks]]
+name = "未关闭的 P1 事件"
+service = "pd"
+operation = "list_incidents"
+fail = 0
+timeout = "10s"
+[checks.params]
+statuses = ["triggered", "acknowledged"]
+`
+
+func writePlaybook(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+Ext)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	p, err := Load(writePlaybook(t, "daily-core", dailyCore))
+	require.NoError(t, err)
+
+	assert.Equal(t, "daily-core", p.Name)
+	assert.Equal(t, "核心集群每日巡检", p.Description)
+	require.Len(t, p.Checks, 2)
+	assert.Equal(t, "5xx 错误率", p.Checks[0].Name)
+	assert.Contains(t, p.Checks[0].Params["promql"], "http_requests_total")
+	assert.Equal(t, 0.05, *p.Checks[0].Fail)
+	assert.Equal(t, DefaultCheckTimeout, p.Checks[0].timeout())
+	assert.Nil(t, p.Checks[1].Warn)
+	assert.Equal(t, 10*time.Second, p.Checks[1].timeout())
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "没有检查项",
+			content: `name = "empty"`,
+			wantErr: "Checks",
+		},
+		{
+			name: "未知字段",
+			content: `
+[[checks]]
+name = "cpu"
+service = "prom"
+operation = "query_instant"
+threshold = 0.9
+`,
+			wantErr: "unknown keys: checks.threshold",
+		},
+		{
+			name: "缺少阈值",
+			content: `
+[[checks]]
+name = "cpu"
+service = "prom"
+operation = "query_instant"
+`,
+			wantErr: "check cpu: at least one of warn or fail is required",
+		},
+		{
+			name: "warn 比 fail 更严重",
+			content: `
+[[checks]]
+name = "可用率"
+service = "prom"
+operation = "query_instant"
+compare = "<"
+warn = 0.95
+fail = 0.99
+`,
+			wantErr: "check 可用率: warn threshold 0.95 is beyond fail threshold 0.99",
+		},
+		{
+			name: "重复的检查项名称",
+			content: `
+[[checks]]
+name = "cpu"
+service = "prom"
+operation = "query_instant"
+fail = 0.9
+
+[[checks]]
+name = "cpu"
+service = "prom"
+operation = "query_instant"
+fail = 0.8
+`,
+			wantErr: "check cpu: duplicate name",
+		},
+		{
+			name: "无效的比较方式",
+			content: `
+[[checks]]
+name = "cpu"
+service = "prom"
+operation = "query_instant"
+compare = "=="
+fail = 0.9
+`,
+			wantErr: "Compare",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writePlaybook(t, "invalid", tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCheck_Evaluate(t *testing.T) {
+	warn, fail := 0.8, 0.9
+	availWarn, availFail := 0.99, 0.95
+
+	tests := []struct {
+		name  string
+		check Check
+		value float64
+		want  Status
+	}{
+		{name: "低于 warn", check: Check{Warn: &warn, Fail: &fail}, value: 0.5, want: StatusPass},
+		{name: "等于 warn 不触发", check: Check{Warn: &warn, Fail: &fail}, value: 0.8, want: StatusPass},
+		{name: "超过 warn", check: Check{Warn: &warn, Fail: &fail}, value: 0.85, want: StatusWarn},
+		{name: "超过 fail", check: Check{Warn: &warn, Fail: &fail}, value: 0.95, want: StatusFail},
+		{name: ">= 等于 fail", check: Check{Compare: CompareGE, Fail: &fail}, value: 0.9, want: StatusFail},
+		{name: "< 可用率正常", check: Check{Compare: CompareLT, Warn: &availWarn, Fail: &availFail}, value: 0.999, want: StatusPass},
+		{name: "< 可用率下降", check: Check{Compare: CompareLT, Warn: &availWarn, Fail: &availFail}, value: 0.97, want: StatusWarn},
+		{name: "< 可用率过低", check: Check{Compare: CompareLT, Warn: &availWarn, Fail: &availFail}, value: 0.9, want: StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.check.evaluate(tt.value))
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("playbooks", "daily-core.toml"), Path("playbooks", "daily-core"))
+	assert.Equal(t, "custom.toml", Path("playbooks", "custom.toml"))
+	assert.Equal(t, filepath.Join("ops", "weekly"), Path("playbooks", filepath.Join("ops", "weekly")))
+}
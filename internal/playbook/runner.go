@@ -0,0 +1,326 @@
+package playbook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/blades/tools"
+
+	"github.com/oneblade/service"
+)
+
+// Status 检查结果
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	// StatusError 调用失败或无法从响应中取值，检查项未能得出结论
+	StatusError Status = "error"
+)
+
+// Result 单个检查项的执行结果
+type Result struct {
+	Check      string   `json:"check"`
+	Service    string   `json:"service"`
+	Status     Status   `json:"status"`
+	Value      *float64 `json:"value,omitempty"`
+	Unit       string   `json:"unit,omitempty"`
+	Thresholds string   `json:"thresholds"`
+	Error      string   `json:"error,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+}
+
+// Report 一次巡检清单执行的结果，按检查项的声明顺序排列
+type Report struct {
+	Playbook    string    `json:"playbook"`
+	Description string    `json:"description,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	Results     []Result  `json:"results"`
+}
+
+// Count 返回指定结果的检查项数量
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Render 将结果渲染为 Markdown，作为报告阶段的输入
+func (r *Report) Render() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## 巡检清单结果：%s\n\n", r.Playbook)
+	if r.Description != "" {
+		fmt.Fprintf(&sb, "%s\n\n", r.Description)
+	}
+	fmt.Fprintf(&sb, "**执行时间**：%s\n**汇总**：通过 %d，警告 %d，失败 %d，错误 %d\n\n",
+		r.StartedAt.Format(time.RFC3339),
+		r.Count(StatusPass), r.Count(StatusWarn), r.Count(StatusFail), r.Count(StatusError))
+	sb.WriteString("| 检查项 | 服务 | 结果 | 当前值 | 阈值 | 说明 |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, res := range r.Results {
+		value := "-"
+		if res.Value != nil {
+			value = strconv.FormatFloat(*res.Value, 'g', -1, 64) + res.Unit
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s |\n",
+			res.Check, res.Service, statusLabel(res.Status), value, res.Thresholds, res.Error)
+	}
+	return sb.String()
+}
+
+func statusLabel(status Status) string {
+	switch status {
+	case StatusPass:
+		return "通过"
+	case StatusWarn:
+		return "警告"
+	case StatusFail:
+		return "失败"
+	default:
+		return "错误"
+	}
+}
+
+// defaultValuePaths 常用只读操作的默认取值路径
+var defaultValuePaths = map[service.ServiceType]map[string]string{
+	// 即时查询取第一条样本的值
+	service.Prometheus: {"query_instant": "data.0.value.1"},
+	service.PagerDuty:  {"list_incidents": "incidents.#"},
+	service.Jira:       {"list_issues": "issues.#"},
+	service.OpenSearch: {"search": "data.hits.total.value"},
+}
+
+// DefaultValuePath 返回操作的默认取值路径，没有默认值时返回空字符串
+func DefaultValuePath(serviceType service.ServiceType, operation string) string {
+	return defaultValuePaths[serviceType][operation]
+}
+
+// paramsKey 返回操作参数在 service 请求中的字段名
+// 各 service 的请求以操作名作为参数字段，jira 额外带 _params 后缀。
+func paramsKey(serviceType service.ServiceType, operation string) string {
+	if serviceType == service.Jira {
+		return operation + "_params"
+	}
+	return operation
+}
+
+// boundCheck 已绑定 service 工具的检查项
+type boundCheck struct {
+	Check
+	tool  tools.Tool
+	input string
+	path  string
+}
+
+// Runner 执行巡检清单
+type Runner struct {
+	playbook *Playbook
+	checks   []boundCheck
+}
+
+// NewRunner 将检查项绑定到对应的 service，service 不存在或缺少取值路径时返回错误
+func NewRunner(p *Playbook, services []service.Service) (*Runner, error) {
+	byName := make(map[string]service.Service, len(services))
+	for _, s := range services {
+		byName[s.Name()] = s
+	}
+
+	toolsByService := make(map[string]tools.Tool)
+	checks := make([]boundCheck, 0, len(p.Checks))
+	for _, c := range p.Checks {
+		s, ok := byName[c.Service]
+		if !ok {
+			return nil, fmt.Errorf("check %s: service %s not found or not enabled", c.Name, c.Service)
+		}
+		tool, ok := toolsByService[c.Service]
+		if !ok {
+			var err error
+			if tool, err = s.AsTool(); err != nil {
+				return nil, fmt.Errorf("create tool for %s: %w", c.Service, err)
+			}
+			toolsByService[c.Service] = tool
+		}
+
+		path := c.Value
+		if path == "" {
+			path = DefaultValuePath(s.Type(), c.Operation)
+		}
+		if path == "" {
+			return nil, fmt.Errorf("check %s: value is required for %s operation %s", c.Name, s.Type(), c.Operation)
+		}
+
+		request := map[string]any{"operation": c.Operation}
+		if len(c.Params) > 0 {
+			request[paramsKey(s.Type(), c.Operation)] = c.Params
+		}
+		input, err := json.Marshal(request)
+		if err != nil {
+			return nil, fmt.Errorf("check %s: encode params: %w", c.Name, err)
+		}
+
+		checks = append(checks, boundCheck{Check: c, tool: tool, input: string(input), path: path})
+	}
+	return &Runner{playbook: p, checks: checks}, nil
+}
+
+// Playbook 返回执行的巡检清单
+func (r *Runner) Playbook() *Playbook {
+	return r.playbook
+}
+
+// Run 按声明顺序依次执行检查项，单个检查项出错不影响其余检查项；只有 ctx 被取消时返回错误
+func (r *Runner) Run(ctx context.Context) (*Report, error) {
+	report := &Report{
+		Playbook:    r.playbook.Name,
+		Description: r.playbook.Description,
+		StartedAt:   time.Now(),
+		Results:     make([]Result, 0, len(r.checks)),
+	}
+	for _, c := range r.checks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report.Results = append(report.Results, c.run(ctx))
+	}
+
+	slog.Info("playbook.complete",
+		"playbook", report.Playbook,
+		"checks", len(report.Results),
+		"warn", report.Count(StatusWarn),
+		"fail", report.Count(StatusFail),
+		"error", report.Count(StatusError),
+		"duration_ms", time.Since(report.StartedAt).Milliseconds(),
+	)
+	return report, nil
+}
+
+func (c boundCheck) run(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout())
+	defer cancel()
+
+	start := time.Now()
+	result := Result{
+		Check:      c.Name,
+		Service:    c.Service,
+		Unit:       c.Unit,
+		Thresholds: c.thresholds(),
+	}
+	value, err := c.measure(ctx)
+	if err != nil {
+		result.Status = StatusError
+		result.Error = err.Error()
+	} else {
+		result.Value = &value
+		result.Status = c.evaluate(value)
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	slog.Info("playbook.check.complete",
+		"check", result.Check,
+		"service", result.Service,
+		"status", result.Status,
+		"duration_ms", result.DurationMs,
+	)
+	return result
+}
+
+// measure 调用 service 工具并从响应中取出当前值
+func (c boundCheck) measure(ctx context.Context) (float64, error) {
+	output, err := c.tool.Handle(ctx, c.input)
+	if err != nil {
+		return 0, err
+	}
+
+	var resp any
+	if err := json.Unmarshal([]byte(output), &resp); err != nil {
+		return 0, fmt.Errorf("decode response: %w", err)
+	}
+	// service 以 success=false 表示业务失败
+	if obj, ok := resp.(map[string]any); ok {
+		if success, ok := obj["success"].(bool); ok && !success {
+			message, _ := obj["message"].(string)
+			return 0, fmt.Errorf("%s failed: %s", c.Operation, message)
+		}
+	}
+
+	v, err := lookup(resp, c.path)
+	if err != nil {
+		return 0, err
+	}
+	return toFloat(v)
+}
+
+// lookup 按路径从 JSON 值中取值
+// # 返回数组或对象的长度，路径不存在时视为空集合（service 响应会省略空列表）。
+func lookup(v any, path string) (any, error) {
+	for _, seg := range strings.Split(path, ".") {
+		if seg == "#" {
+			switch x := v.(type) {
+			case []any:
+				v = float64(len(x))
+			case map[string]any:
+				v = float64(len(x))
+			case nil:
+				v = float64(0)
+			default:
+				return nil, fmt.Errorf("value path %s: # applied to %T", path, v)
+			}
+			continue
+		}
+
+		switch x := v.(type) {
+		case map[string]any:
+			v = x[seg]
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil {
+				return nil, fmt.Errorf("value path %s: %q is not an array index", path, seg)
+			}
+			if i < 0 || i >= len(x) {
+				return nil, fmt.Errorf("value path %s: no data at index %d", path, i)
+			}
+			v = x[i]
+		case nil:
+			// 缺失的字段只允许后接 #
+			v = nil
+		default:
+			return nil, fmt.Errorf("value path %s: cannot select %q from %T", path, seg, v)
+		}
+	}
+	if v == nil {
+		return nil, fmt.Errorf("value path %s: not found in response", path)
+	}
+	return v, nil
+}
+
+// toFloat 将取到的值转换为数值，字符串按数字解析（如 prometheus 样本值）
+func toFloat(v any) (float64, error) {
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case bool:
+		if x {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value %q is not a number", x)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("value of type %T is not a number", v)
+	}
+}
@@ -0,0 +1,165 @@
+package playbook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-kratos/blades/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oneblade/service"
+)
+
+// fakeService 按请求中的 operation 返回固定响应，并记录收到的请求
+type fakeService struct {
+	name      string
+	typ       service.ServiceType
+	responses map[string]string
+	requests  *[]string
+}
+
+func (s fakeService) Name() string                     { return s.name }
+func (s fakeService) Type() service.ServiceType        { return s.typ }
+func (s fakeService) Description() string              { return s.name }
+func (s fakeService) Health(ctx context.Context) error { return nil }
+func (s fakeService) Close() error                     { return nil }
+func (s fakeService) AsTool() (tools.Tool, error) {
+	return tools.NewTool(s.name, s.name, tools.HandleFunc(func(ctx context.Context, input string) (string, error) {
+		if s.requests != nil {
+			*s.requests = append(*s.requests, input)
+		}
+		if resp, ok := s.responses[operationOf(input)]; ok {
+			return resp, nil
+		}
+		return `{"success": false, "message": "unknown operation"}`, nil
+	})), nil
+}
+
+func operationOf(input string) string {
+	var req struct {
+		Operation string `json:"operation"`
+	}
+	_ = json.Unmarshal([]byte(input), &req)
+	return req.Operation
+}
+
+func TestRunner_Run(t *testing.T) {
+	var promRequests []string
+	services := []service.Service{
+		fakeService{
+			name:     "prom",
+			typ:      service.Prometheus,
+			requests: &promRequests,
+			responses: map[string]string{
+				"query_instant": `{"operation": "query_instant", "success": true, "data": [{"metric": {}, "value": [1760000000, "0.03"]}]}`,
+			},
+		},
+		fakeService{
+			name: "pd",
+			typ:  service.PagerDuty,
+			responses: map[string]string{
+				// 没有事件时 incidents 字段被省略
+				"list_incidents": `{"operation": "list_incidents", "success": true}`,
+			},
+		},
+		fakeService{name: "logs", typ: service.OpenSearch},
+	}
+
+	warn, fail, zero := 0.01, 0.05, 0.0
+	p := &Playbook{
+		Name: "daily-core",
+		Checks: []Check{
+			{Name: "5xx 错误率", Service: "prom", Operation: "query_instant", Params: map[string]any{"promql": "error_ratio"}, Warn: &warn, Fail: &fail},
+			{Name: "未关闭的 P1 事件", Service: "pd", Operation: "list_incidents", Fail: &zero},
+			{Name: "错误日志", Service: "logs", Operation: "search", Fail: &zero},
+		},
+	}
+
+	runner, err := NewRunner(p, services)
+	require.NoError(t, err)
+	report, err := runner.Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, report.Results, 3)
+	assert.Equal(t, StatusWarn, report.Results[0].Status)
+	assert.Equal(t, 0.03, *report.Results[0].Value)
+	assert.Equal(t, "warn > 0.01, fail > 0.05", report.Results[0].Thresholds)
+	assert.Equal(t, StatusPass, report.Results[1].Status)
+	assert.Equal(t, 0.0, *report.Results[1].Value)
+	assert.Equal(t, StatusError, report.Results[2].Status)
+	assert.Equal(t, "search failed: unknown operation", report.Results[2].Error)
+
+	require.Len(t, promRequests, 1)
+	assert.JSONEq(t, `{"operation": "query_instant", "query_instant": {"promql": "error_ratio"}}`, promRequests[0])
+
+	text := report.Render()
+	assert.Contains(t, text, "## 巡检清单结果：daily-core")
+	assert.Contains(t, text, "**汇总**：通过 1，警告 1，失败 0，错误 1")
+	assert.Contains(t, text, "| 5xx 错误率 | prom | 警告 | 0.03 | warn > 0.01, fail > 0.05 |  |")
+}
+
+func TestNewRunner_Errors(t *testing.T) {
+	fail := 1.0
+	services := []service.Service{fakeService{name: "jira", typ: service.Jira}}
+
+	tests := []struct {
+		name    string
+		check   Check
+		wantErr string
+	}{
+		{
+			name:    "service 未启用",
+			check:   Check{Name: "cpu", Service: "prom", Operation: "query_instant", Fail: &fail},
+			wantErr: "check cpu: service prom not found or not enabled",
+		},
+		{
+			name:    "没有默认取值路径",
+			check:   Check{Name: "工单", Service: "jira", Operation: "get_issue", Fail: &fail},
+			wantErr: "check 工单: value is required for jira operation get_issue",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRunner(&Playbook{Name: "p", Checks: []Check{tt.check}}, services)
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	var resp any
+	require.NoError(t, json.Unmarshal([]byte(`{"data": {"hits": {"total": {"value": 12}}, "samples": [[1, "0.5"]]}, "items": [1, 2, 3]}`), &resp))
+
+	tests := []struct {
+		path    string
+		want    float64
+		wantErr string
+	}{
+		{path: "data.hits.total.value", want: 12},
+		{path: "data.samples.0.1", want: 0.5},
+		{path: "items.#", want: 3},
+		{path: "missing.#", want: 0},
+		{path: "missing", wantErr: "value path missing: not found in response"},
+		{path: "items.5", wantErr: "value path items.5: no data at index 5"},
+		{path: "items.first", wantErr: `value path items.first: "first" is not an array index`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			v, err := lookup(resp, tt.path)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			got, err := toFloat(v)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Package prompts 管理 agent 的提示词模板
+//
+// 内置模板通过 embed 打包在 templates/<locale>/ 下，可以通过覆盖目录按名称替换：
+// 覆盖目录同样按 <dir>/<locale>/<name>.tmpl 组织，只需放置要修改的模板，其余沿用内置版本。
+// 模板使用 text/template 语法，渲染时注入 Vars 中的运行时变量。
+package prompts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+//go:embed templates
+var builtin embed.FS
+
+// BuiltinVersion 内置模板版本，修改 templates 下的任意模板时需要递增
+const BuiltinVersion = "v9"
+
+// 支持的语言
+const (
+	LocaleZh      = "zh"
+	LocaleEn      = "en"
+	DefaultLocale = LocaleZh
+)
+
+// Locales 支持的语言列表
+var Locales = []string{LocaleZh, LocaleEn}
+
+// 模板名称，对应 <locale>/<name>.tmpl
+const (
+	ServiceAgent    = "service_agent"
+	ReportAgent     = "report_agent"
+	PredictionAgent = "prediction_agent"
+	GeneralAgent    = "general_agent"
+	// Collector 分析流程采集阶段中单个 service 的采集助手
+	Collector = "collector"
+	// Investigator 事件调查中提出并验证假设的调查阶段
+	Investigator = "investigator"
+	// InvestigationFindings 事件调查中汇总结构化结论的阶段
+	InvestigationFindings = "investigation_findings"
+	// PostmortemDraft 事件复盘中起草结构化复盘草稿的阶段
+	PostmortemDraft = "postmortem_draft"
+)
+
+// Names 内置模板名称（不含 partials 中定义的公共片段）
+var Names = []string{ServiceAgent, ReportAgent, PredictionAgent, GeneralAgent, Collector, Investigator, InvestigationFindings, PostmortemDraft}
+
+const templateExt = ".tmpl"
+
+// Service 注入模板的 service 信息
+type Service struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// Vars 渲染模板时注入的运行时变量
+type Vars struct {
+	// Now 渲染时的当前时间
+	Now time.Time
+	// Services agent 可用的 service
+	Services []Service
+	// User 当前会话的用户，未知时为空
+	User string
+}
+
+// funcs 模板可用的辅助函数
+var funcs = template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}
+
+// Options 加载模板的选项
+type Options struct {
+	// Locale 模板语言，默认 DefaultLocale
+	Locale string
+	// Dir 模板覆盖目录，为空时只使用内置模板
+	Dir string
+}
+
+// Set 一组已解析的模板
+type Set struct {
+	locale  string
+	version string
+	tmpl    *template.Template
+}
+
+// Load 加载指定语言的内置模板，并用覆盖目录中的同名模板替换
+func Load(opts Options) (*Set, error) {
+	locale := opts.Locale
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	if !slices.Contains(Locales, locale) {
+		return nil, fmt.Errorf("unsupported prompt locale %q", locale)
+	}
+
+	root := template.New(locale).Funcs(funcs).Option("missingkey=error")
+	builtinFS, err := fs.Sub(builtin, path.Join("templates", locale))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseDir(root, builtinFS); err != nil {
+		return nil, fmt.Errorf("parse builtin prompts: %w", err)
+	}
+
+	version := locale + "@" + BuiltinVersion
+	if opts.Dir != "" {
+		// 覆盖目录必须存在，其下缺少对应语言的子目录时视为没有覆盖
+		if _, err := os.Stat(opts.Dir); err != nil {
+			return nil, fmt.Errorf("prompts dir: %w", err)
+		}
+		dir := path.Join(opts.Dir, locale)
+		overrides, err := parseDir(root, os.DirFS(dir))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("parse prompts in %s: %w", dir, err)
+		}
+		if overrides != "" {
+			version += "+" + overrides
+		}
+	}
+
+	return &Set{locale: locale, version: version, tmpl: root}, nil
+}
+
+// parseDir 解析目录下的全部 .tmpl 文件，同名模板覆盖已有定义
+// 返回这些文件的内容摘要，目录为空时返回空字符串。
+func parseDir(root *template.Template, fsys fs.FS) (string, error) {
+	files, err := fs.Glob(fsys, "*"+templateExt)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		// 区分目录不存在与目录中没有模板
+		if _, err := fs.Stat(fsys, "."); err != nil {
+			return "", err
+		}
+		return "", nil
+	}
+
+	hash := sha256.New()
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return "", err
+		}
+		name := strings.TrimSuffix(file, templateExt)
+		if _, err := root.New(name).Parse(string(content)); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s\x00%s\x00", file, content)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:8], nil
+}
+
+// Locale 返回模板语言
+func (s *Set) Locale() string {
+	return s.locale
+}
+
+// Version 返回模板版本，格式为 <locale>@<BuiltinVersion>，存在覆盖模板时追加 +<内容摘要>
+func (s *Set) Version() string {
+	return s.version
+}
+
+// Render 使用运行时变量渲染指定模板
+func (s *Set) Render(name string, vars Vars) (string, error) {
+	t := s.tmpl.Lookup(name)
+	if t == nil {
+		return "", fmt.Errorf("prompt template %s not found", name)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("render prompt %s: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Default 返回默认语言的内置模板，供未显式配置模板的调用方使用
+var Default = sync.OnceValue(func() *Set {
+	set, err := Load(Options{})
+	if err != nil {
+		panic(fmt.Sprintf("load builtin prompts: %v", err))
+	}
+	return set
+})
@@ -0,0 +1,109 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testVars = Vars{
+	Now:      time.Date(2026, 10, 1, 8, 30, 0, 0, time.UTC),
+	Services: []Service{{Name: "prom", Type: "prometheus", Description: "核心集群监控"}},
+	User:     "alice",
+}
+
+func TestLoad_Builtin(t *testing.T) {
+	tests := []struct {
+		locale  string
+		runtime string
+	}{
+		{locale: LocaleZh, runtime: "当前时间: 2026-10-01 08:30:00 UTC\n当前用户: alice"},
+		{locale: LocaleEn, runtime: "Current time: 2026-10-01 08:30:00 UTC\nCurrent user: alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			set, err := Load(Options{Locale: tt.locale})
+			require.NoError(t, err)
+			assert.Equal(t, tt.locale, set.Locale())
+			assert.Equal(t, tt.locale+"@"+BuiltinVersion, set.Version())
+
+			for _, name := range Names {
+				out, err := set.Render(name, testVars)
+				require.NoError(t, err, name)
+				assert.Contains(t, out, tt.runtime, name)
+				assert.Contains(t, out, "(prometheus) - 核心集群监控", name)
+			}
+		})
+	}
+}
+
+func TestLoad_DefaultLocale(t *testing.T) {
+	set, err := Load(Options{})
+	require.NoError(t, err)
+	assert.Equal(t, LocaleZh, set.Locale())
+
+	out, err := set.Render(ServiceAgent, testVars)
+	require.NoError(t, err)
+	assert.Contains(t, out, "你是一个 SRE 服务交互专家。")
+	assert.Contains(t, out, "1. **prom** (prometheus) - 核心集群监控")
+}
+
+func TestLoad_Override(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, LocaleZh), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, LocaleZh, "report_agent.tmpl"),
+		[]byte(`你是值班报告助手，{{.User}}。{{template "runtime" .}}`), 0o644))
+
+	set, err := Load(Options{Dir: dir})
+	require.NoError(t, err)
+	assert.Regexp(t, `^zh@v\d+\+[0-9a-f]{8}$`, set.Version())
+
+	out, err := set.Render(ReportAgent, testVars)
+	require.NoError(t, err)
+	assert.Equal(t, "你是值班报告助手，alice。当前时间: 2026-10-01 08:30:00 UTC\n当前用户: alice", out)
+
+	// 未覆盖的模板沿用内置版本
+	out, err = set.Render(PredictionAgent, testVars)
+	require.NoError(t, err)
+	assert.Contains(t, out, "你是一个系统健康预测专家。")
+
+	// 覆盖目录没有对应语言时只使用内置模板
+	en, err := Load(Options{Dir: dir, Locale: LocaleEn})
+	require.NoError(t, err)
+	assert.Equal(t, "en@"+BuiltinVersion, en.Version())
+}
+
+func TestLoad_Errors(t *testing.T) {
+	invalid := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(invalid, LocaleZh), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(invalid, LocaleZh, "report_agent.tmpl"), []byte(`{{.User`), 0o644))
+
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{name: "unsupported locale", opts: Options{Locale: "fr"}, wantErr: `unsupported prompt locale "fr"`},
+		{name: "missing dir", opts: Options{Dir: filepath.Join(t.TempDir(), "missing")}, wantErr: "prompts dir"},
+		{name: "invalid template", opts: Options{Dir: invalid}, wantErr: "parse prompts in"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestSet_Render_UnknownTemplate(t *testing.T) {
+	_, err := Default().Render("missing_agent", testVars)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prompt template missing_agent not found")
+}
@@ -5,5 +5,13 @@ const (
 	StateKeySummaryUpdatedAt    = "summary_updated_at"
 	StateKeyLastPromptTokens    = "last_prompt_tokens"
 	StateKeyLastTotalTokens     = "last_total_tokens"
+	// StateKeyPromptVersion 最近一次渲染提示词模板时使用的模板版本
+	StateKeyPromptVersion = "prompt_version"
+	// StateKeyUser 当前会话的用户，作为运行时变量注入提示词模板
+	StateKeyUser = "user"
+	// StateKeyRoutePath 最近一次请求经过的 agent 路径，依次为 orchestrator 与每次 handoff 的目标
+	StateKeyRoutePath = "route_path"
+	// StateKeyPostmortemDraft 等待用户确认的复盘草稿，确认创建或放弃后清空
+	StateKeyPostmortemDraft = "postmortem_draft"
 )
 
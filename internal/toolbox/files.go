@@ -0,0 +1,161 @@
+package toolbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-kratos/blades/tools"
+)
+
+// DefaultMaxFileBytes ReadFile 单次读取的默认最大字节数
+const DefaultMaxFileBytes = 256 << 10
+
+type ReadFileRequest struct {
+	Path string `json:"path" jsonschema:"File or directory path, relative to an allowed directory or an absolute path inside one. Directories return their entries."`
+}
+
+type ReadFileResponse struct {
+	Path      string   `json:"path"`
+	Content   string   `json:"content,omitempty"`
+	Truncated bool     `json:"truncated,omitempty"`
+	Entries   []string `json:"entries,omitempty"`
+}
+
+// fileReader 只读访问一组允许的目录；通过 os.Root 打开文件，".." 与指向目录外的符号链接均无法越界
+type fileReader struct {
+	dirs     []string
+	maxBytes int64
+}
+
+// NewReadFileTool 返回只能读取 dirs 下文本文件与目录列表的工具
+func NewReadFileTool(dirs []string, maxBytes int64) (tools.Tool, error) {
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("ReadFile requires at least one directory")
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxFileBytes
+	}
+	r := &fileReader{maxBytes: maxBytes}
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("resolve dir %s: %w", dir, err)
+		}
+		r.dirs = append(r.dirs, abs)
+	}
+	return tools.NewFunc(
+		"ReadFile",
+		fmt.Sprintf("Read a text file or list a directory. Only these directories are readable: %s.", strings.Join(r.dirs, ", ")),
+		r.read,
+	)
+}
+
+func (r *fileReader) read(ctx context.Context, req ReadFileRequest) (ReadFileResponse, error) {
+	path := strings.TrimSpace(req.Path)
+	if path == "" {
+		return ReadFileResponse{}, fmt.Errorf("path is required")
+	}
+	for _, dir := range r.candidates(path) {
+		resp, err := r.readIn(dir.root, dir.rel)
+		if errors.Is(err, fs.ErrNotExist) && !filepath.IsAbs(path) {
+			continue
+		}
+		return resp, err
+	}
+	if filepath.IsAbs(path) {
+		return ReadFileResponse{}, fmt.Errorf("path %s is outside the allowed directories", path)
+	}
+	return ReadFileResponse{}, fmt.Errorf("path %s not found in the allowed directories", path)
+}
+
+type candidate struct {
+	root string
+	rel  string
+}
+
+// candidates 返回 path 可能所在的允许目录：绝对路径只匹配包含它的目录，相对路径按顺序尝试每个目录
+func (r *fileReader) candidates(path string) []candidate {
+	var out []candidate
+	for _, dir := range r.dirs {
+		if !filepath.IsAbs(path) {
+			out = append(out, candidate{root: dir, rel: filepath.Clean(path)})
+			continue
+		}
+		rel, err := filepath.Rel(dir, filepath.Clean(path))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		out = append(out, candidate{root: dir, rel: rel})
+		break
+	}
+	return out
+}
+
+func (r *fileReader) readIn(dir, rel string) (ReadFileResponse, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return ReadFileResponse{}, err
+	}
+	defer root.Close()
+
+	f, err := root.Open(rel)
+	if err != nil {
+		return ReadFileResponse{}, err
+	}
+	defer f.Close()
+
+	resp := ReadFileResponse{Path: filepath.Join(dir, rel)}
+	info, err := f.Stat()
+	if err != nil {
+		return ReadFileResponse{}, err
+	}
+	if info.IsDir() {
+		entries, err := f.ReadDir(-1)
+		if err != nil {
+			return ReadFileResponse{}, err
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() {
+				name += "/"
+			}
+			resp.Entries = append(resp.Entries, name)
+		}
+		slices.Sort(resp.Entries)
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, r.maxBytes+1))
+	if err != nil {
+		return ReadFileResponse{}, err
+	}
+	if int64(len(data)) > r.maxBytes {
+		data = trimPartialRune(data[:r.maxBytes])
+		resp.Truncated = true
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return ReadFileResponse{}, fmt.Errorf("%s is not a text file", resp.Path)
+	}
+	resp.Content = string(data)
+	return resp, nil
+}
+
+// trimPartialRune 去掉截断时末尾不完整的 UTF-8 字符
+func trimPartialRune(data []byte) []byte {
+	for i := 0; i < utf8.UTFMax && len(data) > 0; i++ {
+		if utf8.Valid(data) {
+			return data
+		}
+		data = data[:len(data)-1]
+	}
+	return data
+}
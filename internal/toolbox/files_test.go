@@ -0,0 +1,81 @@
+package toolbox
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileTool(t *testing.T) {
+	runbooks := t.TempDir()
+	docs := t.TempDir()
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(secret, []byte("token"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(runbooks, "disk.md"), []byte("# 磁盘告警处理"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(runbooks, "db"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "oncall.md"), []byte("值班表"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "large.txt"), []byte("abcdef"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "app.bin"), []byte{0x7f, 0x00, 0x01}, 0o644))
+	require.NoError(t, os.Symlink(secret, filepath.Join(docs, "link.txt")))
+
+	tool, err := NewReadFileTool([]string{runbooks, docs}, 4)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		path    string
+		want    ReadFileResponse
+		wantErr string
+	}{
+		{
+			name: "relative path in first dir",
+			path: "disk.md",
+			want: ReadFileResponse{Path: filepath.Join(runbooks, "disk.md"), Content: "# ", Truncated: true},
+		},
+		{
+			name: "relative path in second dir",
+			path: "oncall.md",
+			want: ReadFileResponse{Path: filepath.Join(docs, "oncall.md"), Content: "值", Truncated: true},
+		},
+		{
+			name: "absolute path inside dir",
+			path: filepath.Join(docs, "large.txt"),
+			want: ReadFileResponse{Path: filepath.Join(docs, "large.txt"), Content: "abcd", Truncated: true},
+		},
+		{
+			name: "directory listing",
+			path: runbooks,
+			want: ReadFileResponse{Path: runbooks, Entries: []string{"db/", "disk.md"}},
+		},
+		{name: "absolute path outside dirs", path: secret, wantErr: "is outside the allowed directories"},
+		{name: "parent traversal", path: "../secret.txt", wantErr: "escapes"},
+		{name: "symlink escaping dir", path: "link.txt", wantErr: "escapes"},
+		{name: "missing file", path: "missing.md", wantErr: "path missing.md not found in the allowed directories"},
+		{name: "binary file", path: "app.bin", wantErr: "is not a text file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, _ := json.Marshal(ReadFileRequest{Path: tt.path})
+			out, err := tool.Handle(context.Background(), string(input))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var got ReadFileResponse
+			require.NoError(t, json.Unmarshal([]byte(out), &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewReadFileTool_RequiresDirs(t *testing.T) {
+	_, err := NewReadFileTool(nil, 0)
+	require.Error(t, err)
+}
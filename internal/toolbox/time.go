@@ -0,0 +1,60 @@
+// Package toolbox 提供 general_agent 使用的本地安全工具：时间、单位换算与只读文件读取。
+package toolbox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/blades/tools"
+)
+
+// now 返回当前时间，测试中可替换
+var now = time.Now
+
+type TimeRequest struct {
+	Timezone string `json:"timezone,omitempty" jsonschema:"IANA time zone such as Asia/Shanghai or UTC. Defaults to the local time zone."`
+	Offset   string `json:"offset,omitempty" jsonschema:"Optional duration added to the current time, e.g. -24h or 90m."`
+}
+
+type TimeResponse struct {
+	Time     string `json:"time"`
+	Date     string `json:"date"`
+	Weekday  string `json:"weekday"`
+	Unix     int64  `json:"unix"`
+	Timezone string `json:"timezone"`
+}
+
+// NewTimeTool 返回查询当前时间（可指定时区与偏移）的工具
+func NewTimeTool() (tools.Tool, error) {
+	return tools.NewFunc(
+		"Time",
+		"Get the current date and time, optionally in a given time zone and shifted by an offset such as -24h.",
+		func(ctx context.Context, req TimeRequest) (TimeResponse, error) {
+			loc := time.Local
+			if tz := strings.TrimSpace(req.Timezone); tz != "" {
+				l, err := time.LoadLocation(tz)
+				if err != nil {
+					return TimeResponse{}, fmt.Errorf("invalid timezone %q: %w", tz, err)
+				}
+				loc = l
+			}
+			t := now().In(loc)
+			if offset := strings.TrimSpace(req.Offset); offset != "" {
+				d, err := time.ParseDuration(offset)
+				if err != nil {
+					return TimeResponse{}, fmt.Errorf("invalid offset %q: %w", offset, err)
+				}
+				t = t.Add(d)
+			}
+			return TimeResponse{
+				Time:     t.Format(time.RFC3339),
+				Date:     t.Format(time.DateOnly),
+				Weekday:  t.Weekday().String(),
+				Unix:     t.Unix(),
+				Timezone: loc.String(),
+			}, nil
+		},
+	)
+}
@@ -0,0 +1,60 @@
+package toolbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeTool(t *testing.T) {
+	fixed := time.Date(2026, 10, 18, 2, 30, 0, 0, time.UTC)
+	orig := now
+	now = func() time.Time { return fixed }
+	t.Cleanup(func() { now = orig })
+
+	tool, err := NewTimeTool()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "timezone",
+			input: `{"timezone": "Asia/Shanghai"}`,
+			want:  `{"time": "2026-10-18T10:30:00+08:00", "date": "2026-10-18", "weekday": "Sunday", "unix": 1792290600, "timezone": "Asia/Shanghai"}`,
+		},
+		{
+			name:  "offset",
+			input: `{"timezone": "UTC", "offset": "-24h"}`,
+			want:  `{"time": "2026-10-17T02:30:00Z", "date": "2026-10-17", "weekday": "Saturday", "unix": 1792204200, "timezone": "UTC"}`,
+		},
+		{
+			name:    "invalid timezone",
+			input:   `{"timezone": "Mars/Olympus"}`,
+			wantErr: `invalid timezone "Mars/Olympus"`,
+		},
+		{
+			name:    "invalid offset",
+			input:   `{"offset": "yesterday"}`,
+			wantErr: `invalid offset "yesterday"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tool.Handle(context.Background(), tt.input)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, out)
+		})
+	}
+}
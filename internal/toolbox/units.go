@@ -0,0 +1,93 @@
+package toolbox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-kratos/blades/tools"
+)
+
+// unit 单位所属的类别及换算到该类别基准单位的系数
+type unit struct {
+	family string
+	factor float64
+}
+
+// units 支持的单位（忽略大小写）：数据量以字节为基准，时长以秒为基准，比例以 1 为基准
+var units = map[string]unit{
+	"bit":  {"data", 1.0 / 8},
+	"kbit": {"data", 1e3 / 8},
+	"mbit": {"data", 1e6 / 8},
+	"gbit": {"data", 1e9 / 8},
+	"b":    {"data", 1},
+	"kb":   {"data", 1e3},
+	"mb":   {"data", 1e6},
+	"gb":   {"data", 1e9},
+	"tb":   {"data", 1e12},
+	"pb":   {"data", 1e15},
+	"kib":  {"data", 1 << 10},
+	"mib":  {"data", 1 << 20},
+	"gib":  {"data", 1 << 30},
+	"tib":  {"data", 1 << 40},
+	"pib":  {"data", 1 << 50},
+
+	"ns":  {"duration", 1e-9},
+	"us":  {"duration", 1e-6},
+	"µs":  {"duration", 1e-6},
+	"ms":  {"duration", 1e-3},
+	"s":   {"duration", 1},
+	"m":   {"duration", 60},
+	"min": {"duration", 60},
+	"h":   {"duration", 3600},
+	"d":   {"duration", 86400},
+	"w":   {"duration", 7 * 86400},
+
+	"ratio":   {"ratio", 1},
+	"%":       {"ratio", 0.01},
+	"percent": {"ratio", 0.01},
+	"‰":       {"ratio", 0.001},
+	"bp":      {"ratio", 0.0001},
+}
+
+type ConvertUnitRequest struct {
+	Value float64 `json:"value" jsonschema:"The value to convert."`
+	From  string  `json:"from" jsonschema:"Source unit, e.g. GiB, MB, bit, ms, h, d, %, ratio."`
+	To    string  `json:"to" jsonschema:"Target unit of the same kind as the source unit."`
+}
+
+type ConvertUnitResponse struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+// NewConvertUnitTool 返回数据量、时长与比例的单位换算工具
+func NewConvertUnitTool() (tools.Tool, error) {
+	return tools.NewFunc(
+		"ConvertUnit",
+		"Convert a value between units of data size (bit, B, KB, MiB, ...), duration (ns, ms, s, min, h, d, w) or ratio (ratio, %, ‰, bp).",
+		func(ctx context.Context, req ConvertUnitRequest) (ConvertUnitResponse, error) {
+			value, err := Convert(req.Value, req.From, req.To)
+			if err != nil {
+				return ConvertUnitResponse{}, err
+			}
+			return ConvertUnitResponse{Value: value, Unit: req.To}, nil
+		},
+	)
+}
+
+// Convert 将 value 从 from 单位换算为 to 单位，两者必须属于同一类别
+func Convert(value float64, from, to string) (float64, error) {
+	src, ok := units[strings.ToLower(strings.TrimSpace(from))]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", from)
+	}
+	dst, ok := units[strings.ToLower(strings.TrimSpace(to))]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", to)
+	}
+	if src.family != dst.family {
+		return 0, fmt.Errorf("cannot convert %s (%s) to %s (%s)", from, src.family, to, dst.family)
+	}
+	return value * src.factor / dst.factor, nil
+}
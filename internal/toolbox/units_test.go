@@ -0,0 +1,50 @@
+package toolbox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   float64
+		from    string
+		to      string
+		want    float64
+		wantErr string
+	}{
+		{name: "binary data size", value: 2, from: "GiB", to: "MiB", want: 2048},
+		{name: "decimal data size", value: 1500, from: "MB", to: "GB", want: 1.5},
+		{name: "bits to bytes", value: 8, from: "Mbit", to: "MB", want: 1},
+		{name: "duration", value: 90, from: "min", to: "h", want: 1.5},
+		{name: "ratio", value: 0.25, from: "ratio", to: "%", want: 25},
+		{name: "unit names ignore case", value: 1, from: "kib", to: "B", want: 1024},
+		{name: "unknown unit", value: 1, from: "furlong", to: "m", wantErr: `unknown unit "furlong"`},
+		{name: "different kinds", value: 1, from: "GB", to: "s", wantErr: "cannot convert GB (data) to s (duration)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(tt.value, tt.from, tt.to)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+}
+
+func TestConvertUnitTool(t *testing.T) {
+	tool, err := NewConvertUnitTool()
+	require.NoError(t, err)
+
+	out, err := tool.Handle(context.Background(), `{"value": 3, "from": "d", "to": "h"}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value": 72, "unit": "h"}`, out)
+}
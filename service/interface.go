@@ -23,3 +23,9 @@ type Service interface {
 	Health(ctx context.Context) error
 	Close() error
 }
+
+// Linker 可选接口：为一次工具调用（request 为工具输入的 JSON）生成可在浏览器中打开的链接，
+// 如 Prometheus 的 graph 页面；无法生成时返回 false
+type Linker interface {
+	Link(request string) (string, bool)
+}
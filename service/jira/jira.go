@@ -6,16 +6,13 @@ import (
 	"log"
 	"time"
 
-	"github.com/BurntSushi/toml"
 	jira "github.com/andygrunwald/go-jira"
 	"github.com/go-kratos/blades/tools"
 	"github.com/oneblade/service"
 )
 
 func init() {
-	service.RegisterOptionsParser(service.Jira, func(meta *toml.MetaData, primitive toml.Primitive) (interface{}, error) {
-		return service.ParseOptions[Options](meta, primitive, service.Jira)
-	})
+	service.RegisterOptions[Options](service.Jira)
 
 	service.RegisterService(service.Jira, func(meta service.ServiceMeta, opts interface{}) (service.Service, error) {
 		jiraOpts, ok := opts.(*Options)
@@ -300,6 +297,11 @@ func (s *Service) CreateIssue(ctx context.Context, params *CreateIssueParams) (R
 			}
 		}
 
+		// 子任务需要指定父 issue
+		if params.Parent != "" {
+			jIssue.Fields.Parent = &jira.Parent{Key: params.Parent}
+		}
+
 		return jIssue
 	}
 
@@ -2,6 +2,7 @@ package jira
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -129,6 +130,31 @@ func TestCreateIssueSuccess(t *testing.T) {
 	require.Equal(t, "Test issue", resp.Issue.Summary)
 }
 
+func TestCreateIssueWithParent(t *testing.T) {
+	svc, teardown := newTestService(t, func(mux *http.ServeMux) {
+		mux.HandleFunc("/rest/api/2/issue", func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Fields struct {
+					Parent *jiraapi.Parent `json:"parent"`
+				} `json:"fields"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.NotNil(t, body.Fields.Parent)
+			require.Equal(t, "OPS-1", body.Fields.Parent.Key)
+			fmt.Fprintf(w, createIssueResponseJSON)
+		})
+	})
+	defer teardown()
+
+	resp, err := svc.CreateIssue(context.Background(), &CreateIssueParams{
+		Type:    "Sub-task",
+		Summary: "Action item",
+		Parent:  "OPS-1",
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+}
+
 func TestCreateIssueWithDefaultProject(t *testing.T) {
 	svc, teardown := newTestService(t, func(mux *http.ServeMux) {
 		mux.HandleFunc("/rest/api/2/issue", func(w http.ResponseWriter, r *http.Request) {
@@ -95,4 +95,5 @@ type CreateIssueParams struct {
 	Labels      []string  `json:"labels,omitempty" jsonschema:"Issue labels"`
 	Priority    *Priority `json:"priority,omitempty" jsonschema:"Issue priority"`
 	Assignee    *Assignee `json:"assignee,omitempty" jsonschema:"Issue assignee"`
+	Parent      string    `json:"parent,omitempty" jsonschema:"Parent issue key, required when type is a sub-task type"`
 }
@@ -6,9 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
 	"time"
 
-	"github.com/BurntSushi/toml"
 	"github.com/go-kratos/blades/tools"
 	"github.com/oneblade/service"
 	"github.com/opensearch-project/opensearch-go/v2"
@@ -18,9 +19,7 @@ import (
 const healthCheckTimeout = 5 * time.Second
 
 func init() {
-	service.RegisterOptionsParser(service.OpenSearch, func(meta *toml.MetaData, primitive toml.Primitive) (interface{}, error) {
-		return service.ParseOptions[Options](meta, primitive, service.OpenSearch)
-	})
+	service.RegisterOptions[Options](service.OpenSearch)
 
 	service.RegisterService(service.OpenSearch, func(meta service.ServiceMeta, opts interface{}) (service.Service, error) {
 		osOpts, ok := opts.(*Options)
@@ -36,12 +35,15 @@ type Options struct {
 	Username  string   `toml:"username"`
 	Password  string   `toml:"password"`
 	Index     string   `toml:"index" validate:"required"`
+	// DashboardsURL OpenSearch Dashboards 地址，配置后调查证据附带 Discover 链接
+	DashboardsURL string `toml:"dashboards_url" validate:"omitempty,url"`
 }
 
 type Service struct {
 	name        string
 	description string
 	index       string
+	dashboards  string
 	client      *opensearch.Client
 }
 
@@ -59,6 +61,7 @@ func NewService(meta service.ServiceMeta, opts *Options) (*Service, error) {
 		name:        meta.Name,
 		description: meta.Description,
 		index:       opts.Index,
+		dashboards:  opts.DashboardsURL,
 		client:      client,
 	}, nil
 }
@@ -125,6 +128,102 @@ func (s *Service) AsTool() (tools.Tool, error) {
 	)
 }
 
+// Link 为 search 请求生成 OpenSearch Dashboards 的 Discover 链接：
+// 带上请求体中的 query_string 查询与时间范围，两者都没有或未配置 dashboards_url 时返回 false
+func (s *Service) Link(request string) (string, bool) {
+	if s.dashboards == "" {
+		return "", false
+	}
+	var req Request
+	if err := json.Unmarshal([]byte(request), &req); err != nil || req.Operation != Search || req.Search == nil {
+		return "", false
+	}
+	var body any
+	if err := json.Unmarshal(req.Search.Body, &body); err != nil {
+		return "", false
+	}
+
+	var params []string
+	if from, to, ok := findTimeRange(body); ok {
+		params = append(params, "_g="+url.QueryEscape(fmt.Sprintf("(time:(from:%s,to:%s))", risonString(from), risonString(to))))
+	}
+	if query, ok := findQueryString(body); ok {
+		params = append(params, "_a="+url.QueryEscape(fmt.Sprintf("(query:(language:lucene,query:%s))", risonString(query))))
+	}
+	if len(params) == 0 {
+		return "", false
+	}
+	return strings.TrimSuffix(s.dashboards, "/") + "/app/discover#/?" + strings.Join(params, "&"), true
+}
+
+// findQueryString 在查询 DSL 中查找第一个 query_string / simple_query_string 的查询语句
+func findQueryString(node any) (string, bool) {
+	switch v := node.(type) {
+	case map[string]any:
+		for _, key := range []string{"query_string", "simple_query_string"} {
+			if qs, ok := v[key].(map[string]any); ok {
+				if query, ok := qs["query"].(string); ok && query != "" {
+					return query, true
+				}
+			}
+		}
+		for _, child := range v {
+			if query, ok := findQueryString(child); ok {
+				return query, true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if query, ok := findQueryString(child); ok {
+				return query, true
+			}
+		}
+	}
+	return "", false
+}
+
+// findTimeRange 在查询 DSL 中查找第一个同时带 gte/gt 与 lte/lt 的 range 条件
+func findTimeRange(node any) (from, to string, ok bool) {
+	switch v := node.(type) {
+	case map[string]any:
+		if fields, isMap := v["range"].(map[string]any); isMap {
+			for _, cond := range fields {
+				c, _ := cond.(map[string]any)
+				from, to = firstString(c, "gte", "gt"), firstString(c, "lte", "lt")
+				if from != "" && to != "" {
+					return from, to, true
+				}
+			}
+		}
+		for _, child := range v {
+			if from, to, ok = findTimeRange(child); ok {
+				return from, to, true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if from, to, ok = findTimeRange(child); ok {
+				return from, to, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func firstString(m map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := m[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// risonString 按 Rison 规则编码字符串，Dashboards 的 URL 状态使用该格式
+func risonString(s string) string {
+	return "'" + strings.NewReplacer("!", "!!", "'", "!'").Replace(s) + "'"
+}
+
 func (s *Service) Health(ctx context.Context) error {
 	log.Printf("[opensearch] Health check started")
 
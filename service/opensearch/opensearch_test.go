@@ -62,3 +62,57 @@ func TestService_Handle_InvalidOp(t *testing.T) {
 	}
 	t.Fatal("expected error for invalid operation")
 }
+
+func TestService_Link(t *testing.T) {
+	opts := &Options{
+		Addresses:     []string{"http://localhost:9200"},
+		Index:         "test-index",
+		DashboardsURL: "http://dashboards:5601/",
+	}
+	svc, err := NewService(service.ServiceMeta{Name: "logs"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ service.Linker = svc
+
+	tests := []struct {
+		name    string
+		request string
+		want    string
+		wantOK  bool
+	}{
+		{
+			name:    "query string and time range",
+			request: `{"operation": "search", "search": {"body": {"query": {"bool": {"must": [{"query_string": {"query": "service:checkout AND level:error"}}], "filter": [{"range": {"@timestamp": {"gte": "2026-10-18T01:30:00Z", "lte": "2026-10-18T02:30:00Z"}}}]}}}}}`,
+			want:    "http://dashboards:5601/app/discover#/?_g=%28time%3A%28from%3A%272026-10-18T01%3A30%3A00Z%27%2Cto%3A%272026-10-18T02%3A30%3A00Z%27%29%29&_a=%28query%3A%28language%3Alucene%2Cquery%3A%27service%3Acheckout+AND+level%3Aerror%27%29%29",
+			wantOK:  true,
+		},
+		{
+			name:    "rison escaping",
+			request: `{"operation": "search", "search": {"body": {"query": {"query_string": {"query": "msg:'oops!'"}}}}}`,
+			want:    "http://dashboards:5601/app/discover#/?_a=%28query%3A%28language%3Alucene%2Cquery%3A%27msg%3A%21%27oops%21%21%21%27%27%29%29",
+			wantOK:  true,
+		},
+		{name: "no query string or range", request: `{"operation": "search", "search": {"body": {"query": {"match_all": {}}}}}`},
+		{name: "missing params", request: `{"operation": "search"}`},
+		{name: "invalid json", request: `not json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := svc.Link(tt.request)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Link() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	// 未配置 dashboards_url 时不生成链接
+	opts.DashboardsURL = ""
+	svc, err = NewService(service.ServiceMeta{Name: "logs"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := svc.Link(tests[0].request); ok {
+		t.Errorf("Link() without dashboards_url = %q, want none", got)
+	}
+}
@@ -1,22 +1,44 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/oneblade/config"
 )
 
 // OptionsParser 解析器函数类型
-type OptionsParser func(meta *toml.MetaData, primitive toml.Primitive) (interface{}, error)
+// name 为配置中的 service 名称，用于检测 [services.<name>.options] 中的未知字段与生成错误信息。
+type OptionsParser func(name string, meta *toml.MetaData, primitive toml.Primitive) (interface{}, error)
 
 var optionsParsers = struct {
 	mu      sync.RWMutex
 	parsers map[ServiceType]OptionsParser
+	types   map[ServiceType]reflect.Type
 }{
 	parsers: make(map[ServiceType]OptionsParser),
+	types:   make(map[ServiceType]reflect.Type),
 }
 
+// optionsValidator 校验 Options 结构体上的 validate 标签，错误中的字段名取 toml 标签
+var optionsValidator = func() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}()
+
 // RegisterOptionsParser 注册 Options 解析器
 func RegisterOptionsParser(serviceType ServiceType, parser OptionsParser) {
 	optionsParsers.mu.Lock()
@@ -24,6 +46,17 @@ func RegisterOptionsParser(serviceType ServiceType, parser OptionsParser) {
 	optionsParsers.parsers[serviceType] = parser
 }
 
+// RegisterOptions 以 T 作为 Options 结构体注册解析器，并记录类型用于导出 JSON Schema
+func RegisterOptions[T any](serviceType ServiceType) {
+	RegisterOptionsParser(serviceType, func(name string, meta *toml.MetaData, primitive toml.Primitive) (interface{}, error) {
+		return ParseOptions[T](name, meta, primitive, serviceType)
+	})
+
+	optionsParsers.mu.Lock()
+	defer optionsParsers.mu.Unlock()
+	optionsParsers.types[serviceType] = reflect.TypeFor[T]()
+}
+
 // GetOptionsParser 获取解析器
 func GetOptionsParser(serviceType ServiceType) (OptionsParser, bool) {
 	optionsParsers.mu.RLock()
@@ -32,11 +65,95 @@ func GetOptionsParser(serviceType ServiceType) (OptionsParser, bool) {
 	return parser, ok
 }
 
+// OptionsTypes 返回通过 RegisterOptions 注册的 Options 结构体类型
+func OptionsTypes() map[ServiceType]reflect.Type {
+	optionsParsers.mu.RLock()
+	defer optionsParsers.mu.RUnlock()
+	types := make(map[ServiceType]reflect.Type, len(optionsParsers.types))
+	for serviceType, t := range optionsParsers.types {
+		types[serviceType] = t
+	}
+	return types
+}
+
 // ParseOptions 泛型函数：解析 TOML Primitive 到具体的配置结构
-func ParseOptions[T any](meta *toml.MetaData, primitive toml.Primitive, typeName ServiceType) (*T, error) {
+// 解析后检查 [services.<name>.options] 中的未知字段，并按 validate 标签校验。
+func ParseOptions[T any](name string, meta *toml.MetaData, primitive toml.Primitive, typeName ServiceType) (*T, error) {
 	var opts T
 	if err := meta.PrimitiveDecode(primitive, &opts); err != nil {
 		return nil, fmt.Errorf("decode %s options: %w", typeName, err)
 	}
+	if unknown := undecodedOptions(meta, name); len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown %s option keys: %s", typeName, strings.Join(unknown, ", "))
+	}
+	if err := validateOptions(name, &opts); err != nil {
+		return nil, fmt.Errorf("validate %s options: %w", typeName, err)
+	}
 	return &opts, nil
 }
+
+// optionsKeyPrefix 返回 service options 在配置文件中的 key 前缀
+func optionsKeyPrefix(name string) string {
+	return toml.Key{"services", name, "options"}.String()
+}
+
+// undecodedOptions 返回 [services.<name>.options] 中未被解析的 key
+// meta 在所有 service 之间共享，因此只统计当前 service 前缀下的 key。
+func undecodedOptions(meta *toml.MetaData, name string) []string {
+	var unknown []string
+	for _, key := range meta.Undecoded() {
+		if len(key) > 3 && key[0] == "services" && key[1] == name && key[2] == "options" {
+			unknown = append(unknown, key.String())
+		}
+	}
+	return unknown
+}
+
+// validateOptions 校验 opts，错误信息中的字段以完整的配置 key 表示
+func validateOptions(name string, opts any) error {
+	err := optionsValidator.Struct(opts)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	prefix := optionsKeyPrefix(name)
+	errs := make([]error, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		// Namespace 形如 Options.addresses[0]，去掉结构体名
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		errs = append(errs, fmt.Errorf("%s.%s: failed on %q rule", prefix, field, rule))
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateOptions 解析 loader 中每个 service 的 options，解析时即完成未知字段检查与 validate 标签校验
+// 返回所有 service 的错误，而不是遇到第一个错误就停止。
+func ValidateOptions(loader *config.Loader) error {
+	cfg, err := loader.Get()
+	if err != nil {
+		return fmt.Errorf("failed to get config: %w", err)
+	}
+
+	var errs []error
+	for name, serviceCfg := range cfg.Services {
+		primitive, meta, err := loader.GetServiceOptions(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("service %s: get options: %w", name, err))
+			continue
+		}
+		parser, ok := GetOptionsParser(ServiceType(serviceCfg.Type))
+		if !ok {
+			errs = append(errs, fmt.Errorf("service %s: no parser registered for type %s", name, serviceCfg.Type))
+			continue
+		}
+		if _, err := parser(name, meta, primitive); err != nil {
+			errs = append(errs, fmt.Errorf("service %s: parse options: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testOptions struct {
+	Address   string        `toml:"address" validate:"required,url"`
+	Addresses []string      `toml:"addresses" validate:"omitempty,dive,url"`
+	Timeout   time.Duration `toml:"timeout"`
+}
+
+// decodeServices 解析 [services.<name>] 配置，返回共享的元数据与每个 service 的 options
+func decodeServices(t *testing.T, content string) (*toml.MetaData, map[string]toml.Primitive) {
+	var doc struct {
+		Services map[string]struct {
+			Options toml.Primitive `toml:"options"`
+		} `toml:"services"`
+	}
+	meta, err := toml.Decode(content, &doc)
+	require.NoError(t, err)
+
+	options := make(map[string]toml.Primitive, len(doc.Services))
+	for name, svc := range doc.Services {
+		options[name] = svc.Options
+	}
+	return &meta, options
+}
+
+// TestParseOptions 测试 options 的未知字段检测与 validate 标签校验
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr []string
+	}{
+		{
+			name: "配置正确",
+			content: `
+[services.prom.options]
+address = "http://localhost:9090"
+timeout = "5s"
+`,
+		},
+		{
+			name: "未知字段",
+			content: `
+[services.prom.options]
+address = "http://localhost:9090"
+adress = "http://localhost:9090"
+`,
+			wantErr: []string{"unknown prometheus option keys", "services.prom.options.adress"},
+		},
+		{
+			name: "缺少必填字段",
+			content: `
+[services.prom.options]
+timeout = "5s"
+`,
+			wantErr: []string{`services.prom.options.address: failed on "required" rule`},
+		},
+		{
+			name: "数组元素校验",
+			content: `
+[services.prom.options]
+address = "http://localhost:9090"
+addresses = ["http://a:9200", "not-a-url"]
+`,
+			wantErr: []string{`services.prom.options.addresses[1]: failed on "url" rule`},
+		},
+		{
+			name: "只检查当前 service 的未知字段",
+			content: `
+[services.prom.options]
+address = "http://localhost:9090"
+
+[services.other.options]
+anything = true
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, options := decodeServices(t, tt.content)
+			opts, err := ParseOptions[testOptions]("prom", meta, options["prom"], Prometheus)
+			if len(tt.wantErr) == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, "http://localhost:9090", opts.Address)
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
@@ -4,18 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
-	"github.com/BurntSushi/toml"
 	"github.com/PagerDuty/go-pagerduty"
 	"github.com/go-kratos/blades/tools"
 	"github.com/oneblade/service"
 )
 
 func init() {
-	service.RegisterOptionsParser(service.PagerDuty, func(meta *toml.MetaData, primitive toml.Primitive) (interface{}, error) {
-		return service.ParseOptions[Options](meta, primitive, service.PagerDuty)
-	})
+	service.RegisterOptions[Options](service.PagerDuty)
 
 	service.RegisterService(service.PagerDuty, func(meta service.ServiceMeta, opts interface{}) (service.Service, error) {
 		pdOpts, ok := opts.(*Options)
@@ -71,6 +69,7 @@ const (
 	AcknowledgeIncident Operation = "acknowledge_incident"
 	ResolveIncident     Operation = "resolve_incident"
 	GetIncident         Operation = "get_incident"
+	ListLogEntries      Operation = "list_log_entries"
 )
 
 type Request struct {
@@ -80,6 +79,7 @@ type Request struct {
 	AcknowledgeIncident *AcknowledgeIncidentParams `json:"acknowledge_incident,omitempty"`
 	ResolveIncident     *ResolveIncidentParams     `json:"resolve_incident,omitempty"`
 	GetIncident         *GetIncidentParams         `json:"get_incident,omitempty"`
+	ListLogEntries      *ListLogEntriesParams      `json:"list_log_entries,omitempty"`
 }
 
 type Response struct {
@@ -90,6 +90,7 @@ type Response struct {
 	Total       int        `json:"total,omitempty"`
 	Incident    *Incident  `json:"incident,omitempty"`
 	SnoozeUntil *time.Time `json:"snooze_until,omitempty"`
+	LogEntries  []LogEntry `json:"log_entries,omitempty"`
 }
 
 // === Params ===
@@ -100,6 +101,7 @@ type ListIncidentsParams struct {
 	ServiceIDs   []string `json:"service_ids,omitempty" jsonschema:"Filter by service IDs"`
 	ServiceNames []string `json:"service_names,omitempty" jsonschema:"Filter by service names (will be converted to service IDs)"`
 	Statuses     []string `json:"statuses,omitempty" jsonschema:"Filter by statuses: triggered, acknowledged, resolved"`
+	Urgencies    []string `json:"urgencies,omitempty" jsonschema:"Filter by urgencies: high, low"`
 	Limit        int      `json:"limit,omitempty"`
 }
 
@@ -120,6 +122,11 @@ type GetIncidentParams struct {
 	IncidentID string `json:"incident_id" jsonschema:"required"`
 }
 
+type ListLogEntriesParams struct {
+	IncidentID string `json:"incident_id" jsonschema:"required"`
+	Limit      int    `json:"limit,omitempty" jsonschema:"Maximum number of log entries to return, defaults to 100"`
+}
+
 // Incident simplified structure
 type Incident struct {
 	ID          string `json:"id"`
@@ -132,6 +139,16 @@ type Incident struct {
 	HTMLURL     string `json:"html_url"`
 }
 
+// LogEntry simplified incident timeline entry
+type LogEntry struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Summary   string `json:"summary"`
+	CreatedAt string `json:"created_at"`
+	Agent     string `json:"agent,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+}
+
 // === Logic ===
 
 func (s *Service) Handle(ctx context.Context, req Request) (Response, error) {
@@ -172,6 +189,13 @@ func (s *Service) Handle(ctx context.Context, req Request) (Response, error) {
 		}
 		log.Printf("[pagerduty] Handle: get_incident params present, calling getIncident")
 		return s.getIncident(ctx, req.GetIncident)
+	case ListLogEntries:
+		if req.ListLogEntries == nil {
+			log.Printf("[pagerduty] Handle: list_log_entries params is nil, returning error")
+			return Response{Success: false, Message: "missing list_log_entries params"}, nil
+		}
+		log.Printf("[pagerduty] Handle: list_log_entries params present, calling listLogEntries")
+		return s.listLogEntries(ctx, req.ListLogEntries)
 	default:
 		return Response{Success: false, Message: fmt.Sprintf("unknown operation: %s", req.Operation)}, nil
 	}
@@ -254,6 +278,9 @@ func (s *Service) listIncidents(ctx context.Context, params *ListIncidentsParams
 	if len(params.Statuses) > 0 {
 		opts.Statuses = params.Statuses
 	}
+	if len(params.Urgencies) > 0 {
+		opts.Urgencies = params.Urgencies
+	}
 
 	resp, err := s.client.ListIncidentsWithContext(ctx, opts)
 	if err != nil {
@@ -425,3 +452,42 @@ func (s *Service) getIncident(ctx context.Context, params *GetIncidentParams) (R
 		},
 	}, nil
 }
+
+func (s *Service) listLogEntries(ctx context.Context, params *ListLogEntriesParams) (Response, error) {
+	log.Printf("[pagerduty] listLogEntries called with incident_id=%s, limit=%d", params.IncidentID, params.Limit)
+
+	limit := params.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	resp, err := s.client.ListIncidentLogEntriesWithContext(ctx, params.IncidentID, pagerduty.ListIncidentLogEntriesOptions{
+		Limit:      uint(limit),
+		IsOverview: true,
+	})
+	if err != nil {
+		log.Printf("[pagerduty] listLogEntries failed: %v", err)
+		return Response{Success: false, Message: err.Error()}, nil
+	}
+	log.Printf("[pagerduty] listLogEntries succeeded, found %d entries for incident %s", len(resp.LogEntries), params.IncidentID)
+
+	entries := make([]LogEntry, 0, len(resp.LogEntries))
+	for _, e := range resp.LogEntries {
+		entries = append(entries, LogEntry{
+			ID:        e.ID,
+			Type:      e.Type,
+			Summary:   e.Summary,
+			CreatedAt: e.CreatedAt,
+			Agent:     e.Agent.Summary,
+			Channel:   e.Channel.Type,
+		})
+	}
+	// PagerDuty 按时间倒序返回，时间线按发生顺序排列
+	slices.Reverse(entries)
+
+	return Response{
+		Operation:  ListLogEntries,
+		Success:    true,
+		LogEntries: entries,
+		Total:      len(entries),
+	}, nil
+}
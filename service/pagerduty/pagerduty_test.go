@@ -2,9 +2,14 @@ package pagerduty
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
+	"github.com/PagerDuty/go-pagerduty"
+
 	"github.com/oneblade/service"
 )
 
@@ -64,3 +69,93 @@ func TestService_Handle_ListIncidents_Mock(t *testing.T) {
 		t.Error("expected error message when API call fails")
 	}
 }
+
+func TestService_Handle_ListIncidentsFilters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/incidents" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if got := query["urgencies[]"]; len(got) != 1 || got[0] != "high" {
+			t.Errorf("expected urgencies[]=high, got %v", got)
+		}
+		if got := query["statuses[]"]; len(got) != 2 {
+			t.Errorf("expected 2 statuses, got %v", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"incidents": [{"id": "PABC123", "title": "checkout 5xx", "status": "triggered", "urgency": "high"}]}`)
+	}))
+	defer server.Close()
+
+	svc := NewService(service.ServiceMeta{Name: "pagerduty"}, &Options{APIKey: "dummy"})
+	svc.client = pagerduty.NewClient("dummy", pagerduty.WithAPIEndpoint(server.URL))
+
+	resp, err := svc.Handle(context.Background(), Request{
+		Operation: ListIncidents,
+		ListIncidents: &ListIncidentsParams{
+			Statuses:  []string{"triggered", "acknowledged"},
+			Urgencies: []string{"high"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got message %q", resp.Message)
+	}
+	if len(resp.Incidents) != 1 || resp.Incidents[0].Urgency != "high" {
+		t.Errorf("unexpected incidents: %+v", resp.Incidents)
+	}
+}
+
+func TestService_Handle_ListLogEntries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/incidents/PABC123/log_entries" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("is_overview"); got != "true" {
+			t.Errorf("expected is_overview=true, got %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"log_entries": [
+			{"id": "L2", "type": "resolve_log_entry", "summary": "Resolved by Alice", "created_at": "2025-01-01T10:30:00Z", "agent": {"summary": "Alice"}},
+			{"id": "L1", "type": "trigger_log_entry", "summary": "Triggered through the API", "created_at": "2025-01-01T10:00:00Z", "channel": {"type": "api"}}
+		]}`)
+	}))
+	defer server.Close()
+
+	svc := NewService(service.ServiceMeta{Name: "pagerduty"}, &Options{APIKey: "dummy"})
+	svc.client = pagerduty.NewClient("dummy", pagerduty.WithAPIEndpoint(server.URL))
+
+	resp, err := svc.Handle(context.Background(), Request{
+		Operation:      ListLogEntries,
+		ListLogEntries: &ListLogEntriesParams{IncidentID: "PABC123"},
+	})
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got message %q", resp.Message)
+	}
+	if len(resp.LogEntries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(resp.LogEntries))
+	}
+	// 时间线按发生顺序排列
+	if resp.LogEntries[0].ID != "L1" || resp.LogEntries[0].Channel != "api" {
+		t.Errorf("unexpected first entry: %+v", resp.LogEntries[0])
+	}
+	if resp.LogEntries[1].Agent != "Alice" {
+		t.Errorf("expected agent Alice, got %q", resp.LogEntries[1].Agent)
+	}
+}
+
+func TestService_Handle_ListLogEntriesMissingParams(t *testing.T) {
+	svc := NewService(service.ServiceMeta{Name: "pagerduty"}, &Options{APIKey: "dummy"})
+	resp, err := svc.Handle(context.Background(), Request{Operation: ListLogEntries})
+	if err != nil {
+		t.Fatalf("Handle should return nil error, got: %v", err)
+	}
+	if resp.Success || resp.Message != "missing list_log_entries params" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
@@ -2,11 +2,13 @@ package prometheus
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
 	"time"
 
-	"github.com/BurntSushi/toml"
 	"github.com/go-kratos/blades/tools"
 	"github.com/oneblade/service"
 	"github.com/prometheus/client_golang/api"
@@ -14,9 +16,7 @@ import (
 )
 
 func init() {
-	service.RegisterOptionsParser(service.Prometheus, func(meta *toml.MetaData, primitive toml.Primitive) (interface{}, error) {
-		return service.ParseOptions[Options](meta, primitive, service.Prometheus)
-	})
+	service.RegisterOptions[Options](service.Prometheus)
 
 	service.RegisterService(service.Prometheus, func(meta service.ServiceMeta, opts interface{}) (service.Service, error) {
 		promOpts, ok := opts.(*Options)
@@ -146,6 +146,33 @@ func (s *Service) AsTool() (tools.Tool, error) {
 	)
 }
 
+// Link 返回该查询在 Prometheus graph 页面的链接，实现 service.Linker
+func (s *Service) Link(request string) (string, bool) {
+	var req Request
+	if err := json.Unmarshal([]byte(request), &req); err != nil {
+		return "", false
+	}
+
+	q := url.Values{}
+	q.Set("g0.tab", "0")
+	switch {
+	case req.Operation == QueryRange && req.QueryRange != nil && req.QueryRange.PromQL != "":
+		q.Set("g0.expr", req.QueryRange.PromQL)
+		start, startErr := time.Parse(time.RFC3339, req.QueryRange.StartTime)
+		end, endErr := time.Parse(time.RFC3339, req.QueryRange.EndTime)
+		if startErr == nil && endErr == nil && end.After(start) {
+			q.Set("g0.end_input", end.UTC().Format(time.DateTime))
+			q.Set("g0.range_input", fmt.Sprintf("%ds", int64(end.Sub(start).Seconds())))
+		}
+	case req.Operation == QueryInstant && req.QueryInstant != nil && req.QueryInstant.PromQL != "":
+		q.Set("g0.expr", req.QueryInstant.PromQL)
+		q.Set("g0.tab", "1")
+	default:
+		return "", false
+	}
+	return strings.TrimSuffix(s.address, "/") + "/graph?" + q.Encode(), true
+}
+
 func (s *Service) Health(ctx context.Context) error {
 	log.Printf("[prometheus] Health check started")
 
@@ -61,3 +61,41 @@ func TestService_Handle_InvalidOp(t *testing.T) {
 		t.Error("expected failure for invalid operation")
 	}
 }
+
+func TestService_Link(t *testing.T) {
+	svc, err := NewService(service.ServiceMeta{Name: "prometheus"}, &Options{Address: "http://prom:9090/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var _ service.Linker = svc
+
+	tests := []struct {
+		name    string
+		request string
+		want    string
+		wantOK  bool
+	}{
+		{
+			name:    "query range",
+			request: `{"operation": "query_range", "query_range": {"promql": "rate(http_requests_total{code=\"500\"}[5m])", "start_time": "2026-10-18T01:30:00Z", "end_time": "2026-10-18T02:30:00Z"}}`,
+			want:    "http://prom:9090/graph?g0.end_input=2026-10-18+02%3A30%3A00&g0.expr=rate%28http_requests_total%7Bcode%3D%22500%22%7D%5B5m%5D%29&g0.range_input=3600s&g0.tab=0",
+			wantOK:  true,
+		},
+		{
+			name:    "query instant",
+			request: `{"operation": "query_instant", "query_instant": {"promql": "up"}}`,
+			want:    "http://prom:9090/graph?g0.expr=up&g0.tab=1",
+			wantOK:  true,
+		},
+		{name: "missing params", request: `{"operation": "query_range"}`},
+		{name: "invalid json", request: `not json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := svc.Link(tt.request)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Link() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
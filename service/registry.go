@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/oneblade/config"
 )
@@ -46,11 +48,27 @@ func getServiceFactory(serviceType ServiceType) (ServiceFactory, bool) {
 type Registry struct {
 	mu       sync.RWMutex
 	services map[string]Service
+
+	// seq 为下一次调用的编号，inflight 为尚未结束的调用，见 Acquire
+	seq      uint64
+	inflight map[uint64]struct{}
+	// pending 被替换或删除、等待在途调用结束后关闭的旧服务
+	pending []*pendingClose
+}
+
+// pendingClose 一组等待关闭的旧服务，编号小于 before 的调用全部结束后关闭
+type pendingClose struct {
+	services map[string]Service
+	before   uint64
 }
 
+// DrainTimeout 旧服务等待在途调用结束的最长时间，超时后强制关闭，避免卡住的调用永久占用连接
+var DrainTimeout = 5 * time.Minute
+
 func NewRegistry() *Registry {
 	return &Registry{
 		services: make(map[string]Service),
+		inflight: make(map[uint64]struct{}),
 	}
 }
 
@@ -122,7 +140,7 @@ func (r *Registry) initService(loader *config.Loader, name string, serviceCfg co
 	}
 
 	// Parse options
-	opts, err := parser(meta, primitive)
+	opts, err := parser(name, meta, primitive)
 	if err != nil {
 		return nil, fmt.Errorf("parse options: %w", err)
 	}
@@ -146,6 +164,157 @@ func (r *Registry) initService(loader *config.Loader, name string, serviceCfg co
 	return service, nil
 }
 
+// Build 根据 loader 中的配置构建指定名称的服务，不修改注册表
+// 任意一个服务构建失败时，已构建的服务会被关闭并返回错误。
+func (r *Registry) Build(loader *config.Loader, names []string) (map[string]Service, error) {
+	cfg, err := loader.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+
+	built := make(map[string]Service, len(names))
+	for _, name := range names {
+		serviceCfg, ok := cfg.Services[name]
+		if !ok {
+			closeServices(built)
+			return nil, fmt.Errorf("service %s not found", name)
+		}
+		svc, err := r.initService(loader, name, serviceCfg)
+		if err != nil {
+			closeServices(built)
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		built[name] = svc
+	}
+	return built, nil
+}
+
+// Merged 返回将 updated/removed 应用到当前注册表后的服务列表，不修改注册表
+func (r *Registry) Merged(updated map[string]Service, removed []string) []Service {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	skip := make(map[string]struct{}, len(removed)+len(updated))
+	for _, name := range removed {
+		skip[name] = struct{}{}
+	}
+	for name := range updated {
+		skip[name] = struct{}{}
+	}
+
+	result := make([]Service, 0, len(r.services)+len(updated))
+	for name, s := range r.services {
+		if _, ok := skip[name]; !ok {
+			result = append(result, s)
+		}
+	}
+	for _, s := range updated {
+		result = append(result, s)
+	}
+	return result
+}
+
+// Acquire 标记一次使用注册表中服务的调用开始，调用结束时执行返回的 release（可重复执行）。
+// 配置重载时被替换或删除的服务会等到在此之前开始的调用全部结束后再关闭。
+func (r *Registry) Acquire() (release func()) {
+	r.mu.Lock()
+	id := r.seq
+	r.seq++
+	r.inflight[id] = struct{}{}
+	r.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.inflight, id)
+			ready := r.drainedLocked()
+			r.mu.Unlock()
+			for _, p := range ready {
+				closeDeferred(p, "drained")
+			}
+		})
+	}
+}
+
+// drainedLocked 从 pending 中取出在途调用已全部结束的旧服务，调用方需持有写锁
+func (r *Registry) drainedLocked() []*pendingClose {
+	var ready []*pendingClose
+	r.pending = slices.DeleteFunc(r.pending, func(p *pendingClose) bool {
+		for id := range r.inflight {
+			if id < p.before {
+				return false
+			}
+		}
+		ready = append(ready, p)
+		return true
+	})
+	return ready
+}
+
+// closeDeferred 关闭延迟关闭的旧服务，此时已没有调用方等待结果，错误只记录日志
+func closeDeferred(p *pendingClose, reason string) {
+	if err := closeServices(p.services); err != nil {
+		slog.Warn("service.close.failed", "reason", reason, "error", err)
+		return
+	}
+	slog.Info("service.close.deferred", "services", len(p.services), "reason", reason)
+}
+
+// Swap 原子地新增/替换 updated 中的服务并删除 removed 中的服务
+// 被替换或删除的旧实例在没有在途调用时立即关闭；否则等在途调用结束（最长 DrainTimeout）后再关闭，
+// 避免正在执行的调用因重载中途失败。
+func (r *Registry) Swap(updated map[string]Service, removed []string) error {
+	stale := make(map[string]Service)
+
+	r.mu.Lock()
+	for name, s := range updated {
+		if old, ok := r.services[name]; ok {
+			stale[name] = old
+		}
+		r.services[name] = s
+	}
+	for _, name := range removed {
+		if old, ok := r.services[name]; ok {
+			stale[name] = old
+			delete(r.services, name)
+		}
+	}
+	if len(stale) == 0 || len(r.inflight) == 0 {
+		r.mu.Unlock()
+		return closeServices(stale)
+	}
+	p := &pendingClose{services: stale, before: r.seq}
+	r.pending = append(r.pending, p)
+	inflight := len(r.inflight)
+	r.mu.Unlock()
+
+	slog.Info("service.close.waiting", "services", len(stale), "inflight", inflight, "timeout", DrainTimeout)
+	time.AfterFunc(DrainTimeout, func() {
+		r.mu.Lock()
+		i := slices.Index(r.pending, p)
+		if i >= 0 {
+			r.pending = slices.Delete(r.pending, i, i+1)
+		}
+		r.mu.Unlock()
+		if i >= 0 {
+			closeDeferred(p, "timeout")
+		}
+	})
+	return nil
+}
+
+// closeServices 关闭一组服务并汇总错误
+func closeServices(services map[string]Service) error {
+	var errs []error
+	for name, s := range services {
+		if err := s.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // All 获取所有已注册的服务
 func (r *Registry) All() []Service {
 	r.mu.RLock()
@@ -169,6 +338,13 @@ func (r *Registry) Close() error {
 			errs = append(errs, fmt.Errorf("close %s: %w", name, err))
 		}
 	}
+	// 退出时不再等待在途调用
+	for _, p := range r.pending {
+		if err := closeServices(p.services); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	r.pending = nil
 
 	return errors.Join(errs...)
 }
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/blades/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeTracker 记录是否已关闭的 Service
+type closeTracker struct {
+	name   string
+	closed atomic.Bool
+}
+
+func (s *closeTracker) Name() string                     { return s.name }
+func (s *closeTracker) Type() ServiceType                { return Prometheus }
+func (s *closeTracker) Description() string              { return "" }
+func (s *closeTracker) AsTool() (tools.Tool, error)      { return nil, nil }
+func (s *closeTracker) Health(ctx context.Context) error { return nil }
+func (s *closeTracker) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+func TestRegistry_Swap_ClosesImmediatelyWhenIdle(t *testing.T) {
+	r := NewRegistry()
+	old := &closeTracker{name: "prom"}
+	require.NoError(t, r.Swap(map[string]Service{"prom": old}, nil))
+
+	release := r.Acquire()
+	release()
+
+	require.NoError(t, r.Swap(map[string]Service{"prom": &closeTracker{name: "prom"}}, nil))
+	assert.True(t, old.closed.Load())
+}
+
+func TestRegistry_Swap_WaitsForInflight(t *testing.T) {
+	r := NewRegistry()
+	replaced := &closeTracker{name: "prom"}
+	removed := &closeTracker{name: "logs"}
+	require.NoError(t, r.Swap(map[string]Service{"prom": replaced, "logs": removed}, nil))
+
+	first := r.Acquire()
+	second := r.Acquire()
+	require.NoError(t, r.Swap(map[string]Service{"prom": &closeTracker{name: "prom"}}, []string{"logs"}))
+	assert.Len(t, r.All(), 1)
+
+	// 重载之后开始的调用不影响旧服务的关闭
+	later := r.Acquire()
+	defer later()
+
+	first()
+	first()
+	assert.False(t, replaced.closed.Load(), "仍有重载前开始的调用")
+	assert.False(t, removed.closed.Load())
+
+	second()
+	assert.True(t, replaced.closed.Load())
+	assert.True(t, removed.closed.Load())
+}
+
+func TestRegistry_Swap_DrainTimeout(t *testing.T) {
+	old := DrainTimeout
+	DrainTimeout = 10 * time.Millisecond
+	t.Cleanup(func() { DrainTimeout = old })
+
+	r := NewRegistry()
+	stale := &closeTracker{name: "prom"}
+	require.NoError(t, r.Swap(map[string]Service{"prom": stale}, nil))
+
+	release := r.Acquire()
+	defer release()
+	require.NoError(t, r.Swap(nil, []string{"prom"}))
+	assert.False(t, stale.closed.Load())
+	assert.Eventually(t, stale.closed.Load, time.Second, 5*time.Millisecond, "卡住的调用不能永久占用旧服务")
+}
+
+func TestRegistry_Close_ClosesPending(t *testing.T) {
+	r := NewRegistry()
+	stale := &closeTracker{name: "prom"}
+	require.NoError(t, r.Swap(map[string]Service{"prom": stale}, nil))
+
+	release := r.Acquire()
+	defer release()
+	require.NoError(t, r.Swap(nil, []string{"prom"}))
+	require.NoError(t, r.Close())
+	assert.True(t, stale.closed.Load())
+}
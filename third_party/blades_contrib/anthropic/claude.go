@@ -140,14 +140,7 @@ func (m *Claude) toClaudeParams(req *blades.ModelRequest) (*anthropic.MessageNew
 		case blades.RoleAssistant:
 			params.Messages = append(params.Messages, anthropic.NewAssistantMessage(convertPartsToContent(msg.Parts)...))
 		case blades.RoleTool:
-			var content []anthropic.ContentBlockParamUnion
-			for _, part := range msg.Parts {
-				switch v := any(part).(type) {
-				case blades.ToolPart:
-					content = append(content, anthropic.NewToolResultBlock(v.ID, v.Response, false))
-				}
-			}
-			params.Messages = append(params.Messages, anthropic.NewUserMessage(content...))
+			params.Messages = append(params.Messages, convertToolMessageToClaude(msg)...)
 		}
 	}
 	if len(req.Tools) > 0 {
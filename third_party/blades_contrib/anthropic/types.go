@@ -9,6 +9,41 @@ import (
 	"github.com/go-kratos/blades/tools"
 )
 
+// MetadataThinking is the message metadata key holding the thinking blocks of
+// a response. They are replayed with the assistant tool_use turn: with
+// extended thinking enabled, Anthropic rejects a tool_result whose preceding
+// assistant turn lost its signed thinking block.
+const MetadataThinking = "anthropic_thinking"
+
+// ThinkingBlock is a thinking block returned by the model.
+type ThinkingBlock struct {
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	// Data is the encrypted content of a redacted thinking block.
+	Data string `json:"data,omitempty"`
+}
+
+// thinkingBlocks returns the thinking blocks stored in msg metadata. Messages
+// restored from a persisted session carry them as decoded JSON.
+func thinkingBlocks(msg *blades.Message) []ThinkingBlock {
+	switch v := msg.Metadata[MetadataThinking].(type) {
+	case nil:
+		return nil
+	case []ThinkingBlock:
+		return v
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var blocks []ThinkingBlock
+		if err := json.Unmarshal(raw, &blocks); err != nil {
+			return nil
+		}
+		return blocks
+	}
+}
+
 // convertPartsToContent converts Blades Parts to Claude ContentBlockParamUnion.
 func convertPartsToContent(parts []blades.Part) []anthropic.ContentBlockParamUnion {
 	var content []anthropic.ContentBlockParamUnion
@@ -25,8 +60,8 @@ func convertPartsToContent(parts []blades.Part) []anthropic.ContentBlockParamUni
 func convertBladesToolsToClaude(tools []tools.Tool) ([]anthropic.ToolUnionParam, error) {
 	var claudeTools []anthropic.ToolUnionParam
 	for _, tool := range tools {
-		var inputSchema anthropic.ToolInputSchemaParam
-		schemaBytes, err := json.Marshal(tool.InputSchema)
+		inputSchema := anthropic.ToolInputSchemaParam{}
+		schemaBytes, err := json.Marshal(tool.InputSchema())
 		if err != nil {
 			return nil, fmt.Errorf("marshaling tool schema: %w", err)
 		}
@@ -47,11 +82,54 @@ func convertBladesToolsToClaude(tools []tools.Tool) ([]anthropic.ToolUnionParam,
 	return claudeTools, nil
 }
 
+// convertToolMessageToClaude converts a Blades tool message, whose parts carry
+// both the tool call and its result, into the assistant tool_use turn and
+// the user tool_result turn the API expects. The assistant turn is replayed
+// as the model produced it: thinking blocks first, then text and tool_use
+// blocks in order.
+func convertToolMessageToClaude(msg *blades.Message) []anthropic.MessageParam {
+	var (
+		calls   []anthropic.ContentBlockParamUnion
+		results []anthropic.ContentBlockParamUnion
+	)
+	for _, b := range thinkingBlocks(msg) {
+		if b.Data != "" {
+			calls = append(calls, anthropic.NewRedactedThinkingBlock(b.Data))
+		} else {
+			calls = append(calls, anthropic.NewThinkingBlock(b.Signature, b.Thinking))
+		}
+	}
+	for _, part := range msg.Parts {
+		switch v := part.(type) {
+		case blades.TextPart:
+			if v.Text != "" {
+				calls = append(calls, anthropic.NewTextBlock(v.Text))
+			}
+		case blades.ToolPart:
+			input := json.RawMessage(v.Request)
+			if !json.Valid(input) {
+				input = json.RawMessage("{}")
+			}
+			calls = append(calls, anthropic.NewToolUseBlock(v.ID, input, v.Name))
+			results = append(results, anthropic.NewToolResultBlock(v.ID, v.Response, false))
+		}
+	}
+	return []anthropic.MessageParam{
+		anthropic.NewAssistantMessage(calls...),
+		anthropic.NewUserMessage(results...),
+	}
+}
+
 // convertClaudeToBlades converts a Claude Message to Blades ModelResponse.
 func convertClaudeToBlades(message *anthropic.Message, status blades.Status) (*blades.ModelResponse, error) {
 	msg := blades.NewAssistantMessage(status)
+	var thinking []ThinkingBlock
 	for _, block := range message.Content {
 		switch b := block.AsAny().(type) {
+		case anthropic.ThinkingBlock:
+			thinking = append(thinking, ThinkingBlock{Thinking: b.Thinking, Signature: b.Signature})
+		case anthropic.RedactedThinkingBlock:
+			thinking = append(thinking, ThinkingBlock{Data: b.Data})
 		case anthropic.TextBlock:
 			msg.Parts = append(msg.Parts, blades.TextPart{Text: b.Text})
 		case anthropic.ToolUseBlock:
@@ -59,6 +137,8 @@ func convertClaudeToBlades(message *anthropic.Message, status blades.Status) (*b
 			if err != nil {
 				return nil, err
 			}
+			// Agents execute tools only for RoleTool responses.
+			msg.Role = blades.RoleTool
 			msg.Parts = append(msg.Parts, blades.ToolPart{
 				ID:      b.ID,
 				Name:    b.Name,
@@ -67,6 +147,13 @@ func convertClaudeToBlades(message *anthropic.Message, status blades.Status) (*b
 		}
 	}
 
+	if len(thinking) > 0 {
+		if msg.Metadata == nil {
+			msg.Metadata = make(map[string]any)
+		}
+		msg.Metadata[MetadataThinking] = thinking
+	}
+
 	// TokenUsage 映射（官方 SDK usage 字段）
 	usage := message.Usage
 	input := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
@@ -26,3 +26,33 @@ func TestConvertClaudeToBlades_TokenUsage(t *testing.T) {
 	require.EqualValues(t, 22, out.Message.TokenUsage.TotalTokens)
 }
 
+func TestConvertToolMessageToClaude_ReplaysThinking(t *testing.T) {
+	msg := blades.NewAssistantMessage(blades.StatusCompleted)
+	msg.Role = blades.RoleTool
+	msg.Parts = []blades.Part{
+		blades.TextPart{Text: "先查一下 CPU。"},
+		blades.ToolPart{ID: "call_1", Name: "query_metrics", Request: `{"metric":"cpu"}`, Response: "42%"},
+	}
+	// 从持久化会话恢复的消息，metadata 为解码后的 JSON
+	msg.Metadata = map[string]any{MetadataThinking: []any{
+		map[string]any{"thinking": "需要查询 CPU", "signature": "sig-1"},
+		map[string]any{"data": "redacted-1"},
+	}}
+
+	turns := convertToolMessageToClaude(msg)
+	require.Len(t, turns, 2)
+	blocks := turns[0].Content
+	require.Len(t, blocks, 4)
+	require.NotNil(t, blocks[0].OfThinking)
+	require.Equal(t, "sig-1", blocks[0].OfThinking.Signature)
+	require.Equal(t, "需要查询 CPU", blocks[0].OfThinking.Thinking)
+	require.NotNil(t, blocks[1].OfRedactedThinking)
+	require.Equal(t, "redacted-1", blocks[1].OfRedactedThinking.Data)
+	require.NotNil(t, blocks[2].OfText)
+	require.Equal(t, "先查一下 CPU。", blocks[2].OfText.Text)
+	require.NotNil(t, blocks[3].OfToolUse)
+	require.Equal(t, "call_1", blocks[3].OfToolUse.ID)
+
+	require.Len(t, turns[1].Content, 1)
+	require.NotNil(t, turns[1].Content[0].OfToolResult)
+}
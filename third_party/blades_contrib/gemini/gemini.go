@@ -99,6 +99,10 @@ func (m *Gemini) toGenerateConfig(req *blades.ModelRequest) (*genai.GenerateCont
 			return nil, fmt.Errorf("converting tools: %w", err)
 		}
 		config.Tools = tools
+	} else if req.OutputSchema != nil {
+		// JSON mode cannot be combined with function calling.
+		config.ResponseMIMEType = "application/json"
+		config.ResponseJsonSchema = req.OutputSchema
 	}
 	return &config, nil
 }
@@ -147,7 +151,7 @@ func (m *Gemini) NewStreaming(ctx context.Context, req *blades.ModelRequest) bla
 					if candidate.Content == nil {
 						candidate.Content = &genai.Content{Parts: []*genai.Part{}}
 					}
-					candidate.Content.Parts = append(candidate.Content.Parts, chunkCandidate.Content.Parts...)
+					candidate.Content.Parts = appendParts(candidate.Content.Parts, chunkCandidate.Content.Parts)
 				}
 				// Update finish reason if present
 				if chunkCandidate.FinishReason != "" {
@@ -171,3 +175,23 @@ func (m *Gemini) NewStreaming(ctx context.Context, req *blades.ModelRequest) bla
 	}
 }
 
+// appendParts appends streamed parts, merging consecutive text deltas into a
+// single part so the accumulated message reads as one text.
+func appendParts(parts, chunk []*genai.Part) []*genai.Part {
+	for _, part := range chunk {
+		if n := len(parts); n > 0 && isPlainText(parts[n-1]) && isPlainText(part) {
+			merged := *parts[n-1]
+			merged.Text += part.Text
+			parts[n-1] = &merged
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+func isPlainText(part *genai.Part) bool {
+	return part != nil && part.Text != "" && !part.Thought &&
+		part.FunctionCall == nil && part.FunctionResponse == nil &&
+		part.InlineData == nil && part.FileData == nil
+}
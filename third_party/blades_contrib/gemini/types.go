@@ -26,23 +26,40 @@ func convertMessageToGenAI(req *blades.ModelRequest) (*genai.Content, []*genai.C
 		case blades.RoleAssistant:
 			contents = append(contents, &genai.Content{Role: genai.RoleModel, Parts: convertMessagePartsToGenAI(msg.Parts)})
 		case blades.RoleTool:
-			var parts []*genai.Part
-			for _, part := range msg.Parts {
-				switch v := any(part).(type) {
-				case blades.ToolPart:
-					response := map[string]any{}
-					if err := json.Unmarshal([]byte(v.Response), &response); err != nil {
-						response["output"] = v.Response
-					}
-					parts = append(parts, genai.NewPartFromFunctionResponse(v.Name, response))
-				}
-			}
-			contents = append(contents, &genai.Content{Role: genai.RoleUser, Parts: parts})
+			contents = append(contents, convertToolMessageToGenAI(msg)...)
 		}
 	}
 	return system, contents, nil
 }
 
+// convertToolMessageToGenAI converts a Blades tool message, whose parts carry
+// both the tool call and its result, into the model function-call turn and
+// the user function-response turn the API expects.
+func convertToolMessageToGenAI(msg *blades.Message) []*genai.Content {
+	var calls, responses []*genai.Part
+	for _, part := range msg.Parts {
+		v, ok := part.(blades.ToolPart)
+		if !ok {
+			continue
+		}
+		args := map[string]any{}
+		if v.Request != "" {
+			_ = json.Unmarshal([]byte(v.Request), &args)
+		}
+		calls = append(calls, &genai.Part{FunctionCall: &genai.FunctionCall{ID: v.ID, Name: v.Name, Args: args}})
+
+		response := map[string]any{}
+		if err := json.Unmarshal([]byte(v.Response), &response); err != nil {
+			response = map[string]any{"output": v.Response}
+		}
+		responses = append(responses, &genai.Part{FunctionResponse: &genai.FunctionResponse{ID: v.ID, Name: v.Name, Response: response}})
+	}
+	return []*genai.Content{
+		{Role: genai.RoleModel, Parts: calls},
+		{Role: genai.RoleUser, Parts: responses},
+	}
+}
+
 func convertMessagePartsToGenAI(parts []blades.Part) []*genai.Part {
 	res := make([]*genai.Part, 0, len(parts))
 	for _, part := range parts {
@@ -108,6 +125,10 @@ func convertGenAIToBlades(resp *genai.GenerateContentResponse, status blades.Sta
 			if err != nil {
 				return nil, err
 			}
+			// Agents execute tools only for RoleTool responses.
+			if _, ok := bladesPart.(blades.ToolPart); ok {
+				message.Role = blades.RoleTool
+			}
 			message.Parts = append(message.Parts, bladesPart)
 		}
 	}
@@ -129,6 +150,19 @@ func convertGenAIToBlades(resp *genai.GenerateContentResponse, status blades.Sta
 
 // convertGenAIPartToBlades converts a GenAI Part to Blades Part
 func convertGenAIPartToBlades(part *genai.Part) (blades.Part, error) {
+	if fc := part.FunctionCall; fc != nil {
+		args, err := json.Marshal(fc.Args)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling function call args: %w", err)
+		}
+		// The Gemini API usually omits call IDs; blades needs one to pair
+		// the call with its result.
+		id := fc.ID
+		if id == "" {
+			id = "call_" + blades.NewMessageID()
+		}
+		return blades.ToolPart{ID: id, Name: fc.Name, Request: string(args)}, nil
+	}
 	if part.FileData != nil {
 		return blades.FilePart{
 			URI:      part.FileData.FileURI,